@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"sort"
 
 	"k8s.io/utils/ptr"
@@ -31,9 +33,7 @@ var knownControllers = map[string]func(controllerName string, mgr ctrl.Manager)
 	"teamRoleBindingController": (&teamrbaccontrollers.TeamRoleBindingReconciler{}).SetupWithManager,
 
 	// Plugin controllers.
-	"plugin": (&plugincontrollers.PluginReconciler{
-		KubeRuntimeOpts: kubeClientOpts,
-	}).SetupWithManager,
+	"plugin":       startPluginReconciler,
 	"pluginPreset": (&plugincontrollers.PluginPresetReconciler{}).SetupWithManager,
 
 	// Cluster controllers
@@ -83,8 +83,26 @@ func startClusterReconciler(name string, mgr ctrl.Manager) error {
 		setupLog.Info("Setting renewRemoteClusterBearerTokenAfter to half of remoteClusterBearerTokenValidity")
 		renewRemoteClusterBearerTokenAfter = remoteClusterBearerTokenValidity / 2
 	}
+	var tunnelProxyCA []byte
+	if clusterTunnelProxyCAFile != "" {
+		var err error
+		if tunnelProxyCA, err = os.ReadFile(clusterTunnelProxyCAFile); err != nil {
+			return fmt.Errorf("failed to read CA of the tunnel server proxy: %w", err)
+		}
+	}
 	return (&clustercontrollers.RemoteClusterReconciler{
 		RemoteClusterBearerTokenValidity:   remoteClusterBearerTokenValidity,
 		RenewRemoteClusterBearerTokenAfter: renewRemoteClusterBearerTokenAfter,
+		TunnelProxyURL:                     clusterTunnelProxyURL,
+		TunnelProxyCA:                      tunnelProxyCA,
+		InventoryInterval:                  clusterInventoryInterval,
+		DeprecatedAPIScanInterval:          clusterDeprecatedAPIScanInterval,
+	}).SetupWithManager(name, mgr)
+}
+
+func startPluginReconciler(name string, mgr ctrl.Manager) error {
+	return (&plugincontrollers.PluginReconciler{
+		KubeRuntimeOpts:                   kubeClientOpts,
+		ExposedServiceHealthCheckInterval: exposedServiceHealthCheckInterval,
 	}).SetupWithManager(name, mgr)
 }
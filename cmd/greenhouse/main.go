@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	goflag "flag"
 	"fmt"
@@ -21,6 +22,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -28,9 +30,12 @@ import (
 	greenhousesapv1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/clientutil"
 	"github.com/cloudoperators/greenhouse/pkg/common"
+	clustercontrollers "github.com/cloudoperators/greenhouse/pkg/controllers/cluster"
+	plugincontrollers "github.com/cloudoperators/greenhouse/pkg/controllers/plugin"
 	dexapi "github.com/cloudoperators/greenhouse/pkg/dex/api"
 	"github.com/cloudoperators/greenhouse/pkg/features"
 	"github.com/cloudoperators/greenhouse/pkg/helm"
+	"github.com/cloudoperators/greenhouse/pkg/tunnel"
 	"github.com/cloudoperators/greenhouse/pkg/version"
 )
 
@@ -63,8 +68,18 @@ var (
 	enabledControllers []string
 	remoteClusterBearerTokenValidity,
 	renewRemoteClusterBearerTokenAfter time.Duration
-	kubeClientOpts clientutil.RuntimeOptions
-	featureFlags   *features.Features
+	exposedServiceHealthCheckInterval time.Duration
+	tunnelBindAddress                 string
+	clusterTunnelProxyURL             string
+	tunnelAdvertiseAddress            string
+	tunnelTLSCertFile                 string
+	tunnelTLSKeyFile                  string
+	tunnelInsecure                    bool
+	clusterTunnelProxyCAFile          string
+	clusterInventoryInterval          time.Duration
+	clusterDeprecatedAPIScanInterval  time.Duration
+	kubeClientOpts                    clientutil.RuntimeOptions
+	featureFlags                      *features.Features
 )
 
 func init() {
@@ -95,6 +110,33 @@ func main() {
 	flag.DurationVar(&renewRemoteClusterBearerTokenAfter, "renew-remote-cluster-bearer-token-after", defaultRenewRemoteClusterBearerTokenAfter,
 		"Renew the bearer token we requested for remote clusters after this duration")
 
+	flag.DurationVar(&exposedServiceHealthCheckInterval, "exposed-service-health-check-interval", plugincontrollers.DefaultExposedServiceHealthCheckInterval,
+		"Interval in which exposed services of Plugins with a configured health check are probed")
+
+	flag.StringVar(&tunnelBindAddress, "tunnel-bind-address", "",
+		"The address the tunnel server for clusters with the reverse-tunnel access mode binds to. The tunnel server is disabled if empty")
+
+	flag.StringVar(&clusterTunnelProxyURL, "cluster-tunnel-proxy-url", "",
+		"The URL of the tunnel server proxy used to access clusters with the reverse-tunnel access mode, e.g. https://greenhouse-tunnel.greenhouse.svc:8090")
+
+	flag.StringVar(&clusterTunnelProxyCAFile, "cluster-tunnel-proxy-ca-file", "",
+		"Path to the CA certificate clients verify the tunnel server proxy with. Stored in the Secret of clusters with the reverse-tunnel access mode")
+
+	flag.StringVar(&tunnelAdvertiseAddress, "tunnel-advertise-address", "",
+		"The address other replicas reach the tunnel server of this replica at, e.g. $(POD_IP):8090. Required if more than one replica runs the tunnel server")
+
+	flag.StringVar(&tunnelTLSCertFile, "tunnel-tls-cert-file", "",
+		"Path to the TLS certificate the tunnel server is served with. The certificate is reloaded when the file changes")
+	flag.StringVar(&tunnelTLSKeyFile, "tunnel-tls-key-file", "",
+		"Path to the private key of the TLS certificate of the tunnel server")
+	flag.BoolVar(&tunnelInsecure, "tunnel-insecure", false,
+		"Serve the tunnel server without TLS. Tokens are transmitted in plain text, only use this if TLS is terminated in front of the tunnel server or for development")
+
+	flag.DurationVar(&clusterInventoryInterval, "cluster-inventory-interval", clustercontrollers.DefaultInventoryInterval,
+		"Interval in which the inventory of clusters is collected. Collecting the inventory is disabled if zero")
+	flag.DurationVar(&clusterDeprecatedAPIScanInterval, "cluster-deprecated-api-scan-interval", clustercontrollers.DefaultDeprecatedAPIScanInterval,
+		"Interval in which clusters are scanned for API versions removed in upcoming Kubernetes versions. Scanning is disabled if zero")
+
 	flag.StringVar(&common.DNSDomain, "dns-domain", "",
 		"The DNS domain to use for the Greenhouse central cluster")
 
@@ -169,6 +211,13 @@ func main() {
 		}
 	}
 
+	// Register the tunnel server for clusters with the reverse-tunnel access mode.
+	if mode != webhookOnlyMode && tunnelBindAddress != "" {
+		tlsConfig, err := tunnelServerTLSConfig(mgr)
+		handleError(err, "unable to configure TLS of tunnel server")
+		handleError(mgr.Add(tunnel.NewServer(mgr.GetClient(), tunnelBindAddress, tunnelAdvertiseAddress, tlsConfig)), "unable to create tunnel server")
+	}
+
 	// Register webhooks.
 	if mode != controllerOnlyMode {
 		for webhookName, hookFunc := range knownWebhooks {
@@ -228,3 +277,26 @@ func calculateManagerMode() (managerMode, error) {
 
 	return regularMode, nil
 }
+
+// tunnelServerTLSConfig returns the TLS configuration of the tunnel server with a certificate reloaded on change.
+// It returns nil if the tunnel server is explicitly configured to serve without TLS.
+func tunnelServerTLSConfig(mgr ctrl.Manager) (*tls.Config, error) {
+	if tunnelTLSCertFile == "" || tunnelTLSKeyFile == "" {
+		if tunnelInsecure {
+			setupLog.Info("WARN: tunnel server is served without TLS")
+			return nil, nil
+		}
+		return nil, errors.New("tunnel server requires --tunnel-tls-cert-file and --tunnel-tls-key-file unless --tunnel-insecure is set")
+	}
+	watcher, err := certwatcher.New(tunnelTLSCertFile, tunnelTLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(watcher); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: watcher.GetCertificate,
+	}, nil
+}
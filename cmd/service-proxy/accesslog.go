@@ -0,0 +1,182 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cloudoperators/greenhouse/pkg/common"
+)
+
+const (
+	// accessLogOutputStdout writes the access log to stdout.
+	accessLogOutputStdout = "stdout"
+)
+
+// accessLogEntry is a single line of the access log written as JSON.
+type accessLogEntry struct {
+	Time            string  `json:"time"`
+	Cluster         string  `json:"cluster,omitempty"`
+	Route           string  `json:"route"`
+	Namespace       string  `json:"namespace,omitempty"`
+	Service         string  `json:"service,omitempty"`
+	Method          string  `json:"method"`
+	Path            string  `json:"path"`
+	Status          int     `json:"status"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Bytes           int64   `json:"bytes"`
+	User            string  `json:"user,omitempty"`
+	RemoteAddr      string  `json:"remote_addr,omitempty"`
+	UserAgent       string  `json:"user_agent,omitempty"`
+}
+
+// AccessLogger writes structured access logs for requests handled by the service-proxy.
+type AccessLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	// sampleRatio is the ratio of successful requests being logged. Failed requests are always logged.
+	sampleRatio float64
+	// userHeader is the request header containing the authenticated user. It is only trustworthy if set by an authenticating proxy
+	// in front of the service-proxy that removes it from client requests, as any client can set it otherwise.
+	userHeader string
+	now        func() time.Time
+}
+
+// NewAccessLogger returns an AccessLogger writing JSON lines to w.
+// The sampleRatio must be in the range [0, 1] and applies to requests with a status code below 400.
+// The user is only logged if a userHeader set by an authenticating proxy is given.
+func NewAccessLogger(w io.Writer, sampleRatio float64, userHeader string) (*AccessLogger, error) {
+	if sampleRatio < 0 || sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid access log sample ratio %v, must be between 0 and 1", sampleRatio)
+	}
+	return &AccessLogger{
+		enc:         json.NewEncoder(w),
+		sampleRatio: sampleRatio,
+		userHeader:  userHeader,
+		now:         time.Now,
+	}, nil
+}
+
+// OpenAccessLogOutput returns the writer for the given access log output, which must be closed on shutdown.
+// The output is either "stdout" or the path of a file the log is appended to.
+func OpenAccessLogOutput(output string) (io.WriteCloser, error) {
+	if output == accessLogOutputStdout {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	f, err := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open access log file %s: %w", output, err)
+	}
+	return f, nil
+}
+
+// Handler wraps the given handler and logs every request with the route information known to the ProxyManager.
+func (a *AccessLogger) Handler(pm *ProxyManager, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := a.now()
+		recorder := &responseRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		if recorder.status < http.StatusBadRequest && !a.sampled() {
+			return
+		}
+		entry := accessLogEntry{
+			Time:            start.UTC().Format(time.RFC3339Nano),
+			Route:           "https://" + req.Host,
+			Method:          req.Method,
+			Path:            req.URL.Path,
+			Status:          recorder.status,
+			DurationSeconds: a.now().Sub(start).Seconds(),
+			Bytes:           recorder.bytes,
+			User:            a.userFromRequest(req),
+			RemoteAddr:      req.RemoteAddr,
+			UserAgent:       req.UserAgent(),
+		}
+		if cluster, err := common.ExtractCluster(req.Host); err == nil {
+			entry.Cluster = cluster
+			if route, found := pm.GetClusterRoute(cluster, entry.Route); found {
+				entry.Namespace = route.namespace
+				entry.Service = route.serviceName
+			}
+		}
+		a.write(entry)
+	})
+}
+
+func (a *AccessLogger) sampled() bool {
+	switch a.sampleRatio {
+	case 1:
+		return true
+	case 0:
+		return false
+	default:
+		return rand.Float64() < a.sampleRatio //nolint:gosec
+	}
+}
+
+func (a *AccessLogger) write(entry accessLogEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// errors writing the access log must not affect the proxied request
+	_ = a.enc.Encode(entry) //nolint:errcheck
+}
+
+// userFromRequest returns the authenticated user of the request if a trusted user header is configured.
+func (a *AccessLogger) userFromRequest(req *http.Request) string {
+	if a.userHeader == "" {
+		return ""
+	}
+	return req.Header.Get(a.userHeader)
+}
+
+// nopWriteCloser prevents closing stdout on shutdown.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// responseRecorder captures the status code and the number of bytes written to the client.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Flush is required as the reverse proxy flushes streamed responses immediately.
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the underlying ResponseWriter.
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
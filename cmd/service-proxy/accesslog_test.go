@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestAccessLogger tests that the access logger writes a JSON line per request containing the route information
+// and that sampling only applies to successful requests.
+func TestAccessLogger(t *testing.T) {
+	tests := []struct {
+		name            string
+		host            string
+		status          int
+		sampleRatio     float64
+		userHeader      string
+		user            string
+		expectedUser    string
+		expectLogged    bool
+		expectedCluster string
+		expectedService string
+	}{
+		{
+			name:            "known route is logged with service",
+			host:            "cluster--1234567.organisation.basedomain",
+			status:          http.StatusOK,
+			sampleRatio:     1,
+			userHeader:      "X-Forwarded-Email",
+			user:            "jane.doe@example.com",
+			expectedUser:    "jane.doe@example.com",
+			expectLogged:    true,
+			expectedCluster: "cluster",
+			expectedService: "test-service",
+		},
+		{
+			name:            "user is not logged without trusted user header",
+			host:            "cluster--1234567.organisation.basedomain",
+			status:          http.StatusOK,
+			sampleRatio:     1,
+			user:            "forged@example.com",
+			expectLogged:    true,
+			expectedCluster: "cluster",
+			expectedService: "test-service",
+		},
+		{
+			name:            "unknown route is logged without service",
+			host:            "unknown--7654321.organisation.basedomain",
+			status:          http.StatusBadGateway,
+			sampleRatio:     1,
+			expectLogged:    true,
+			expectedCluster: "unknown",
+		},
+		{
+			name:         "successful request is not logged if sampled out",
+			host:         "cluster--1234567.organisation.basedomain",
+			status:       http.StatusOK,
+			sampleRatio:  0,
+			expectLogged: false,
+		},
+		{
+			name:            "failed request is logged although sampled out",
+			host:            "cluster--1234567.organisation.basedomain",
+			status:          http.StatusNotFound,
+			sampleRatio:     0,
+			expectLogged:    true,
+			expectedCluster: "cluster",
+			expectedService: "test-service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := NewProxyManager()
+			pm.setCluster("cluster", &clusterRoutes{
+				routes: map[string]route{
+					"https://cluster--1234567.organisation.basedomain": {
+						namespace:   "kube-monitoring",
+						serviceName: "test-service",
+					},
+				},
+			})
+
+			buf := new(bytes.Buffer)
+			accessLogger, err := NewAccessLogger(buf, tt.sampleRatio, tt.userHeader)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			handler := accessLogger.Handler(pm, http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				rw.WriteHeader(tt.status)
+				_, _ = rw.Write([]byte("hello")) //nolint:errcheck
+			}))
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://"+tt.host+"/dashboard", http.NoBody)
+			if err != nil {
+				t.Fatal("failed to create request")
+			}
+			if tt.user != "" {
+				req.Header.Set("X-Forwarded-Email", tt.user)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if !tt.expectLogged {
+				if buf.Len() != 0 {
+					t.Errorf("expected no access log, got %s", buf.String())
+				}
+				return
+			}
+
+			var entry accessLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("failed to unmarshal access log %q: %v", buf.String(), err)
+			}
+			if entry.Status != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, entry.Status)
+			}
+			if entry.Bytes != int64(len("hello")) {
+				t.Errorf("expected %d bytes, got %d", len("hello"), entry.Bytes)
+			}
+			if entry.Cluster != tt.expectedCluster {
+				t.Errorf("expected cluster %q, got %q", tt.expectedCluster, entry.Cluster)
+			}
+			if entry.Service != tt.expectedService {
+				t.Errorf("expected service %q, got %q", tt.expectedService, entry.Service)
+			}
+			if entry.User != tt.expectedUser {
+				t.Errorf("expected user %q, got %q", tt.expectedUser, entry.User)
+			}
+			if entry.Path != "/dashboard" {
+				t.Errorf("expected path /dashboard, got %s", entry.Path)
+			}
+		})
+	}
+}
+
+func TestNewAccessLoggerInvalidSampleRatio(t *testing.T) {
+	for _, ratio := range []float64{-0.1, 1.5} {
+		if _, err := NewAccessLogger(new(bytes.Buffer), ratio, ""); err == nil {
+			t.Errorf("expected error for sample ratio %v", ratio)
+		}
+	}
+}
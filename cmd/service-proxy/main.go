@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"io"
 	"net/http"
 	"os"
 	"syscall"
@@ -34,6 +35,8 @@ import (
 func main() {
 	var kubecontext, kubenamespace string
 	var listenAddr, metricsAddr, healthzAddr string
+	var accessLogOutput, accessLogUserHeader string
+	var accessLogSampleRatio float64
 
 	opts := zap.Options{
 		Development: true,
@@ -53,6 +56,10 @@ func main() {
 	flag.StringVar(&listenAddr, "listen-addr", ":8080", "proxy listen address")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":6543", "bind address for metrics")
 	flag.StringVar(&healthzAddr, "healz-addr", ":8081", "bind address for health checks")
+	flag.StringVar(&accessLogOutput, "access-log", "", "write access logs as JSON to \"stdout\" or the given file path, disabled if empty")
+	flag.StringVar(&accessLogUserHeader, "access-log-user-header", "", "request header containing the authenticated user written to the access log, e.g. X-Forwarded-Email. "+
+		"It must only be set if an authenticating proxy in front of the service-proxy sets this header and removes it from client requests, as clients can forge it otherwise")
+	flag.Float64Var(&accessLogSampleRatio, "access-log-sample-ratio", 1, "ratio of successful requests written to the access log, failed requests are always logged")
 	flag.Parse()
 
 	k8sConfig, err := ctrlconfig.GetConfigWithContext(kubecontext)
@@ -107,9 +114,23 @@ func main() {
 			cancelMgr()
 		})
 
+	handler := InstrumentHandler(pm, metrics.Registry)
+	var accessLogOut io.WriteCloser
+	if accessLogOutput != "" {
+		accessLogOut, err = OpenAccessLogOutput(accessLogOutput)
+		if err != nil {
+			failWithError(err, "Failed to open access log output")
+		}
+		accessLogger, err := NewAccessLogger(accessLogOut, accessLogSampleRatio, accessLogUserHeader)
+		if err != nil {
+			failWithError(err, "Failed to create access logger")
+		}
+		handler = accessLogger.Handler(pm, handler)
+	}
+
 	frontend := http.Server{
 		Addr:    listenAddr,
-		Handler: InstrumentHandler(pm, metrics.Registry),
+		Handler: handler,
 	}
 
 	g.Add(
@@ -124,6 +145,12 @@ func main() {
 		})
 
 	err = g.Run()
+	// The server was shut down, so no more requests are logged.
+	if accessLogOut != nil {
+		if closeErr := accessLogOut.Close(); closeErr != nil {
+			logger.Error(closeErr, "Failed to close access log output")
+		}
+	}
 	var signalErr run.SignalError
 	if ok := errors.As(err, &signalErr); ok {
 		return
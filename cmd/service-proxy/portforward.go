@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// serviceHostSuffix is the suffix of the host used in routes in the port-forward routing mode.
+// The host has the format <service>.<namespace>.svc and is resolved by the portForwardDialer.
+const serviceHostSuffix = ".svc"
+
+// portForwardDialer dials services in the remote cluster through the pods/portforward subresource.
+// This avoids the path rewriting of the API server service proxy and only uses the API server to establish a stream.
+type portForwardDialer struct {
+	restConfig *rest.Config
+	clientset  kubernetes.Interface
+	requestID  int
+	mu         sync.Mutex
+}
+
+// newPortForwardTransport returns a transport dialing every connection through a port-forward to a ready pod backing the service.
+func newPortForwardTransport(restConfig *rest.Config) (http.RoundTripper, error) {
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &portForwardDialer{restConfig: restConfig, clientset: clientset}
+	return &http.Transport{
+		DialContext:     dialer.DialContext,
+		IdleConnTimeout: 90 * time.Second,
+		// The API server proxy does not verify the certificates of services either.
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+	}, nil
+}
+
+// DialContext resolves the service addressed by addr to a ready pod and opens a port-forward stream to it.
+func (d *portForwardDialer) DialContext(ctx context.Context, _, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	namespace, serviceName, err := parseServiceHost(host)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseInt(portStr, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %s: %w", portStr, err)
+	}
+	podName, podPort, err := d.resolveServiceEndpoint(ctx, namespace, serviceName, int32(port))
+	if err != nil {
+		return nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(d.restConfig)
+	if err != nil {
+		return nil, err
+	}
+	portForwardURL := d.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(namespace).Name(podName).SubResource("portforward").URL()
+	streamConn, _, err := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, portForwardURL).
+		Dial(portforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to port-forward to pod %s/%s: %w", namespace, podName, err)
+	}
+	conn, err := d.newStreamConn(streamConn, podPort)
+	if err != nil {
+		streamConn.Close() //nolint:errcheck
+		return nil, err
+	}
+	return conn, nil
+}
+
+// newStreamConn creates the error and data stream for a single forwarded connection.
+func (d *portForwardDialer) newStreamConn(streamConn httpstream.Connection, port int32) (*portForwardConn, error) {
+	d.mu.Lock()
+	d.requestID++
+	requestID := d.requestID
+	d.mu.Unlock()
+
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(int(port)))
+	headers.Set(corev1.PortForwardRequestIDHeader, strconv.Itoa(requestID))
+	errorStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error stream: %w", err)
+	}
+	// the error stream is only read from
+	errorStream.Close() //nolint:errcheck
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := streamConn.CreateStream(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create data stream: %w", err)
+	}
+	conn := &portForwardConn{streamConn: streamConn, dataStream: dataStream}
+	go func() {
+		// close the connection if the kubelet reports an error, e.g. the pod is gone
+		if message, err := io.ReadAll(errorStream); err == nil && len(message) > 0 {
+			conn.Close() //nolint:errcheck
+		}
+	}()
+	return conn, nil
+}
+
+// resolveServiceEndpoint returns the name of a ready pod backing the service and the target port for the given service port.
+func (d *portForwardDialer) resolveServiceEndpoint(ctx context.Context, namespace, serviceName string, port int32) (podName string, podPort int32, err error) {
+	svc, err := d.clientset.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to get service %s/%s: %w", namespace, serviceName, err)
+	}
+	var servicePort *corev1.ServicePort
+	for idx := range svc.Spec.Ports {
+		if svc.Spec.Ports[idx].Port == port {
+			servicePort = &svc.Spec.Ports[idx]
+			break
+		}
+	}
+	if servicePort == nil {
+		return "", 0, fmt.Errorf("service %s/%s has no port %d", namespace, serviceName, port)
+	}
+
+	slices, err := d.clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + serviceName,
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to list endpoints of service %s/%s: %w", namespace, serviceName, err)
+	}
+	return readyPodEndpoint(slices.Items, servicePort.Name)
+}
+
+// readyPodEndpoint returns the first ready pod and its port matching the service port name from the given EndpointSlices.
+func readyPodEndpoint(slices []discoveryv1.EndpointSlice, portName string) (podName string, podPort int32, err error) {
+	for _, slice := range slices {
+		var slicePort *int32
+		for _, p := range slice.Ports {
+			if p.Port != nil && (p.Name == nil && portName == "" || p.Name != nil && *p.Name == portName) {
+				slicePort = p.Port
+				break
+			}
+		}
+		if slicePort == nil {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" {
+				continue
+			}
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			return endpoint.TargetRef.Name, *slicePort, nil
+		}
+	}
+	return "", 0, errors.New("no ready pod found for service")
+}
+
+// parseServiceHost returns the namespace and name of the service from a host in the format <service>.<namespace>.svc.
+func parseServiceHost(host string) (namespace, serviceName string, err error) {
+	parts := strings.Split(strings.TrimSuffix(host, serviceHostSuffix), ".")
+	if !strings.HasSuffix(host, serviceHostSuffix) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid service host: %s", host)
+	}
+	return parts[1], parts[0], nil
+}
+
+// portForwardConn is a net.Conn backed by the data stream of a port-forward connection.
+type portForwardConn struct {
+	streamConn httpstream.Connection
+	dataStream httpstream.Stream
+	closeOnce  sync.Once
+}
+
+func (c *portForwardConn) Read(b []byte) (int, error) {
+	return c.dataStream.Read(b)
+}
+
+func (c *portForwardConn) Write(b []byte) (int, error) {
+	return c.dataStream.Write(b)
+}
+
+func (c *portForwardConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.dataStream.Reset() //nolint:errcheck
+		err = c.streamConn.Close()
+	})
+	return err
+}
+
+func (c *portForwardConn) LocalAddr() net.Addr {
+	return portForwardAddr{}
+}
+
+func (c *portForwardConn) RemoteAddr() net.Addr {
+	return portForwardAddr{}
+}
+
+// SetDeadline is a no-op, timeouts are handled by the streams of the underlying connection.
+func (c *portForwardConn) SetDeadline(_ time.Time) error {
+	return nil
+}
+
+func (c *portForwardConn) SetReadDeadline(_ time.Time) error {
+	return nil
+}
+
+func (c *portForwardConn) SetWriteDeadline(_ time.Time) error {
+	return nil
+}
+
+type portForwardAddr struct{}
+
+func (portForwardAddr) Network() string {
+	return "portforward"
+}
+
+func (portForwardAddr) String() string {
+	return "portforward"
+}
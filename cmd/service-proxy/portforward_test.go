@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+)
+
+func TestParseServiceHost(t *testing.T) {
+	tests := []struct {
+		name              string
+		host              string
+		expectedNamespace string
+		expectedService   string
+		expectErr         bool
+	}{
+		{
+			name:              "valid service host",
+			host:              "test-service.kube-monitoring.svc",
+			expectedNamespace: "kube-monitoring",
+			expectedService:   "test-service",
+		},
+		{
+			name:      "missing svc suffix",
+			host:      "test-service.kube-monitoring",
+			expectErr: true,
+		},
+		{
+			name:      "missing namespace",
+			host:      "test-service.svc",
+			expectErr: true,
+		},
+		{
+			name:      "fully qualified host",
+			host:      "test-service.kube-monitoring.svc.cluster.local",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, serviceName, err := parseServiceHost(tt.host)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error for host %s", tt.host)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if namespace != tt.expectedNamespace || serviceName != tt.expectedService {
+				t.Errorf("expected %s/%s, got %s/%s", tt.expectedNamespace, tt.expectedService, namespace, serviceName)
+			}
+		})
+	}
+}
+
+// TestResolveServiceEndpoint tests that the service port is resolved to the target port of a ready pod.
+func TestResolveServiceEndpoint(t *testing.T) {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "kube-monitoring"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "metrics", Port: 9090, TargetPort: intstr.FromString("metrics")},
+				{Name: "web", Port: 8080, TargetPort: intstr.FromString("http")},
+			},
+		},
+	}
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-service-abcde",
+			Namespace: "kube-monitoring",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "test-service"},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{Name: ptr.To("metrics"), Port: ptr.To[int32](19090)},
+			{Name: ptr.To("web"), Port: ptr.To[int32](3000)},
+		},
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(false)},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "not-ready-pod"},
+			},
+			{
+				Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)},
+				TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: "ready-pod"},
+			},
+		},
+	}
+	dialer := &portForwardDialer{clientset: fake.NewClientset(service, endpointSlice)}
+
+	podName, podPort, err := dialer.resolveServiceEndpoint(context.Background(), "kube-monitoring", "test-service", 8080)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if podName != "ready-pod" {
+		t.Errorf("expected pod ready-pod, got %s", podName)
+	}
+	if podPort != 3000 {
+		t.Errorf("expected port 3000, got %d", podPort)
+	}
+
+	if _, _, err := dialer.resolveServiceEndpoint(context.Background(), "kube-monitoring", "test-service", 1234); err == nil {
+		t.Error("expected error for unknown service port")
+	}
+}
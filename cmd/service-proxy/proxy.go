@@ -6,8 +6,9 @@
 // When reconciling cluster routes from a Plugin with an exposed service, the ProxyManager persists the transport and URL necessary to proxy an incoming request in the clusters map.
 // The transport is created using the credentials from the Secret associated with the cluster.
 // The URL is created using the k8s API server proxy: https://kubernetes.io/docs/tasks/access-application-cluster/access-cluster-services/#discovering-builtin-services
+// If the Secret is annotated with the port-forward routing mode, the URL addresses the service directly and the transport tunnels connections through a port-forward to a ready pod of the service.
 // Entries are saved by cluster and exposed URL. E.g., if a Plugin exposes a service with the URL "https://cluster1--1234567.example.com" on cluster-1, the route is saved in
-// clusters["cluster-1"]clusterRoutes{
+// index.clusters["cluster-1"]clusterRoutes{
 //   transport: net/http.RoundTripper{$TransportCreatedFromClusterKubeConfig},
 //   routes: map[string]route ["https://cluster-1--1234567.organisation.basedomain"]route{
 //     url: *net/url.URL {$BackenURL},
@@ -15,6 +16,8 @@
 //     namespace: $serviceNamespace
 //   }
 // }.
+// Secrets are reconciled to (re)build all routes of a cluster, Plugins are reconciled to only update the routes of the Plugin.
+// The route index is copy-on-write, so incoming requests read it without locking. See routes.go.
 
 package main
 
@@ -27,6 +30,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
@@ -35,8 +39,8 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
@@ -45,28 +49,22 @@ import (
 )
 
 func NewProxyManager() *ProxyManager {
-	return &ProxyManager{
-		clusters: make(map[string]clusterRoutes),
+	pm := &ProxyManager{
+		pluginClusters: make(map[types.NamespacedName]string),
 	}
+	pm.index.Store(newRouteIndex())
+	return pm
 }
 
 type ProxyManager struct {
-	client   client.Client
-	logger   logr.Logger
-	clusters map[string]clusterRoutes
-	mu       sync.RWMutex
-}
-
-type clusterRoutes struct {
-	transport http.RoundTripper
-	routes    map[string]route
-}
-
-// route holds the url the request should be forwarded to and the service name and namespace as metadata
-type route struct {
-	url         *url.URL
-	serviceName string
-	namespace   string
+	client client.Client
+	logger logr.Logger
+	// index is read without locking when proxying requests
+	index atomic.Pointer[routeIndex]
+	// pluginClusters maps the namespaced name of a Plugin to the cluster its routes are stored for
+	pluginClusters map[types.NamespacedName]string
+	// mu serializes updates of the index and pluginClusters
+	mu sync.Mutex
 }
 
 // contextClusterKey is used to embed a cluster in the context
@@ -95,7 +93,7 @@ func (pm *ProxyManager) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		logger.Info("Removing deleted cluster from cache")
 		pm.mu.Lock()
 		defer pm.mu.Unlock()
-		delete(pm.clusters, req.Name)
+		pm.deleteCluster(req.Name)
 		return ctrl.Result{}, nil
 	}
 
@@ -109,20 +107,20 @@ func (pm *ProxyManager) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	}
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	if _, ok := pm.clusters[req.Name]; !ok {
+	if _, ok := pm.index.Load().clusters[req.Name]; !ok {
 		logger.Info("Adding cluster")
 	} else {
 		logger.Info("Updating cluster")
 	}
-	cls := clusterRoutes{}
-	if cls.transport, err = rest.TransportFor(restConfig); err != nil {
+	cls := &clusterRoutes{
+		routingMode: secret.GetAnnotations()[greenhouseapis.ServiceProxyRoutingModeAnnotation],
+		routes:      make(map[string]route),
+		plugins:     make(map[types.NamespacedName][]string),
+	}
+	if cls.transport, err = transportForRoutingMode(cls.routingMode, restConfig); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to create transport for cluster %s: %w", req.Name, err)
 	}
-
-	cls.routes = make(map[string]route)
-
-	k8sAPIURL, err := url.Parse(restConfig.Host)
-	if err != nil {
+	if cls.apiURL, err = url.Parse(restConfig.Host); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to parse api url: %w", err)
 	}
 
@@ -131,37 +129,122 @@ func (pm *ProxyManager) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, fmt.Errorf("failed to get plugins for cluster %s: %w", req.Name, err)
 	}
 	for _, plugin := range plugins {
-		for url, svc := range plugin.Status.ExposedServices {
-			u := *k8sAPIURL // copy URL struct
-
-			if svc.Protocol != nil && *svc.Protocol == "https" {
-				// For HTTPS, format should be: https:<service_name>:<port>
-				u.Path = fmt.Sprintf("/api/v1/namespaces/%s/services/https:%s:%d/proxy", svc.Namespace, svc.Name, svc.Port)
-			} else {
-				// For HTTP, format should be: <service_name>:<port>
-				u.Path = fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%d/proxy", svc.Namespace, svc.Name, svc.Port)
+		key := client.ObjectKeyFromObject(&plugin)
+		for url, r := range cls.routesForPlugin(&plugin) {
+			cls.routes[url] = r
+			cls.plugins[key] = append(cls.plugins[key], url)
+		}
+	}
+	// drop Plugins which are no longer routed to this cluster
+	if previous, ok := pm.index.Load().clusters[req.Name]; ok {
+		for plugin := range previous.plugins {
+			if _, ok := cls.plugins[plugin]; !ok {
+				delete(pm.pluginClusters, plugin)
 			}
-			cls.routes[url] = route{url: &u, namespace: svc.Namespace, serviceName: svc.Name}
 		}
 	}
-	logger.Info("Added routes for cluster", "cluster", req.Name, "routes", cls.routes)
-	pm.clusters[req.Name] = cls
+	for plugin := range cls.plugins {
+		if previous, ok := pm.pluginClusters[plugin]; ok && previous != req.Name {
+			pm.deletePluginRoutes(plugin)
+		}
+		pm.pluginClusters[plugin] = req.Name
+	}
+	logger.Info("Added routes for cluster", "cluster", req.Name, "routingMode", cls.routingMode, "routes", cls.routes)
+	pm.setCluster(req.Name, cls)
 
 	return ctrl.Result{}, nil
 }
 
+// reconcilePlugin updates the routes of a single Plugin without rebuilding the routes of its cluster.
+func (pm *ProxyManager) reconcilePlugin(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	var plugin = new(greenhousev1alpha1.Plugin)
+	err := pm.client.Get(ctx, req.NamespacedName, plugin)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	// ignore deleted plugins and plugins not tied to a cluster
+	if err != nil || plugin.DeletionTimestamp != nil || plugin.Spec.ClusterName == "" {
+		pm.deletePluginRoutes(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+	cls, ok := pm.index.Load().clusters[plugin.Spec.ClusterName]
+	if !ok {
+		// the routes are added once the cluster is reconciled
+		pm.deletePluginRoutes(req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+	routes := cls.routesForPlugin(plugin)
+	pm.setPluginRoutes(plugin.Spec.ClusterName, req.NamespacedName, routes)
+	logger.Info("Updated routes for plugin", "cluster", plugin.Spec.ClusterName, "routes", routes)
+	return ctrl.Result{}, nil
+}
+
+// routesForPlugin returns the routes for the services exposed by the Plugin on this cluster.
+func (cls *clusterRoutes) routesForPlugin(plugin *greenhousev1alpha1.Plugin) map[string]route {
+	routes := make(map[string]route, len(plugin.Status.ExposedServices))
+	for url, svc := range plugin.Status.ExposedServices {
+		routes[url] = route{url: serviceRouteURL(cls.routingMode, cls.apiURL, svc), namespace: svc.Namespace, serviceName: svc.Name}
+	}
+	return routes
+}
+
+// transportForRoutingMode returns the transport used to reach exposed services of a cluster with the given routing mode.
+func transportForRoutingMode(routingMode string, restConfig *rest.Config) (http.RoundTripper, error) {
+	switch routingMode {
+	case "", greenhouseapis.ServiceProxyRoutingModeAPIServerProxy:
+		return rest.TransportFor(restConfig)
+	case greenhouseapis.ServiceProxyRoutingModePortForward:
+		return newPortForwardTransport(restConfig)
+	default:
+		return nil, fmt.Errorf("unknown routing mode %q", routingMode)
+	}
+}
+
+// serviceRouteURL returns the URL requests to the exposed service are forwarded to.
+// With the port-forward routing mode the URL addresses the service directly and is resolved by the portForwardDialer,
+// otherwise the URL points to the k8s API server service proxy.
+func serviceRouteURL(routingMode string, k8sAPIURL *url.URL, svc greenhousev1alpha1.Service) *url.URL {
+	isHTTPS := svc.Protocol != nil && *svc.Protocol == "https"
+	if routingMode == greenhouseapis.ServiceProxyRoutingModePortForward {
+		u := &url.URL{Scheme: "http", Host: fmt.Sprintf("%s.%s%s:%d", svc.Name, svc.Namespace, serviceHostSuffix, svc.Port)}
+		if isHTTPS {
+			u.Scheme = "https"
+		}
+		return u
+	}
+
+	u := *k8sAPIURL // copy URL struct
+	if isHTTPS {
+		// For HTTPS, format should be: https:<service_name>:<port>
+		u.Path = fmt.Sprintf("/api/v1/namespaces/%s/services/https:%s:%d/proxy", svc.Namespace, svc.Name, svc.Port)
+	} else {
+		// For HTTP, format should be: <service_name>:<port>
+		u.Path = fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%d/proxy", svc.Namespace, svc.Name, svc.Port)
+	}
+	return &u
+}
+
 func (pm *ProxyManager) SetupWithManager(name string, mgr ctrl.Manager) error {
 	pm.client = mgr.GetClient()
 	pm.logger = mgr.GetLogger()
 
-	return ctrl.NewControllerManagedBy(mgr).
+	err := ctrl.NewControllerManagedBy(mgr).
 		Named(name).
 		For(&v1.Secret{}, builder.WithPredicates(
 			clientutil.PredicateFilterBySecretTypes(greenhouseapis.SecretTypeKubeConfig, greenhouseapis.SecretTypeOIDCConfig),
 		)).
-		// Watch plugins to be notified about exposed services
-		Watches(&greenhousev1alpha1.Plugin{}, handler.EnqueueRequestsFromMapFunc(enqueuePluginForCluster)).
 		Complete(pm)
+	if err != nil {
+		return err
+	}
+	// Reconcile plugins to incrementally update the routes of their exposed services
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(name + "-plugin").
+		For(&greenhousev1alpha1.Plugin{}).
+		Complete(reconcile.Func(pm.reconcilePlugin))
 }
 
 // ReverseProxy returns a reverse proxy that will forward requests to the cluster
@@ -177,14 +260,12 @@ func (pm *ProxyManager) ReverseProxy() *httputil.ReverseProxy {
 
 // RoundTrip executes the rewritten request and uses the transport created when reconciling the cluster with respective credentials
 func (pm *ProxyManager) RoundTrip(req *http.Request) (resp *http.Response, err error) {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
 	cluster, ok := req.Context().Value(contextClusterKey{}).(string)
 
 	if !ok {
 		return nil, fmt.Errorf("no upstream found for: %s", req.URL.String())
 	}
-	cls, ok := pm.clusters[cluster]
+	cls, ok := pm.index.Load().clusters[cluster]
 	if !ok {
 		return nil, fmt.Errorf("cluster %s not found", cluster)
 	}
@@ -294,9 +375,7 @@ func (pm *ProxyManager) pluginsForCluster(ctx context.Context, cluster, namespac
 
 // GetClusterRoute returns the route information for a given cluster and incoming URL
 func (pm *ProxyManager) GetClusterRoute(cluster, inURL string) (*route, bool) {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
-	cls, ok := pm.clusters[cluster]
+	cls, ok := pm.index.Load().clusters[cluster]
 	if !ok {
 		return nil, false
 	}
@@ -306,15 +385,3 @@ func (pm *ProxyManager) GetClusterRoute(cluster, inURL string) (*route, bool) {
 	}
 	return &getRoute, true
 }
-
-func enqueuePluginForCluster(_ context.Context, o client.Object) []ctrl.Request {
-	plugin, ok := o.(*greenhousev1alpha1.Plugin)
-	if !ok {
-		return nil
-	}
-	// ignore plugins not tied to a cluster
-	if plugin.Spec.ClusterName == "" {
-		return nil
-	}
-	return []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: plugin.Namespace, Name: plugin.Spec.ClusterName}}}
-}
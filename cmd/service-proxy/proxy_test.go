@@ -78,7 +78,7 @@ func TestRewrite(t *testing.T) {
 			}
 
 			pm := NewProxyManager()
-			pm.clusters["cluster"] = clusterRoutes{
+			pm.setCluster("cluster", &clusterRoutes{
 				routes: map[string]route{
 					inputURL.Scheme + "://" + inputURL.Host: {
 						url:         proxyURL,
@@ -86,7 +86,7 @@ func TestRewrite(t *testing.T) {
 						serviceName: "test-service",
 					},
 				},
-			}
+			})
 
 			r, err := http.NewRequestWithContext(context.Background(), http.MethodGet, inputURL.String(), http.NoBody)
 			if err != nil {
@@ -122,24 +122,43 @@ func TestRewrite(t *testing.T) {
 func TestURLGenerationWithProtocols(t *testing.T) {
 	// Test cases to cover different protocol scenarios
 	tests := []struct {
-		name            string
-		protocol        *string
-		expectedURLPath string
+		name        string
+		protocol    *string
+		routingMode string
+		expectedURL string
 	}{
 		{
-			name:            "default_no_protocol",
-			protocol:        nil,
-			expectedURLPath: "/api/v1/namespaces/namespace/services/test:8080/proxy",
+			name:        "default_no_protocol",
+			protocol:    nil,
+			expectedURL: "https://apiserver.test/api/v1/namespaces/namespace/services/test:8080/proxy",
+		},
+		{
+			name:        "explicit_http_protocol",
+			protocol:    pointer("http"),
+			expectedURL: "https://apiserver.test/api/v1/namespaces/namespace/services/test:8080/proxy",
+		},
+		{
+			name:        "explicit_https_protocol",
+			protocol:    pointer("https"),
+			expectedURL: "https://apiserver.test/api/v1/namespaces/namespace/services/https:test:8080/proxy",
+		},
+		{
+			name:        "explicit_apiserver_proxy_routing_mode",
+			protocol:    nil,
+			routingMode: greenhouseapis.ServiceProxyRoutingModeAPIServerProxy,
+			expectedURL: "https://apiserver.test/api/v1/namespaces/namespace/services/test:8080/proxy",
 		},
 		{
-			name:            "explicit_http_protocol",
-			protocol:        pointer("http"),
-			expectedURLPath: "/api/v1/namespaces/namespace/services/test:8080/proxy",
+			name:        "port_forward_routing_mode",
+			protocol:    nil,
+			routingMode: greenhouseapis.ServiceProxyRoutingModePortForward,
+			expectedURL: "http://test.namespace.svc:8080",
 		},
 		{
-			name:            "explicit_https_protocol",
-			protocol:        pointer("https"),
-			expectedURLPath: "/api/v1/namespaces/namespace/services/https:test:8080/proxy",
+			name:        "port_forward_routing_mode_https_protocol",
+			protocol:    pointer("https"),
+			routingMode: greenhouseapis.ServiceProxyRoutingModePortForward,
+			expectedURL: "https://test.namespace.svc:8080",
 		},
 	}
 
@@ -168,8 +187,9 @@ func TestURLGenerationWithProtocols(t *testing.T) {
 				},
 				&v1.Secret{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:      "cluster-1",
-						Namespace: "namespace",
+						Name:        "cluster-1",
+						Namespace:   "namespace",
+						Annotations: map[string]string{greenhouseapis.ServiceProxyRoutingModeAnnotation: tc.routingMode},
 					},
 					Type: "greenhouse.sap/kubeconfig",
 					Data: map[string][]byte{
@@ -199,15 +219,14 @@ users:
 				t.Errorf("expected no error, got: %s", err)
 			}
 
-			route, ok := pm.clusters["cluster-1"].routes["https://cluster-1--1234567.org.basedomain"]
+			route, ok := pm.index.Load().clusters["cluster-1"].routes["https://cluster-1--1234567.org.basedomain"]
 			if !ok {
 				t.Fatal("expected route to be added")
 			}
 
 			targetURL := route.url
-			expectedURL := "https://apiserver.test" + tc.expectedURLPath
-			if targetURL.String() != expectedURL {
-				t.Errorf("expected url %s, got %s", expectedURL, targetURL.String())
+			if targetURL.String() != tc.expectedURL {
+				t.Errorf("expected url %s, got %s", tc.expectedURL, targetURL.String())
 			}
 		})
 	}
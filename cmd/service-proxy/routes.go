@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// routeIndex is an immutable snapshot of the routes of all clusters.
+// It must not be modified once it is published. Changes are applied to a copy which atomically replaces the current index (copy-on-write),
+// so that incoming requests look up routes without locking while Plugins and clusters are reconciled.
+type routeIndex struct {
+	clusters map[string]*clusterRoutes
+}
+
+// clusterRoutes holds the transport and the routes of a cluster. It is immutable like the routeIndex containing it.
+type clusterRoutes struct {
+	transport   http.RoundTripper
+	apiURL      *url.URL
+	routingMode string
+	// routes maps the exposed URL to the route.
+	routes map[string]route
+	// plugins maps the namespaced name of a Plugin to the exposed URLs of its services.
+	// Plugins are namespaced per organization, so Plugins of different organizations may share a name.
+	plugins map[types.NamespacedName][]string
+}
+
+// route holds the url the request should be forwarded to and the service name and namespace as metadata
+type route struct {
+	url         *url.URL
+	serviceName string
+	namespace   string
+}
+
+func newRouteIndex() *routeIndex {
+	return &routeIndex{clusters: make(map[string]*clusterRoutes)}
+}
+
+// withCluster returns a copy of the index with the given cluster added or replaced.
+func (idx *routeIndex) withCluster(name string, cls *clusterRoutes) *routeIndex {
+	clusters := maps.Clone(idx.clusters)
+	clusters[name] = cls
+	return &routeIndex{clusters: clusters}
+}
+
+// withoutCluster returns a copy of the index with the given cluster removed.
+func (idx *routeIndex) withoutCluster(name string) *routeIndex {
+	clusters := maps.Clone(idx.clusters)
+	delete(clusters, name)
+	return &routeIndex{clusters: clusters}
+}
+
+// withPluginRoutes returns a copy of the cluster with the routes of the given Plugin replaced.
+// Passing no routes removes the Plugin from the cluster. Only the routes of this cluster are copied.
+func (cls *clusterRoutes) withPluginRoutes(plugin types.NamespacedName, routes map[string]route) *clusterRoutes {
+	updated := *cls
+	updated.routes = make(map[string]route, len(cls.routes)+len(routes))
+	maps.Copy(updated.routes, cls.routes)
+	updated.plugins = make(map[types.NamespacedName][]string, len(cls.plugins)+1)
+	maps.Copy(updated.plugins, cls.plugins)
+	for _, u := range cls.plugins[plugin] {
+		delete(updated.routes, u)
+	}
+	delete(updated.plugins, plugin)
+	if len(routes) == 0 {
+		return &updated
+	}
+	urls := make([]string, 0, len(routes))
+	for u, r := range routes {
+		updated.routes[u] = r
+		urls = append(urls, u)
+	}
+	updated.plugins[plugin] = urls
+	return &updated
+}
+
+// setCluster publishes the given cluster. The caller must hold pm.mu.
+func (pm *ProxyManager) setCluster(name string, cls *clusterRoutes) {
+	pm.index.Store(pm.index.Load().withCluster(name, cls))
+}
+
+// deleteCluster removes the cluster and all its routes. The caller must hold pm.mu.
+func (pm *ProxyManager) deleteCluster(name string) {
+	idx := pm.index.Load()
+	cls, ok := idx.clusters[name]
+	if !ok {
+		return
+	}
+	for plugin := range cls.plugins {
+		delete(pm.pluginClusters, plugin)
+	}
+	pm.index.Store(idx.withoutCluster(name))
+}
+
+// setPluginRoutes replaces the routes of a Plugin on the given cluster and removes its routes from any other cluster.
+// It returns false if the cluster is not known yet, the routes are added once the cluster is reconciled. The caller must hold pm.mu.
+func (pm *ProxyManager) setPluginRoutes(cluster string, plugin types.NamespacedName, routes map[string]route) bool {
+	if previous, ok := pm.pluginClusters[plugin]; ok && previous != cluster {
+		pm.deletePluginRoutes(plugin)
+	}
+	cls, ok := pm.index.Load().clusters[cluster]
+	if !ok {
+		return false
+	}
+	pm.setCluster(cluster, cls.withPluginRoutes(plugin, routes))
+	pm.pluginClusters[plugin] = cluster
+	return true
+}
+
+// deletePluginRoutes removes all routes of a Plugin. The caller must hold pm.mu.
+func (pm *ProxyManager) deletePluginRoutes(plugin types.NamespacedName) {
+	cluster, ok := pm.pluginClusters[plugin]
+	if !ok {
+		return
+	}
+	delete(pm.pluginClusters, plugin)
+	if cls, ok := pm.index.Load().clusters[cluster]; ok {
+		pm.setCluster(cluster, cls.withPluginRoutes(plugin, nil))
+	}
+}
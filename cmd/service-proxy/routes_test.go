@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+// TestReconcilePlugin tests that reconciling a Plugin only updates its own routes and moves them along with the Plugin.
+func TestReconcilePlugin(t *testing.T) {
+	apiURL, err := url.Parse("https://apiserver.test")
+	if err != nil {
+		t.Fatal("failed to parse URL")
+	}
+	plugin := &greenhousev1alpha1.Plugin{
+		ObjectMeta: metav1.ObjectMeta{Name: "plugin1", Namespace: "namespace"},
+		Spec:       greenhousev1alpha1.PluginSpec{ClusterName: "cluster-1"},
+		Status: greenhousev1alpha1.PluginStatus{
+			ExposedServices: map[string]greenhousev1alpha1.Service{
+				"https://plugin1--1234567.org.basedomain": {Namespace: "namespace", Name: "test", Port: 8080},
+			},
+		},
+	}
+	otherRoute := route{serviceName: "other", namespace: "namespace"}
+
+	pm := NewProxyManager()
+	pm.client = fake.NewClientBuilder().WithScheme(test.GreenhouseV1Alpha1Scheme()).WithObjects(plugin).Build()
+	pm.setCluster("cluster-1", (&clusterRoutes{apiURL: apiURL}).withPluginRoutes(types.NamespacedName{Namespace: "namespace", Name: "plugin2"}, map[string]route{"https://plugin2--1234567.org.basedomain": otherRoute}))
+	pm.setCluster("cluster-2", &clusterRoutes{apiURL: apiURL})
+
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "plugin1", Namespace: "namespace"}}
+	if _, err := pm.reconcilePlugin(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pm.GetClusterRoute("cluster-1", "https://plugin1--1234567.org.basedomain"); !ok {
+		t.Error("expected route of plugin1 to be added to cluster-1")
+	}
+	if _, ok := pm.GetClusterRoute("cluster-1", "https://plugin2--1234567.org.basedomain"); !ok {
+		t.Error("expected route of plugin2 to be kept")
+	}
+
+	// move the plugin to another cluster
+	plugin.Spec.ClusterName = "cluster-2"
+	if err := pm.client.Update(ctx, plugin); err != nil {
+		t.Fatalf("failed to update plugin: %v", err)
+	}
+	if _, err := pm.reconcilePlugin(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pm.GetClusterRoute("cluster-1", "https://plugin1--1234567.org.basedomain"); ok {
+		t.Error("expected route of plugin1 to be removed from cluster-1")
+	}
+	if _, ok := pm.GetClusterRoute("cluster-2", "https://plugin1--1234567.org.basedomain"); !ok {
+		t.Error("expected route of plugin1 to be added to cluster-2")
+	}
+
+	// delete the plugin
+	if err := pm.client.Delete(ctx, plugin); err != nil {
+		t.Fatalf("failed to delete plugin: %v", err)
+	}
+	if _, err := pm.reconcilePlugin(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pm.GetClusterRoute("cluster-2", "https://plugin1--1234567.org.basedomain"); ok {
+		t.Error("expected route of plugin1 to be removed from cluster-2")
+	}
+	if _, ok := pm.GetClusterRoute("cluster-1", "https://plugin2--1234567.org.basedomain"); !ok {
+		t.Error("expected route of plugin2 to be kept")
+	}
+}
+
+// TestReconcilePluginsWithSameName tests that Plugins with the same name in different organizations keep their routes.
+func TestReconcilePluginsWithSameName(t *testing.T) {
+	apiURL, err := url.Parse("https://apiserver.test")
+	if err != nil {
+		t.Fatal("failed to parse URL")
+	}
+	newPlugin := func(namespace, cluster, exposedURL string) *greenhousev1alpha1.Plugin {
+		return &greenhousev1alpha1.Plugin{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-monitoring", Namespace: namespace},
+			Spec:       greenhousev1alpha1.PluginSpec{ClusterName: cluster},
+			Status: greenhousev1alpha1.PluginStatus{
+				ExposedServices: map[string]greenhousev1alpha1.Service{
+					exposedURL: {Namespace: "kube-monitoring", Name: "prometheus", Port: 9090},
+				},
+			},
+		}
+	}
+	pluginA := newPlugin("org-a", "cluster-a", "https://cluster-a--1234567.org-a.basedomain")
+	pluginB := newPlugin("org-b", "cluster-b", "https://cluster-b--1234567.org-b.basedomain")
+
+	pm := NewProxyManager()
+	pm.client = fake.NewClientBuilder().WithScheme(test.GreenhouseV1Alpha1Scheme()).WithObjects(pluginA, pluginB).Build()
+	pm.setCluster("cluster-a", &clusterRoutes{apiURL: apiURL})
+	pm.setCluster("cluster-b", &clusterRoutes{apiURL: apiURL})
+
+	ctx := context.Background()
+	// reconcile both Plugins repeatedly, the second round must not evict the routes of the other organization
+	for range 2 {
+		for _, plugin := range []*greenhousev1alpha1.Plugin{pluginA, pluginB} {
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: plugin.Name, Namespace: plugin.Namespace}}
+			if _, err := pm.reconcilePlugin(ctx, req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+	if _, ok := pm.GetClusterRoute("cluster-a", "https://cluster-a--1234567.org-a.basedomain"); !ok {
+		t.Error("expected route of the Plugin in org-a to be kept on cluster-a")
+	}
+	if _, ok := pm.GetClusterRoute("cluster-b", "https://cluster-b--1234567.org-b.basedomain"); !ok {
+		t.Error("expected route of the Plugin in org-b to be kept on cluster-b")
+	}
+
+	// deleting the Plugin of one organization keeps the routes of the other
+	if err := pm.client.Delete(ctx, pluginA); err != nil {
+		t.Fatalf("failed to delete plugin: %v", err)
+	}
+	if _, err := pm.reconcilePlugin(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: pluginA.Name, Namespace: pluginA.Namespace}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := pm.GetClusterRoute("cluster-a", "https://cluster-a--1234567.org-a.basedomain"); ok {
+		t.Error("expected route of the deleted Plugin in org-a to be removed")
+	}
+	if _, ok := pm.GetClusterRoute("cluster-b", "https://cluster-b--1234567.org-b.basedomain"); !ok {
+		t.Error("expected route of the Plugin in org-b to be kept")
+	}
+}
+
+// BenchmarkGetClusterRoute measures the route lookup latency with 10k routes while routes are updated concurrently.
+func BenchmarkGetClusterRoute(b *testing.B) {
+	const numClusters, numPluginsPerCluster = 100, 100
+	type lookup struct{ cluster, url string }
+	lookups := make([]lookup, 0, numClusters*numPluginsPerCluster)
+	pm := NewProxyManager()
+	for c := range numClusters {
+		cls := &clusterRoutes{}
+		for p := range numPluginsPerCluster {
+			u := fmt.Sprintf("https://plugin-%d--cluster-%d.org.basedomain", p, c)
+			cls = cls.withPluginRoutes(types.NamespacedName{Namespace: "namespace", Name: fmt.Sprintf("plugin-%d-%d", c, p)}, map[string]route{u: {serviceName: "test", namespace: "namespace"}})
+			lookups = append(lookups, lookup{cluster: fmt.Sprintf("cluster-%d", c), url: u})
+		}
+		pm.setCluster(fmt.Sprintf("cluster-%d", c), cls)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			pm.mu.Lock()
+			pm.setPluginRoutes("cluster-0", types.NamespacedName{Namespace: "namespace", Name: "plugin-0-0"}, map[string]route{
+				"https://plugin-0--cluster-0.org.basedomain": {serviceName: fmt.Sprintf("test-%d", i), namespace: "namespace"},
+			})
+			pm.mu.Unlock()
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			l := lookups[i%len(lookups)]
+			if _, ok := pm.GetClusterRoute(l.cluster, l.url); !ok {
+				b.Fatal("expected route to exist")
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// The tunnel-agent runs in a cluster with the reverse-tunnel access mode. It dials out to the Greenhouse tunnel server
+// and forwards connections from Greenhouse to the API server of the cluster.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"net"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/cloudoperators/greenhouse/pkg/tunnel"
+	"github.com/cloudoperators/greenhouse/pkg/version"
+)
+
+func main() {
+	var serverURL, cluster, caFile, target string
+	var reconnectInterval = tunnel.DefaultReconnectInterval
+
+	flag.StringVar(&serverURL, "server-url", os.Getenv("TUNNEL_SERVER_URL"), "URL of the Greenhouse tunnel server")
+	flag.StringVar(&cluster, "cluster", os.Getenv("TUNNEL_CLUSTER"), "Greenhouse cluster of the agent as <organization>/<cluster>")
+	flag.StringVar(&caFile, "ca-file", "", "CA bundle to verify the tunnel server, defaults to the system roots")
+	flag.StringVar(&target, "target", defaultTarget(), "address of the API server connections are forwarded to")
+	flag.DurationVar(&reconnectInterval, "reconnect-interval", reconnectInterval, "interval in which the agent reconnects to the tunnel server")
+
+	opts := zap.Options{
+		Development: true,
+		TimeEncoder: zapcore.RFC3339TimeEncoder,
+	}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+	logger := zap.New(zap.UseFlagOptions(&opts))
+	ctrl.SetLogger(logger)
+
+	logger.Info("Tunnel-agent", "version", version.GitCommit, "build_date", version.BuildDate, "go", version.GoVersion)
+
+	var failWithError = func(err error, message string) {
+		logger.Error(err, message)
+		os.Exit(1)
+	}
+
+	// The token is read from the environment only to keep it out of the process arguments.
+	token := os.Getenv("TUNNEL_TOKEN")
+	switch {
+	case serverURL == "":
+		failWithError(errors.New("--server-url must be set"), "Invalid configuration")
+	case token == "":
+		failWithError(errors.New("TUNNEL_TOKEN must be set"), "Invalid configuration")
+	case target == "":
+		failWithError(errors.New("--target must be set"), "Invalid configuration")
+	}
+	clusterKey, err := tunnel.ParseClusterKey(cluster)
+	if err != nil {
+		failWithError(err, "Invalid configuration")
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			failWithError(err, "Failed to read CA file")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			failWithError(errors.New("no certificates found"), "Failed to parse CA file")
+		}
+	}
+
+	agent := &tunnel.Agent{
+		ServerURL:         serverURL,
+		Cluster:           clusterKey,
+		Token:             token,
+		Target:            target,
+		TLSConfig:         tlsConfig,
+		ReconnectInterval: reconnectInterval,
+	}
+	ctx := ctrl.LoggerInto(ctrl.SetupSignalHandler(), logger)
+	agent.Run(ctx)
+}
+
+// defaultTarget returns the address of the API server of the cluster the agent runs in.
+func defaultTarget() string {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, port)
+}
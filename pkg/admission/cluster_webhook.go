@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -79,10 +80,13 @@ func DefaultCluster(ctx context.Context, _ client.Client, obj runtime.Object) er
 	return nil
 }
 
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
 //+kubebuilder:webhook:path=/validate-greenhouse-sap-v1alpha1-cluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=greenhouse.sap,resources=clusters,verbs=create;update;delete,versions=v1alpha1,name=vcluster.kb.io,admissionReviewVersions=v1
 
 // ValidateCreateCluster disallows creating clusters with deletionMarked or deletionSchedule annotations
-func ValidateCreateCluster(ctx context.Context, _ client.Client, obj runtime.Object) (admission.Warnings, error) {
+// and migrations to Organizations the requester cannot create clusters in.
+func ValidateCreateCluster(ctx context.Context, c client.Client, obj runtime.Object) (admission.Warnings, error) {
 	logger := ctrl.LoggerFrom(ctx)
 	cluster, ok := obj.(*greenhousev1alpha1.Cluster)
 	if !ok {
@@ -103,12 +107,17 @@ func ValidateCreateCluster(ctx context.Context, _ client.Client, obj runtime.Obj
 		logger.Error(err, "found deletion annotation on cluster creation, admission will be denied")
 		return admission.Warnings{"you cannot create a cluster with deletion annotation"}, err
 	}
+	if err := validateMigrationRequest(ctx, c, nil, cluster); err != nil {
+		logger.Error(err, "create request denied", "cluster", cluster.GetName())
+		return nil, err
+	}
 
 	return nil, nil
 }
 
 // ValidateUpdateCluster disallows cluster updates with invalid deletion schedules
-func ValidateUpdateCluster(ctx context.Context, _ client.Client, _, currObj runtime.Object) (admission.Warnings, error) {
+// and migrations to Organizations the requester cannot create clusters in.
+func ValidateUpdateCluster(ctx context.Context, c client.Client, oldObj, currObj runtime.Object) (admission.Warnings, error) {
 	cluster, ok := currObj.(*greenhousev1alpha1.Cluster)
 	logger := ctrl.LoggerFrom(ctx)
 	if !ok {
@@ -120,9 +129,54 @@ func ValidateUpdateCluster(ctx context.Context, _ client.Client, _, currObj runt
 		logger.Error(err, "update request denied", "cluster", cluster.GetName())
 		return admission.Warnings{"update is not allowed"}, err
 	}
+	oldCluster, _ := oldObj.(*greenhousev1alpha1.Cluster)
+	if err := validateMigrationRequest(ctx, c, oldCluster, cluster); err != nil {
+		logger.Error(err, "update request denied", "cluster", cluster.GetName())
+		return admission.Warnings{"update is not allowed"}, err
+	}
 	return nil, nil
 }
 
+// validateMigrationRequest ensures the requester of a migration is allowed to create clusters in the target Organization.
+// Otherwise, members of any Organization could move their clusters into another Organization.
+func validateMigrationRequest(ctx context.Context, c client.Client, oldCluster, cluster *greenhousev1alpha1.Cluster) error {
+	targetOrganization, isRequested := cluster.GetAnnotations()[apis.ClusterMigrateToOrganizationAnnotation]
+	if !isRequested || (oldCluster != nil && oldCluster.GetAnnotations()[apis.ClusterMigrateToOrganizationAnnotation] == targetOrganization) {
+		return nil
+	}
+	groupResource := schema.GroupResource{Group: greenhousev1alpha1.GroupVersion.Group, Resource: "clusters"}
+	request, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return apierrors.NewForbidden(groupResource, cluster.GetName(), err)
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(request.UserInfo.Extra))
+	for key, value := range request.UserInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	accessReview := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: targetOrganization,
+				Verb:      "create",
+				Group:     groupResource.Group,
+				Resource:  groupResource.Resource,
+			},
+			User:   request.UserInfo.Username,
+			Groups: request.UserInfo.Groups,
+			UID:    request.UserInfo.UID,
+			Extra:  extra,
+		},
+	}
+	if err := c.Create(ctx, accessReview); err != nil {
+		return fmt.Errorf("failed to review the access to organization %s: %w", targetOrganization, err)
+	}
+	if !accessReview.Status.Allowed {
+		return apierrors.NewForbidden(groupResource, cluster.GetName(),
+			fmt.Errorf("migrating the cluster requires permission to create clusters in organization %s", targetOrganization))
+	}
+	return nil
+}
+
 // ValidateDeleteCluster only allows deletion requests for clusters with a deletion schedule timestamp past now.
 func ValidateDeleteCluster(ctx context.Context, _ client.Client, obj runtime.Object) (admission.Warnings, error) {
 	now := time.Now()
@@ -7,7 +7,14 @@ import (
 	"context"
 	"time"
 
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
@@ -288,4 +295,56 @@ var _ = Describe("Cluster Webhook", func() {
 			true,
 		),
 	)
+
+	Describe("Validate Cluster Migration", func() {
+		var (
+			c       client.Client
+			cluster *greenhousev1alpha1.Cluster
+		)
+
+		BeforeEach(func() {
+			// Only members of the target-org are allowed to create clusters there.
+			c = fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(_ context.Context, _ client.WithWatch, obj client.Object, _ ...client.CreateOption) error {
+					accessReview, ok := obj.(*authorizationv1.SubjectAccessReview)
+					Expect(ok).To(BeTrue(), "only SubjectAccessReviews should be created")
+					accessReview.Status.Allowed = accessReview.Spec.User == "target-admin" && accessReview.Spec.ResourceAttributes.Namespace == "target-org"
+					return nil
+				},
+			}).Build()
+			cluster = &greenhousev1alpha1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test-cluster",
+					Namespace:   "test-namespace",
+					Annotations: map[string]string{greenhouseapis.ClusterMigrateToOrganizationAnnotation: "target-org"},
+				},
+				Spec: greenhousev1alpha1.ClusterSpec{
+					AccessMode: greenhousev1alpha1.ClusterAccessModeDirect,
+				},
+			}
+		})
+
+		requestBy := func(user string) context.Context {
+			return admission.NewContextWithRequest(ctx, admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: user},
+			}})
+		}
+
+		It("should allow the migration if the requester can create clusters in the target organization", func() {
+			_, err := ValidateUpdateCluster(requestBy("target-admin"), c, &greenhousev1alpha1.Cluster{}, cluster)
+			Expect(err).ToNot(HaveOccurred(), "the migration should be allowed")
+		})
+
+		It("should deny the migration if the requester cannot create clusters in the target organization", func() {
+			_, err := ValidateUpdateCluster(requestBy("source-admin"), c, &greenhousev1alpha1.Cluster{}, cluster)
+			Expect(err).To(MatchError(ContainSubstring("requires permission to create clusters in organization target-org")))
+			_, err = ValidateCreateCluster(requestBy("source-admin"), c, cluster)
+			Expect(err).To(HaveOccurred(), "creating a cluster with the migrate annotation should be denied")
+		})
+
+		It("should not review the access if the target organization is unchanged", func() {
+			_, err := ValidateUpdateCluster(requestBy("source-admin"), c, cluster.DeepCopy(), cluster)
+			Expect(err).ToNot(HaveOccurred(), "updates of a cluster being migrated should be allowed")
+		})
+	})
 })
@@ -8,11 +8,13 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/scim"
 )
@@ -64,6 +66,8 @@ func ValidateCreateOrganization(_ context.Context, _ client.Client, obj runtime.
 		allErrs = append(allErrs, err)
 	}
 
+	allErrs = append(allErrs, validateClusterLabelRules(organization)...)
+
 	return nil, allErrs.ToAggregate()
 }
 
@@ -82,6 +86,8 @@ func ValidateUpdateOrganization(_ context.Context, _ client.Client, _, newObj ru
 		allErrs = append(allErrs, err)
 	}
 
+	allErrs = append(allErrs, validateClusterLabelRules(organization)...)
+
 	return nil, allErrs.ToAggregate()
 }
 
@@ -125,3 +131,34 @@ func validateSCIMConfig(organization *greenhousev1alpha1.Organization) *field.Er
 
 	return nil
 }
+
+func validateClusterLabelRules(organization *greenhousev1alpha1.Organization) field.ErrorList {
+	allErrs := field.ErrorList{}
+	labels := make(map[string]struct{}, len(organization.Spec.ClusterLabelRules))
+	for idx, rule := range organization.Spec.ClusterLabelRules {
+		rulePath := field.NewPath("spec").Child("clusterLabelRules").Index(idx)
+		for _, msg := range validation.IsQualifiedName(rule.Label) {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("label"), rule.Label, msg))
+		}
+		if strings.HasPrefix(rule.Label, greenhouseapis.LabelKeyPrefixClusterInventory) {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("label"), rule.Label,
+				"labels with the prefix "+greenhouseapis.LabelKeyPrefixClusterInventory+" are reserved for the cluster inventory"))
+		}
+		if _, ok := labels[rule.Label]; ok {
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("label"), rule.Label))
+		}
+		labels[rule.Label] = struct{}{}
+
+		sources := 0
+		for _, isSet := range []bool{rule.NodeLabel != "", rule.KubernetesVersion, rule.Namespace != "", rule.CustomResourceDefinition != ""} {
+			if isSet {
+				sources++
+			}
+		}
+		if sources != 1 {
+			allErrs = append(allErrs, field.Invalid(rulePath, rule.Label,
+				"exactly one of nodeLabel, kubernetesVersion, namespace or customResourceDefinition must be set"))
+		}
+	}
+	return allErrs
+}
@@ -152,6 +152,42 @@ var _ = Describe("Validate Organization Webhook", func() {
 				},
 			},
 		}, true),
+		Entry("with valid cluster label rules", &greenhousev1alpha1.Organization{
+			Spec: greenhousev1alpha1.OrganizationSpec{
+				MappedOrgAdminIDPGroup: "MAPPER_ADMIN_ID_GROUP",
+				ClusterLabelRules: []greenhousev1alpha1.ClusterLabelRule{
+					{Label: "region", NodeLabel: "topology.kubernetes.io/region"},
+					{Label: "example.com/monitoring", CustomResourceDefinition: "prometheuses.monitoring.coreos.com"},
+				},
+			},
+		}, false),
+		Entry("with cluster label rule without source", &greenhousev1alpha1.Organization{
+			Spec: greenhousev1alpha1.OrganizationSpec{
+				MappedOrgAdminIDPGroup: "MAPPER_ADMIN_ID_GROUP",
+				ClusterLabelRules:      []greenhousev1alpha1.ClusterLabelRule{{Label: "region"}},
+			},
+		}, true),
+		Entry("with cluster label rule with multiple sources", &greenhousev1alpha1.Organization{
+			Spec: greenhousev1alpha1.OrganizationSpec{
+				MappedOrgAdminIDPGroup: "MAPPER_ADMIN_ID_GROUP",
+				ClusterLabelRules:      []greenhousev1alpha1.ClusterLabelRule{{Label: "region", NodeLabel: "region", KubernetesVersion: true}},
+			},
+		}, true),
+		Entry("with duplicate cluster label rules", &greenhousev1alpha1.Organization{
+			Spec: greenhousev1alpha1.OrganizationSpec{
+				MappedOrgAdminIDPGroup: "MAPPER_ADMIN_ID_GROUP",
+				ClusterLabelRules: []greenhousev1alpha1.ClusterLabelRule{
+					{Label: "version", KubernetesVersion: true},
+					{Label: "version", Namespace: "kube-system"},
+				},
+			},
+		}, true),
+		Entry("with cluster label rule for a reserved label", &greenhousev1alpha1.Organization{
+			Spec: greenhousev1alpha1.OrganizationSpec{
+				MappedOrgAdminIDPGroup: "MAPPER_ADMIN_ID_GROUP",
+				ClusterLabelRules:      []greenhousev1alpha1.ClusterLabelRule{{Label: "inventory.greenhouse.sap/region", NodeLabel: "region"}},
+			},
+		}, true),
 	)
 
 	DescribeTable("Update Organization Webhook", func(obj runtime.Object, expectedError bool) {
@@ -156,6 +156,12 @@ func validateOwnerReference(plugin *greenhousev1alpha1.Plugin) admission.Warning
 	return nil
 }
 
+// ValidatePluginOptionValues validates the option values of the Plugin against the PluginDefinition with the rules applied on admission.
+// It allows validating Plugins before they are applied, e.g. by greenhousectl.
+func ValidatePluginOptionValues(plugin *greenhousev1alpha1.Plugin, pluginDefinition *greenhousev1alpha1.PluginDefinition) field.ErrorList {
+	return validatePluginOptionValues(plugin.Spec.OptionValues, pluginDefinition, true, field.NewPath("spec").Child("optionValues"))
+}
+
 func validatePluginOptionValues(
 	optionValues []greenhousev1alpha1.PluginOptionValue,
 	pluginDefinition *greenhousev1alpha1.PluginDefinition,
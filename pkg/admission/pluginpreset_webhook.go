@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 )
 
@@ -135,6 +136,10 @@ func ValidateDeletePluginPreset(_ context.Context, _ client.Client, obj runtime.
 		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata").Child("annotation").Child(preventDeletionAnnotation),
 			pluginPreset.Annotations, fmt.Sprintf("PluginPreset with annotation '%s' set may not be deleted.", preventDeletionAnnotation)))
 	}
+	if pluginPreset.Spec.DeletionPolicy == greenhousev1alpha1.DeletionPolicyProtect && pluginPreset.Annotations[greenhouseapis.AllowPluginPresetDeletionAnnotation] != "true" {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("deletionPolicy"), pluginPreset.Spec.DeletionPolicy,
+			fmt.Sprintf("PluginPreset with deletion policy %s may only be deleted with annotation '%s: \"true\"' set.", greenhousev1alpha1.DeletionPolicyProtect, greenhouseapis.AllowPluginPresetDeletionAnnotation)))
+	}
 
 	if len(allErrs) > 0 {
 		return nil, apierrors.NewInvalid(pluginPreset.GroupVersionKind().GroupKind(), pluginPreset.Name, allErrs)
@@ -143,6 +148,12 @@ func ValidateDeletePluginPreset(_ context.Context, _ client.Client, obj runtime.
 	return nil, nil
 }
 
+// ValidatePluginPresetOptionValues validates the option values and cluster overrides of the PluginPreset against the PluginDefinition with the rules applied on admission.
+// It allows validating PluginPresets before they are applied, e.g. by greenhousectl.
+func ValidatePluginPresetOptionValues(pluginPreset *greenhousev1alpha1.PluginPreset, pluginDefinition *greenhousev1alpha1.PluginDefinition) field.ErrorList {
+	return validatePluginOptionValuesForPreset(pluginPreset, pluginDefinition)
+}
+
 // validatePluginOptionValuesForPreset validates plugin options and their values, but skips the check for required options.
 // Required options are checked at the Plugin creation level, because the preset can override options and we cannot predict what clusters will be a part of the PluginPreset later on.
 func validatePluginOptionValuesForPreset(pluginPreset *greenhousev1alpha1.PluginPreset, pluginDefinition *greenhousev1alpha1.PluginDefinition) field.ErrorList {
@@ -9,6 +9,7 @@ import (
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/clientutil"
 	"github.com/cloudoperators/greenhouse/pkg/test"
@@ -208,6 +209,40 @@ var _ = Describe("PluginPreset Admission Tests", Ordered, func() {
 		err = test.K8sClient.Delete(test.Ctx, pluginPreset)
 		Expect(err).ToNot(HaveOccurred())
 	})
+
+	It("should reject delete operation when PluginPreset has the Protect deletion policy until deletion is allowed", func() {
+		pluginPreset := &greenhousev1alpha1.PluginPreset{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pluginPresetUpdate,
+				Namespace: test.TestNamespace,
+			},
+			Spec: greenhousev1alpha1.PluginPresetSpec{
+				Plugin: greenhousev1alpha1.PluginSpec{
+					PluginDefinition: pluginPresetDefinition,
+				},
+				ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"foo": "bar"}},
+				DeletionPolicy:  greenhousev1alpha1.DeletionPolicyProtect,
+			},
+		}
+
+		err := test.K8sClient.Create(test.Ctx, pluginPreset)
+		Expect(err).ToNot(HaveOccurred())
+
+		pluginPreset.Annotations = map[string]string{}
+		err = test.K8sClient.Update(test.Ctx, pluginPreset)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = test.K8sClient.Delete(test.Ctx, pluginPreset)
+		Expect(err).To(HaveOccurred(), "there must be an error deleting a protected PluginPreset")
+		Expect(err.Error()).To(ContainSubstring(greenhouseapis.AllowPluginPresetDeletionAnnotation))
+
+		pluginPreset.Annotations = map[string]string{greenhouseapis.AllowPluginPresetDeletionAnnotation: "true"}
+		err = test.K8sClient.Update(test.Ctx, pluginPreset)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = test.K8sClient.Delete(test.Ctx, pluginPreset)
+		Expect(err).ToNot(HaveOccurred())
+	})
 })
 
 var _ = Describe("Validate Plugin OptionValues for PluginPreset", func() {
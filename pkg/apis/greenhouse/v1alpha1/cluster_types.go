@@ -4,6 +4,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -14,10 +15,19 @@ type ClusterSpec struct {
 
 	// KubeConfig contains specific values for `KubeConfig` for the cluster.
 	KubeConfig ClusterKubeConfig `json:"kubeConfig,omitempty"`
+
+	// DeletionPolicy defines what happens to the Helm releases of the Plugins deployed to the cluster when the cluster is deleted.
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Optional
+	DeletionPolicy ClusterDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+// ClusterDeletionPolicy defines what happens to the Helm releases of the Plugins deployed to the cluster when the cluster is deleted.
+// +kubebuilder:validation:Enum=Delete;Detach
+type ClusterDeletionPolicy string
+
 // ClusterAccessMode configures the access mode to the customer cluster.
-// +kubebuilder:validation:Enum=direct
+// +kubebuilder:validation:Enum=direct;reverse-tunnel
 type ClusterAccessMode string
 
 // ClusterKubeConfig configures kube config values.
@@ -33,12 +43,39 @@ const (
 	// ClusterAccessModeDirect configures direct access to the cluster.
 	ClusterAccessModeDirect ClusterAccessMode = "direct"
 
+	// ClusterAccessModeReverseTunnel configures access to the cluster through a tunnel opened by an agent running in the cluster.
+	ClusterAccessModeReverseTunnel ClusterAccessMode = "reverse-tunnel"
+
+	// ClusterDeletionPolicyDelete uninstalls the Helm releases of the Plugins together with the cluster.
+	ClusterDeletionPolicyDelete ClusterDeletionPolicy = "Delete"
+
+	// ClusterDeletionPolicyDetach keeps the Helm releases of the Plugins running in the cluster. They can be managed with Helm or adopted by Plugins again.
+	ClusterDeletionPolicyDetach ClusterDeletionPolicy = "Detach"
+
 	// AllNodesReady reflects the readiness status of all nodes of a cluster.
 	AllNodesReady ConditionType = "AllNodesReady"
 
 	// KubeConfigValid reflects the validity of the kubeconfig of a cluster.
 	KubeConfigValid ConditionType = "KubeConfigValid"
 
+	// APIServerReady reflects the readiness of the API server of a cluster reported by its /readyz endpoint.
+	APIServerReady ConditionType = "APIServerReady"
+
+	// APIServerCertificateValid reflects whether the serving certificate of the API server of a cluster is valid and not about to expire.
+	APIServerCertificateValid ConditionType = "APIServerCertificateValid"
+
+	// ClusterDNSReady reflects the readiness of the DNS of a cluster. A cluster with a failing DNS is not ready.
+	ClusterDNSReady ConditionType = "ClusterDNSReady"
+
+	// MetricsServerReady reflects the availability of the metrics-server of a cluster.
+	MetricsServerReady ConditionType = "MetricsServerReady"
+
+	// TokenExpiringSoon reflects whether the token used to access a cluster is about to expire because its renewal keeps failing.
+	TokenExpiringSoon ConditionType = "TokenExpiringSoon"
+
+	// DeprecatedAPIsInUse reflects whether the cluster uses API versions that are removed in upcoming Kubernetes versions.
+	DeprecatedAPIsInUse ConditionType = "DeprecatedAPIsInUse"
+
 	// MaxTokenValidity contains maximum bearer token validity duration. It is also default value.
 	MaxTokenValidity = 72
 
@@ -52,10 +89,136 @@ type ClusterStatus struct {
 	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
 	// BearerTokenExpirationTimestamp reflects the expiration timestamp of the bearer token used to access the cluster.
 	BearerTokenExpirationTimestamp metav1.Time `json:"bearerTokenExpirationTimestamp,omitempty"`
+	// LastCredentialRotationTimestamp reflects the time the credentials used to access the cluster were last rotated on demand.
+	LastCredentialRotationTimestamp metav1.Time `json:"lastCredentialRotationTimestamp,omitempty"`
 	// StatusConditions contain the different conditions that constitute the status of the Cluster.
 	StatusConditions `json:"statusConditions,omitempty"`
 	// Nodes provides a map of cluster node names to node statuses
 	Nodes map[string]NodeStatus `json:"nodes,omitempty"`
+	// Inventory contains facts collected from the cluster.
+	Inventory *ClusterInventory `json:"inventory,omitempty"`
+	// DeletionImpact lists the resources that are removed once the scheduled deletion of the cluster proceeds.
+	// It is only set while a deletion is scheduled.
+	DeletionImpact *ClusterDeletionImpact `json:"deletionImpact,omitempty"`
+	// Migration reflects the progress of the migration of the cluster to another Organization.
+	Migration *ClusterMigrationStatus `json:"migration,omitempty"`
+	// DeprecatedAPIs reports the usage of API versions that are removed in upcoming Kubernetes versions and block an upgrade of the cluster.
+	DeprecatedAPIs *ClusterDeprecatedAPIReport `json:"deprecatedAPIs,omitempty"`
+}
+
+// ClusterDeprecatedAPIReport reports the usage of API versions that are removed in upcoming Kubernetes versions. It is refreshed periodically.
+type ClusterDeprecatedAPIReport struct {
+	// TargetKubernetesVersion is the Kubernetes version up to which removed API versions are reported.
+	TargetKubernetesVersion string `json:"targetKubernetesVersion,omitempty"`
+	// Objects are the API versions removed in upcoming Kubernetes versions that are used by objects in the cluster, as recorded in their managed fields.
+	Objects []DeprecatedAPIUsage `json:"objects,omitempty"`
+	// Plugins are the API versions removed in upcoming Kubernetes versions that are used in the manifests of the Helm releases of the Plugins deployed to the cluster.
+	Plugins []PluginDeprecatedAPIUsage `json:"plugins,omitempty"`
+	// RemovedObjects are the API versions already removed in the current Kubernetes version that are still recorded in the managed fields of objects in the cluster.
+	RemovedObjects []DeprecatedAPIUsage `json:"removedObjects,omitempty"`
+	// RemovedPlugins are the API versions already removed in the current Kubernetes version that are still used in the manifests of the Helm releases of Plugins.
+	// Helm fails to upgrade these releases until the manifests are migrated.
+	RemovedPlugins []PluginDeprecatedAPIUsage `json:"removedPlugins,omitempty"`
+	// LastScanTime is the time the cluster was last scanned, including failed scans.
+	LastScanTime metav1.Time `json:"lastScanTime,omitempty"`
+	// LastScanError is the error of the last scan if it failed. The results of the last successful scan are kept.
+	LastScanError string `json:"lastScanError,omitempty"`
+}
+
+// DeprecatedAPIUsage is the usage of an API version that is removed in a Kubernetes version.
+type DeprecatedAPIUsage struct {
+	// APIVersion is the removed API version, e.g. policy/v1beta1.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the objects using the API version.
+	Kind string `json:"kind"`
+	// RemovedInVersion is the Kubernetes version the API version is removed in.
+	RemovedInVersion string `json:"removedInVersion"`
+	// Replacement is the API version to migrate to, if any.
+	Replacement string `json:"replacement,omitempty"`
+	// Count is the number of objects using the API version.
+	Count int `json:"count"`
+}
+
+// PluginDeprecatedAPIUsage is the usage of removed API versions in the manifest of the Helm release of a Plugin.
+type PluginDeprecatedAPIUsage struct {
+	// Name is the name of the Plugin.
+	Name string `json:"name"`
+	// APIs are the removed API versions used in the manifest of the Helm release.
+	APIs []DeprecatedAPIUsage `json:"apis"`
+}
+
+// ClusterMigrationPhase is the phase of the migration of a cluster to another Organization.
+// +kubebuilder:validation:Enum=Pending;HandingOverAccess;CopyingCluster;MigratingPlugins;MigratingTeamRoleBindings;Completed;Failed
+type ClusterMigrationPhase string
+
+const (
+	// ClusterMigrationPhasePending is set once the migration was requested.
+	ClusterMigrationPhasePending ClusterMigrationPhase = "Pending"
+	// ClusterMigrationPhaseHandingOverAccess grants the target Organization access to the cluster.
+	ClusterMigrationPhaseHandingOverAccess ClusterMigrationPhase = "HandingOverAccess"
+	// ClusterMigrationPhaseCopyingCluster creates the Cluster and its kubeconfig Secret in the target Organization.
+	ClusterMigrationPhaseCopyingCluster ClusterMigrationPhase = "CopyingCluster"
+	// ClusterMigrationPhaseMigratingPlugins recreates the Plugins in the target Organization, which adopt the existing Helm releases.
+	ClusterMigrationPhaseMigratingPlugins ClusterMigrationPhase = "MigratingPlugins"
+	// ClusterMigrationPhaseMigratingTeamRoleBindings recreates the TeamRoleBindings for the cluster in the target Organization.
+	ClusterMigrationPhaseMigratingTeamRoleBindings ClusterMigrationPhase = "MigratingTeamRoleBindings"
+	// ClusterMigrationPhaseCompleted is set once the cluster was migrated. The cluster is then removed from the source Organization.
+	ClusterMigrationPhaseCompleted ClusterMigrationPhase = "Completed"
+	// ClusterMigrationPhaseFailed is set if a step of the migration failed. The step is reported in FailedStep and the migration is retried.
+	ClusterMigrationPhaseFailed ClusterMigrationPhase = "Failed"
+)
+
+// ClusterMigrationStatus reflects the progress of the migration of a cluster to another Organization.
+type ClusterMigrationStatus struct {
+	// TargetOrganization is the name of the Organization the cluster is migrated to.
+	TargetOrganization string `json:"targetOrganization"`
+	// Phase is the current phase of the migration.
+	Phase ClusterMigrationPhase `json:"phase"`
+	// Message provides details about the current phase, e.g. the reason of a failure.
+	Message string `json:"message,omitempty"`
+	// FailedStep is the phase of the step that failed if the migration is in phase Failed.
+	FailedStep ClusterMigrationPhase `json:"failedStep,omitempty"`
+	// StartTimestamp is the time the migration was started.
+	StartTimestamp metav1.Time `json:"startTimestamp,omitempty"`
+	// SkippedTeamRoleBindings are the names of the TeamRoleBindings that were not migrated, as their Team or TeamRole does not exist in the target Organization
+	// or another TeamRoleBinding with the same name exists there.
+	SkippedTeamRoleBindings []string `json:"skippedTeamRoleBindings,omitempty"`
+}
+
+// ClusterDeletionImpact lists the resources that are removed once the scheduled deletion of the cluster proceeds.
+type ClusterDeletionImpact struct {
+	// Plugins are the names of the Plugins deployed to the cluster that are deleted.
+	Plugins []string `json:"plugins,omitempty"`
+	// HelmReleases are the Helm releases in the cluster that are uninstalled, in the format namespace/name. Empty with the Detach deletion policy.
+	HelmReleases []string `json:"helmReleases,omitempty"`
+	// TeamRoleBindings are the names of the TeamRoleBindings whose RBAC resources are removed from the cluster.
+	TeamRoleBindings []string `json:"teamRoleBindings,omitempty"`
+	// ExposedServices are the URLs of the services exposed from the cluster that will disappear.
+	ExposedServices []string `json:"exposedServices,omitempty"`
+}
+
+// ClusterInventory contains facts collected from the cluster. It is refreshed periodically.
+type ClusterInventory struct {
+	// Provider is the cloud provider of the cluster derived from the provider ID of the nodes.
+	Provider string `json:"provider,omitempty"`
+	// Region is the region of the cluster derived from the topology labels of the nodes.
+	Region string `json:"region,omitempty"`
+	// Zones are the availability zones of the nodes derived from their topology labels.
+	Zones []string `json:"zones,omitempty"`
+	// NodeCount is the number of nodes of the cluster.
+	NodeCount int `json:"nodeCount"`
+	// InstanceTypes maps the instance types of the nodes to the number of nodes of each type.
+	InstanceTypes map[string]int `json:"instanceTypes,omitempty"`
+	// AllocatableCPU is the total allocatable CPU of all nodes.
+	AllocatableCPU resource.Quantity `json:"allocatableCPU,omitempty"`
+	// AllocatableMemory is the total allocatable memory of all nodes.
+	AllocatableMemory resource.Quantity `json:"allocatableMemory,omitempty"`
+	// ContainerRuntimeVersions are the distinct container runtime versions of the nodes.
+	ContainerRuntimeVersions []string `json:"containerRuntimeVersions,omitempty"`
+	// CRDGroups are the API groups of the CustomResourceDefinitions installed in the cluster.
+	CRDGroups []string `json:"crdGroups,omitempty"`
+	// LastUpdateTime is the time the inventory was collected.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // ClusterConditionType is a valid condition of a cluster.
@@ -43,9 +43,68 @@ type ClusterKubeconfigAuthInfoItem struct {
 }
 
 type ClusterKubeconfigAuthInfo struct {
-	AuthProvider          clientcmdapi.AuthProviderConfig `json:"auth-provider,omitempty"`
-	ClientCertificateData []byte                          `json:"client-certificate-data,omitempty"`
-	ClientKeyData         []byte                          `json:"client-key-data,omitempty"`
+	// AuthProvider configures the legacy oidc auth-provider, which is not supported by current kubectl versions.
+	AuthProvider *clientcmdapi.AuthProviderConfig `json:"auth-provider,omitempty"`
+	// Exec configures a credential plugin to obtain the token.
+	Exec                  *ClusterKubeconfigExecConfig `json:"exec,omitempty"`
+	ClientCertificateData []byte                       `json:"client-certificate-data,omitempty"`
+	ClientKeyData         []byte                       `json:"client-key-data,omitempty"`
+}
+
+// ClusterKubeconfigExecConfig configures an exec credential plugin.
+// It is a simplified version of clientcmdapi.ExecConfig: https://pkg.go.dev/k8s.io/client-go/tools/clientcmd/api#ExecConfig
+type ClusterKubeconfigExecConfig struct {
+	APIVersion         string                        `json:"apiVersion"`
+	Command            string                        `json:"command"`
+	Args               []string                      `json:"args,omitempty"`
+	Env                []ClusterKubeconfigExecEnvVar `json:"env,omitempty"`
+	InstallHint        string                        `json:"installHint,omitempty"`
+	ProvideClusterInfo bool                          `json:"provideClusterInfo,omitempty"`
+	// +kubebuilder:validation:Enum=Never;IfAvailable;Always
+	InteractiveMode clientcmdapi.ExecInteractiveMode `json:"interactiveMode,omitempty"`
+}
+
+type ClusterKubeconfigExecEnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ToExecConfig converts the ClusterKubeconfigExecConfig to a clientcmdapi.ExecConfig.
+func (in *ClusterKubeconfigExecConfig) ToExecConfig() *clientcmdapi.ExecConfig {
+	if in == nil {
+		return nil
+	}
+	execConfig := &clientcmdapi.ExecConfig{
+		APIVersion:         in.APIVersion,
+		Command:            in.Command,
+		Args:               append([]string(nil), in.Args...),
+		InstallHint:        in.InstallHint,
+		ProvideClusterInfo: in.ProvideClusterInfo,
+		InteractiveMode:    in.InteractiveMode,
+	}
+	for _, env := range in.Env {
+		execConfig.Env = append(execConfig.Env, clientcmdapi.ExecEnvVar{Name: env.Name, Value: env.Value})
+	}
+	return execConfig
+}
+
+// NewClusterKubeconfigExecConfig converts a clientcmdapi.ExecConfig to a ClusterKubeconfigExecConfig.
+func NewClusterKubeconfigExecConfig(execConfig *clientcmdapi.ExecConfig) *ClusterKubeconfigExecConfig {
+	if execConfig == nil {
+		return nil
+	}
+	out := &ClusterKubeconfigExecConfig{
+		APIVersion:         execConfig.APIVersion,
+		Command:            execConfig.Command,
+		Args:               append([]string(nil), execConfig.Args...),
+		InstallHint:        execConfig.InstallHint,
+		ProvideClusterInfo: execConfig.ProvideClusterInfo,
+		InteractiveMode:    execConfig.InteractiveMode,
+	}
+	for _, env := range execConfig.Env {
+		out.Env = append(out.Env, ClusterKubeconfigExecEnvVar{Name: env.Name, Value: env.Value})
+	}
+	return out
 }
 
 type ClusterKubeconfigContextItem struct {
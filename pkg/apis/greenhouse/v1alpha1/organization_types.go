@@ -53,6 +53,25 @@ type OrganizationSpec struct {
 
 	// MappedOrgAdminIDPGroup is the IDP group ID identifying org admins
 	MappedOrgAdminIDPGroup string `json:"mappedOrgAdminIdPGroup,omitempty"`
+
+	// ClusterLabelRules derive labels of the Clusters of the organization from the remote clusters.
+	// The labels are kept in sync by Greenhouse and can be used to select clusters, e.g. in the ClusterSelector of a PluginPreset.
+	ClusterLabelRules []ClusterLabelRule `json:"clusterLabelRules,omitempty"`
+}
+
+// ClusterLabelRule derives a label of a Cluster from a source in the remote cluster. Exactly one source must be set.
+type ClusterLabelRule struct {
+	// Label is the key of the label set on the Cluster.
+	Label string `json:"label"`
+	// NodeLabel sets the label to the most common value of this label on the nodes of the cluster.
+	// The label is removed if no node has this label.
+	NodeLabel string `json:"nodeLabel,omitempty"`
+	// KubernetesVersion sets the label to the minor Kubernetes version of the cluster, e.g. v1.32.
+	KubernetesVersion bool `json:"kubernetesVersion,omitempty"`
+	// Namespace sets the label to "true" if the namespace exists in the cluster and to "false" otherwise.
+	Namespace string `json:"namespace,omitempty"`
+	// CustomResourceDefinition sets the label to "true" if the CustomResourceDefinition with this name exists in the cluster and to "false" otherwise.
+	CustomResourceDefinition string `json:"customResourceDefinition,omitempty"`
 }
 
 type Authentication struct {
@@ -75,8 +94,27 @@ type OIDCConfig struct {
 	// OAuth2ClientRedirectURIs are a registered set of redirect URIs. When redirecting from the idproxy to
 	// the client application, the URI requested to redirect to must be contained in this list.
 	OAuth2ClientRedirectURIs []string `json:"oauth2ClientRedirectURIs,omitempty"`
+	// KubeconfigCredentialPlugin selects how the ClusterKubeconfigs of the organization obtain tokens.
+	// auth-provider configures the legacy oidc auth-provider, which is not supported by current kubectl versions.
+	// kubelogin and greenhousectl configure the respective tool as exec credential plugin.
+	// +kubebuilder:default=auth-provider
+	// +kubebuilder:validation:Optional
+	KubeconfigCredentialPlugin KubeconfigCredentialPlugin `json:"kubeconfigCredentialPlugin,omitempty"`
 }
 
+// KubeconfigCredentialPlugin is the tool used by the ClusterKubeconfigs to obtain tokens.
+// +kubebuilder:validation:Enum=auth-provider;kubelogin;greenhousectl
+type KubeconfigCredentialPlugin string
+
+const (
+	// KubeconfigCredentialPluginAuthProvider configures the legacy oidc auth-provider.
+	KubeconfigCredentialPluginAuthProvider KubeconfigCredentialPlugin = "auth-provider"
+	// KubeconfigCredentialPluginKubelogin configures kubelogin (kubectl oidc-login) as exec credential plugin.
+	KubeconfigCredentialPluginKubelogin KubeconfigCredentialPlugin = "kubelogin"
+	// KubeconfigCredentialPluginGreenhousectl configures greenhousectl as exec credential plugin.
+	KubeconfigCredentialPluginGreenhousectl KubeconfigCredentialPlugin = "greenhousectl"
+)
+
 type SCIMConfig struct {
 	// URL to the SCIM server.
 	BaseURL string `json:"baseURL"`
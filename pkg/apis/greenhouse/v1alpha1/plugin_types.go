@@ -70,6 +70,9 @@ const (
 	// HelmChartTestSucceededCondition reflects the status of the HelmChart tests.
 	HelmChartTestSucceededCondition ConditionType = "HelmChartTestSucceeded"
 
+	// ExposedServicesHealthyCondition reflects the result of the health checks of the exposed services.
+	ExposedServicesHealthyCondition ConditionType = "ExposedServicesHealthy"
+
 	// PluginDefinitionNotFoundReason is set when the pluginDefinition is not found.
 	PluginDefinitionNotFoundReason ConditionReason = "PluginDefinitionNotFound"
 
@@ -116,6 +119,18 @@ type Service struct {
 	Port int32 `json:"port"`
 	// Protocol is the protocol of the service.
 	Protocol *string `json:"protocol,omitempty"`
+	// HealthCheck configures the periodic health check of the service.
+	HealthCheck *ServiceHealthCheck `json:"healthCheck,omitempty"`
+	// Healthy reflects the result of the last health check of the service. It is not set if no health check is configured.
+	Healthy *bool `json:"healthy,omitempty"`
+}
+
+// ServiceHealthCheck configures the health check of an exposed service.
+type ServiceHealthCheck struct {
+	// Path is the HTTP path requested to check the health of the service.
+	Path string `json:"path"`
+	// ExpectedStatus is the HTTP status code expected from a healthy service.
+	ExpectedStatus int32 `json:"expectedStatus"`
 }
 
 // HelmReleaseStatus reflects the status of a Helm release.
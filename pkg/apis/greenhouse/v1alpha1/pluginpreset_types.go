@@ -27,8 +27,26 @@ type PluginPresetSpec struct {
 	// ClusterOptionOverrides define plugin option values to override by the PluginPreset
 	// +kubebuilder:validation:Optional
 	ClusterOptionOverrides []ClusterOptionOverride `json:"clusterOptionOverrides,omitempty"`
+
+	// DeletionPolicy defines what happens to the managed Plugins when the PluginPreset is deleted.
+	// +kubebuilder:default=Delete
+	// +kubebuilder:validation:Optional
+	DeletionPolicy PluginPresetDeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
+// PluginPresetDeletionPolicy defines what happens to the managed Plugins when the PluginPreset is deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan;Protect
+type PluginPresetDeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the managed Plugins together with the PluginPreset.
+	DeletionPolicyDelete PluginPresetDeletionPolicy = "Delete"
+	// DeletionPolicyOrphan keeps the managed Plugins running. They lose their owner reference and are no longer managed by the PluginPreset.
+	DeletionPolicyOrphan PluginPresetDeletionPolicy = "Orphan"
+	// DeletionPolicyProtect rejects the deletion of the PluginPreset until it is allowed by annotation. The managed Plugins are deleted afterwards.
+	DeletionPolicyProtect PluginPresetDeletionPolicy = "Protect"
+)
+
 // ClusterOptionOverride defines which plugin option should be override in which cluster
 // +kubebuilder:validation:Optional
 type ClusterOptionOverride struct {
@@ -68,6 +86,7 @@ type ManagedPluginStatus struct {
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="Plugin Definition",type=string,JSONPath=`.spec.plugin.pluginDefinition`
 //+kubebuilder:printcolumn:name="Release Namespace",type=string,JSONPath=`.spec.plugin.releaseNamespace`
+//+kubebuilder:printcolumn:name="Deletion Policy",type=string,JSONPath=`.spec.deletionPolicy`,priority=1
 //+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=`.status.statusConditions.conditions[?(@.type == "Ready")].status`
 
 // PluginPreset is the Schema for the PluginPresets API
@@ -11,6 +11,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -65,6 +66,121 @@ func (in *Cluster) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDeletionImpact) DeepCopyInto(out *ClusterDeletionImpact) {
+	*out = *in
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HelmReleases != nil {
+		in, out := &in.HelmReleases, &out.HelmReleases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TeamRoleBindings != nil {
+		in, out := &in.TeamRoleBindings, &out.TeamRoleBindings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExposedServices != nil {
+		in, out := &in.ExposedServices, &out.ExposedServices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDeletionImpact.
+func (in *ClusterDeletionImpact) DeepCopy() *ClusterDeletionImpact {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDeletionImpact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDeprecatedAPIReport) DeepCopyInto(out *ClusterDeprecatedAPIReport) {
+	*out = *in
+	if in.Objects != nil {
+		in, out := &in.Objects, &out.Objects
+		*out = make([]DeprecatedAPIUsage, len(*in))
+		copy(*out, *in)
+	}
+	if in.Plugins != nil {
+		in, out := &in.Plugins, &out.Plugins
+		*out = make([]PluginDeprecatedAPIUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RemovedObjects != nil {
+		in, out := &in.RemovedObjects, &out.RemovedObjects
+		*out = make([]DeprecatedAPIUsage, len(*in))
+		copy(*out, *in)
+	}
+	if in.RemovedPlugins != nil {
+		in, out := &in.RemovedPlugins, &out.RemovedPlugins
+		*out = make([]PluginDeprecatedAPIUsage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastScanTime.DeepCopyInto(&out.LastScanTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDeprecatedAPIReport.
+func (in *ClusterDeprecatedAPIReport) DeepCopy() *ClusterDeprecatedAPIReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDeprecatedAPIReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterInventory) DeepCopyInto(out *ClusterInventory) {
+	*out = *in
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.InstanceTypes != nil {
+		in, out := &in.InstanceTypes, &out.InstanceTypes
+		*out = make(map[string]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	out.AllocatableCPU = in.AllocatableCPU.DeepCopy()
+	out.AllocatableMemory = in.AllocatableMemory.DeepCopy()
+	if in.ContainerRuntimeVersions != nil {
+		in, out := &in.ContainerRuntimeVersions, &out.ContainerRuntimeVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CRDGroups != nil {
+		in, out := &in.CRDGroups, &out.CRDGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterInventory.
+func (in *ClusterInventory) DeepCopy() *ClusterInventory {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterInventory)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterKubeConfig) DeepCopyInto(out *ClusterKubeConfig) {
 	*out = *in
@@ -110,7 +226,16 @@ func (in *ClusterKubeconfig) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterKubeconfigAuthInfo) DeepCopyInto(out *ClusterKubeconfigAuthInfo) {
 	*out = *in
-	in.AuthProvider.DeepCopyInto(&out.AuthProvider)
+	if in.AuthProvider != nil {
+		in, out := &in.AuthProvider, &out.AuthProvider
+		*out = new(api.AuthProviderConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exec != nil {
+		in, out := &in.Exec, &out.Exec
+		*out = new(ClusterKubeconfigExecConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ClientCertificateData != nil {
 		in, out := &in.ClientCertificateData, &out.ClientCertificateData
 		*out = make([]byte, len(*in))
@@ -251,6 +376,46 @@ func (in *ClusterKubeconfigData) DeepCopy() *ClusterKubeconfigData {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterKubeconfigExecConfig) DeepCopyInto(out *ClusterKubeconfigExecConfig) {
+	*out = *in
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]ClusterKubeconfigExecEnvVar, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterKubeconfigExecConfig.
+func (in *ClusterKubeconfigExecConfig) DeepCopy() *ClusterKubeconfigExecConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterKubeconfigExecConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterKubeconfigExecEnvVar) DeepCopyInto(out *ClusterKubeconfigExecEnvVar) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterKubeconfigExecEnvVar.
+func (in *ClusterKubeconfigExecEnvVar) DeepCopy() *ClusterKubeconfigExecEnvVar {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterKubeconfigExecEnvVar)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterKubeconfigList) DeepCopyInto(out *ClusterKubeconfigList) {
 	*out = *in
@@ -330,6 +495,21 @@ func (in *ClusterKubeconfigStatus) DeepCopy() *ClusterKubeconfigStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterLabelRule) DeepCopyInto(out *ClusterLabelRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterLabelRule.
+func (in *ClusterLabelRule) DeepCopy() *ClusterLabelRule {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterLabelRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterList) DeepCopyInto(out *ClusterList) {
 	*out = *in
@@ -362,6 +542,27 @@ func (in *ClusterList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterMigrationStatus) DeepCopyInto(out *ClusterMigrationStatus) {
+	*out = *in
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	if in.SkippedTeamRoleBindings != nil {
+		in, out := &in.SkippedTeamRoleBindings, &out.SkippedTeamRoleBindings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterMigrationStatus.
+func (in *ClusterMigrationStatus) DeepCopy() *ClusterMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterOptionOverride) DeepCopyInto(out *ClusterOptionOverride) {
 	*out = *in
@@ -425,6 +626,7 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
 	in.BearerTokenExpirationTimestamp.DeepCopyInto(&out.BearerTokenExpirationTimestamp)
+	in.LastCredentialRotationTimestamp.DeepCopyInto(&out.LastCredentialRotationTimestamp)
 	in.StatusConditions.DeepCopyInto(&out.StatusConditions)
 	if in.Nodes != nil {
 		in, out := &in.Nodes, &out.Nodes
@@ -433,6 +635,26 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(ClusterInventory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeletionImpact != nil {
+		in, out := &in.DeletionImpact, &out.DeletionImpact
+		*out = new(ClusterDeletionImpact)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(ClusterMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeprecatedAPIs != nil {
+		in, out := &in.DeprecatedAPIs, &out.DeprecatedAPIs
+		*out = new(ClusterDeprecatedAPIReport)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -461,6 +683,21 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeprecatedAPIUsage) DeepCopyInto(out *DeprecatedAPIUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeprecatedAPIUsage.
+func (in *DeprecatedAPIUsage) DeepCopy() *DeprecatedAPIUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(DeprecatedAPIUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HelmChartReference) DeepCopyInto(out *HelmChartReference) {
 	*out = *in
@@ -614,6 +851,11 @@ func (in *OrganizationSpec) DeepCopyInto(out *OrganizationSpec) {
 		*out = new(Authentication)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ClusterLabelRules != nil {
+		in, out := &in.ClusterLabelRules, &out.ClusterLabelRules
+		*out = make([]ClusterLabelRule, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OrganizationSpec.
@@ -780,6 +1022,26 @@ func (in *PluginDefinitionStatus) DeepCopy() *PluginDefinitionStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginDeprecatedAPIUsage) DeepCopyInto(out *PluginDeprecatedAPIUsage) {
+	*out = *in
+	if in.APIs != nil {
+		in, out := &in.APIs, &out.APIs
+		*out = make([]DeprecatedAPIUsage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginDeprecatedAPIUsage.
+func (in *PluginDeprecatedAPIUsage) DeepCopy() *PluginDeprecatedAPIUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginDeprecatedAPIUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PluginList) DeepCopyInto(out *PluginList) {
 	*out = *in
@@ -1085,6 +1347,16 @@ func (in *Service) DeepCopyInto(out *Service) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(ServiceHealthCheck)
+		**out = **in
+	}
+	if in.Healthy != nil {
+		in, out := &in.Healthy, &out.Healthy
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Service.
@@ -1097,6 +1369,21 @@ func (in *Service) DeepCopy() *Service {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceHealthCheck) DeepCopyInto(out *ServiceHealthCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceHealthCheck.
+func (in *ServiceHealthCheck) DeepCopy() *ServiceHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatusConditions) DeepCopyInto(out *StatusConditions) {
 	*out = *in
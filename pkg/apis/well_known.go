@@ -24,6 +24,10 @@ const (
 	// This kubeconfig should be used by Greenhouse controllers and their kubernetes clients to access the remote cluster.
 	GreenHouseKubeConfigKey = "greenhousekubeconfig"
 
+	// RotationTokenKey is the key for the token of the temporary service account used while the credentials of a cluster are rotated.
+	// It is present while a rotation is in progress, so an interrupted rotation is resumed with it.
+	RotationTokenKey = "rotationToken"
+
 	// LabelKeyPluginPreset is used to identify the PluginPreset managing the plugin.
 	LabelKeyPluginPreset = "greenhouse.sap/pluginpreset"
 
@@ -41,6 +45,12 @@ const (
 
 	// LabelKeyExposeNamedPort is specifying the port to be exposed by name. LabelKeyExposeService needs to be set. Defaults to the first port if the named port is not found.
 	LabelKeyExposeNamedPort = "greenhouse.sap/exposeNamedPort"
+
+	// AnnotationKeyExposeHealthCheckPath is specifying the HTTP path used to periodically check the health of an exposed service. LabelKeyExposeService needs to be set.
+	AnnotationKeyExposeHealthCheckPath = "greenhouse.sap/exposeHealthCheckPath"
+
+	// AnnotationKeyExposeHealthCheckStatus is specifying the HTTP status code expected from a healthy exposed service. Defaults to 200.
+	AnnotationKeyExposeHealthCheckStatus = "greenhouse.sap/exposeHealthCheckStatus"
 )
 
 // TeamRole and TeamRoleBinding constants
@@ -74,6 +84,75 @@ const (
 	ClusterConnectivityAnnotation     = "greenhouse.sap/cluster-connectivity"
 	ClusterConnectivityKubeconfig     = "kubeconfig"
 	ClusterConnectivityOIDC           = "oidc"
+	// ClusterDerivedLabelsAnnotation contains the comma-separated keys of the labels derived from the ClusterLabelRules of the Organization.
+	// It is used to remove labels that are no longer derived.
+	ClusterDerivedLabelsAnnotation = "greenhouse.sap/derived-labels"
+	// ClusterDerivedLabelConflictsAnnotation contains the comma-separated keys of the derived labels skipped because they are set by users.
+	// It is used to only report changed conflicts.
+	ClusterDerivedLabelConflictsAnnotation = "greenhouse.sap/derived-label-conflicts"
+	// ClusterRotateCredentialsAnnotation triggers the rotation of the credentials used to access the cluster.
+	// It is removed once the credentials were rotated.
+	ClusterRotateCredentialsAnnotation = "greenhouse.sap/rotate-credentials"
+	// ClusterMigrateToOrganizationAnnotation contains the name of the Organization the cluster is migrated to.
+	ClusterMigrateToOrganizationAnnotation = "greenhouse.sap/migrate-to-organization"
+	// ClusterMigratedFromOrganizationAnnotation contains the name of the Organization the cluster and its Plugins, Secrets and TeamRoleBindings were migrated from.
+	ClusterMigratedFromOrganizationAnnotation = "greenhouse.sap/migrated-from-organization"
+)
+
+// cluster inventory labels
+const (
+	// LabelKeyPrefixClusterInventory is the prefix of all labels derived from the inventory of a cluster.
+	// Labels with this prefix are managed by Greenhouse and are removed if they are not derived from the inventory.
+	LabelKeyPrefixClusterInventory = "inventory.greenhouse.sap/"
+	// LabelKeyClusterProvider is the cloud provider of the cluster.
+	LabelKeyClusterProvider = LabelKeyPrefixClusterInventory + "provider"
+	// LabelKeyClusterRegion is the region of the cluster.
+	LabelKeyClusterRegion = LabelKeyPrefixClusterInventory + "region"
+	// LabelKeyClusterKubernetesVersion is the Kubernetes version of the cluster.
+	LabelKeyClusterKubernetesVersion = LabelKeyPrefixClusterInventory + "kubernetes-version"
+	// LabelKeyClusterContainerRuntime is the container runtime of the nodes of the cluster, if all nodes use the same runtime.
+	LabelKeyClusterContainerRuntime = LabelKeyPrefixClusterInventory + "container-runtime"
+)
+
+// reverse tunnel
+const (
+	// SecretClusterAccessModeAnnotation is set on the kubeconfig Secret to bootstrap the cluster with the given access mode instead of direct.
+	SecretClusterAccessModeAnnotation = "greenhouse.sap/access-mode"
+	// SecretTunnelProxyURLAnnotation is set on the Secret of clusters with the reverse-tunnel access mode.
+	// Requests to the cluster are sent through this proxy, which forwards them through the tunnel opened by the agent in the cluster.
+	SecretTunnelProxyURLAnnotation = "greenhouse.sap/tunnel-proxy-url"
+	// TunnelTokenKey is the key in the Secret of a cluster containing the token the tunnel agent authenticates with.
+	TunnelTokenKey = "tunnelToken"
+	// SecretTunnelServerAddressAnnotation is set on the Secret of a cluster by the replica of the tunnel server the agent is connected to.
+	// Other replicas forward proxy requests for the cluster to this address.
+	SecretTunnelServerAddressAnnotation = "greenhouse.sap/tunnel-server-address"
+	// TunnelProxyTokenKey is the key in the Secret of a cluster containing the token clients of the tunnel server proxy authenticate with.
+	// It is sent as password of the proxy user and never leaves the Greenhouse cluster, unlike the token of the agent.
+	TunnelProxyTokenKey = "tunnelProxyToken"
+	// TunnelProxyCAKey is the key in the Secret of a cluster containing the CA certificate of the tunnel server proxy.
+	TunnelProxyCAKey = "tunnelProxyCA"
+)
+
+// PluginPreset annotations
+const (
+	// AllowPluginPresetDeletionAnnotation must be set to "true" to delete a PluginPreset with the Protect deletion policy.
+	AllowPluginPresetDeletionAnnotation = "greenhouse.sap/allow-deletion"
+)
+
+// Plugin annotations
+const (
+	// PluginDetachAnnotation is set to "true" to keep the Helm release of a Plugin running in the cluster when the Plugin is deleted.
+	PluginDetachAnnotation = "greenhouse.sap/detach"
+)
+
+// service-proxy annotations
+const (
+	// ServiceProxyRoutingModeAnnotation is set on the kubeconfig Secret of a cluster to configure how the service-proxy reaches exposed services.
+	ServiceProxyRoutingModeAnnotation = "greenhouse.sap/service-proxy-routing-mode"
+	// ServiceProxyRoutingModeAPIServerProxy routes requests through the API server service proxy. This is the default.
+	ServiceProxyRoutingModeAPIServerProxy = "apiserver-proxy"
+	// ServiceProxyRoutingModePortForward routes requests through a port-forward to a ready pod of the service.
+	ServiceProxyRoutingModePortForward = "port-forward"
 )
 
 const (
@@ -5,6 +5,7 @@ package clientutil
 
 import (
 	"fmt"
+	"net/url"
 	"sync"
 
 	"github.com/spf13/pflag"
@@ -16,6 +17,8 @@ import (
 	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 )
 
 // Implements the genericclioptions.RESTClientGetter interface and additionally allows to access the KubeConfig from Bytes/Secret.
@@ -158,9 +161,65 @@ func NewRestClientGetterFromSecret(secret *corev1.Secret, namespace string, opts
 	for _, opt := range opts {
 		opt(g)
 	}
+	// Clusters with the reverse-tunnel access mode are reached through the proxy of the tunnel server.
+	if proxyURL, ok := secret.GetAnnotations()[greenhouseapis.SecretTunnelProxyURLAnnotation]; ok {
+		proxyURL, err := tunnelProxyURLWithToken(proxyURL, secret)
+		if err != nil {
+			return nil, err
+		}
+		if g.overrides == nil {
+			g.overrides = &clientcmd.ConfigOverrides{}
+			g.overrides.Context.Namespace = namespace
+		}
+		g.overrides.ClusterInfo.ProxyURL = proxyURL
+		if tunnelCA, ok := secret.Data[greenhouseapis.TunnelProxyCAKey]; ok {
+			caData, err := clusterCAWithTunnelCA(secret, tunnelCA)
+			if err != nil {
+				return nil, err
+			}
+			g.overrides.ClusterInfo.CertificateAuthorityData = caData
+		}
+	}
 	return g, nil
 }
 
+// clusterCAWithTunnelCA returns the CA of the current cluster of the kubeconfig in the Secret extended by the CA of the tunnel server.
+// The TLS connection to the proxy is verified with the same CA as the cluster, so the tunnel server must use a dedicated CA
+// to not allow it to impersonate the API server.
+func clusterCAWithTunnelCA(secret *corev1.Secret, tunnelCA []byte) ([]byte, error) {
+	kubeConfig, err := NewClientConfigLoaderFromSecret(secret).Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig of secret %s/%s: %w", secret.GetNamespace(), secret.GetName(), err)
+	}
+	kubeContext, ok := kubeConfig.Contexts[kubeConfig.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig of secret %s/%s has no current context", secret.GetNamespace(), secret.GetName())
+	}
+	cluster, ok := kubeConfig.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("kubeconfig of secret %s/%s has no cluster %s", secret.GetNamespace(), secret.GetName(), kubeContext.Cluster)
+	}
+	caData := append([]byte{}, cluster.CertificateAuthorityData...)
+	if len(caData) > 0 && caData[len(caData)-1] != '\n' {
+		caData = append(caData, '\n')
+	}
+	return append(caData, tunnelCA...), nil
+}
+
+// tunnelProxyURLWithToken returns the proxy URL of the tunnel server with the proxy token of the Secret as password of the proxy user.
+func tunnelProxyURLWithToken(rawURL string, secret *corev1.Secret) (string, error) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid tunnel proxy URL: %w", err)
+	}
+	token, ok := secret.Data[greenhouseapis.TunnelProxyTokenKey]
+	if !ok || proxyURL.User == nil {
+		return "", fmt.Errorf("secret %s/%s has no tunnel proxy credentials", secret.GetNamespace(), secret.GetName())
+	}
+	proxyURL.User = url.UserPassword(proxyURL.User.Username(), string(token))
+	return proxyURL.String(), nil
+}
+
 // NewRestClientGetterFromBytes returns a RestClientGetter from a []bytes containing a Kube Config.
 func NewRestClientGetterFromBytes(config []byte, namespace string, opts ...KubeClientOption) *RestClientGetter {
 	if namespace == "" {
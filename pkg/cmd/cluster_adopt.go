@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+)
+
+type clusterAdoptOptions struct {
+	kubecontext          string
+	orgName              string
+	clusterName          string
+	greenhouseKubeConfig string
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterAdoptCmd())
+}
+
+func newClusterAdoptCmd() *cobra.Command {
+	o := &clusterAdoptOptions{}
+	adoptCmd := &cobra.Command{
+		Use:   "adopt",
+		Short: "Generate Plugins adopting the Helm releases deployed to a cluster",
+		Long: `Generates Plugins for the Helm releases deployed to an onboarded cluster.
+A release is adopted by a Plugin with the same name, matching the PluginDefinition by the name of the Helm chart.
+Once applied, Greenhouse upgrades the existing releases in place instead of reinstalling them.
+The Plugins are printed to stdout. Releases without a matching PluginDefinition or with the name of an existing Plugin are skipped.
+Values of secret options are written to a Secret named "<release>-values", which is printed before the Plugin and referenced by it.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		},
+	}
+
+	adoptCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the cluster to adopt the Helm releases from (defaults to current-context)")
+	adoptCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	adoptCmd.Flags().StringVar(&o.clusterName, "cluster-name", clientutil.GetEnvOrDefault("GREENHOUSE_CLUSTER_NAME", ""), "The name of the cluster in Greenhouse. Can be set via GREENHOUSE_CLUSTER_NAME env var")
+	adoptCmd.Flags().StringVar(&o.greenhouseKubeConfig, "greenhouse-kubeconfig", "", "The kubeconfig of the greenhouse cluster")
+	for _, flagName := range []string{"org", "cluster-name", "greenhouse-kubeconfig"} {
+		if err := adoptCmd.MarkFlagRequired(flagName); err != nil {
+			setupLog.Error(err, "Flag could not set as required", flagName)
+		}
+	}
+	return adoptCmd
+}
+
+func (o *clusterAdoptOptions) run(ctx context.Context, out, errOut io.Writer) error {
+	ghConfig, err := clientcmd.BuildConfigFromFlags("", o.greenhouseKubeConfig)
+	if err != nil {
+		return err
+	}
+	ghClient, err := clientutil.NewK8sClient(ghConfig)
+	if err != nil {
+		return err
+	}
+	clusterConfig, err := config.GetConfigWithContext(o.kubecontext)
+	if err != nil {
+		return err
+	}
+	releases, err := helm.ListDeployedReleases(clientutil.NewRestClientGetterFromRestConfig(clusterConfig, o.orgName))
+	if err != nil {
+		return fmt.Errorf("failed to list helm releases: %w", err)
+	}
+	objects, err := pluginsForReleases(ctx, ghClient, releases, o.orgName, o.clusterName, errOut)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		yamlBytes, err := jsonToYaml(jsonBytes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "---\n%s", yamlBytes)
+	}
+	return nil
+}
+
+// pluginsForReleases returns Plugins adopting the given Helm releases, each preceded by the Secret with the values of its secret options if any.
+// Releases that cannot be adopted are reported to errOut and skipped.
+func pluginsForReleases(ctx context.Context, ghClient client.Client, releases []*release.Release, namespace, clusterName string, errOut io.Writer) ([]client.Object, error) {
+	pluginDefinitionList := new(greenhousev1alpha1.PluginDefinitionList)
+	if err := ghClient.List(ctx, pluginDefinitionList); err != nil {
+		return nil, fmt.Errorf("failed to list plugindefinitions: %w", err)
+	}
+	pluginList := new(greenhousev1alpha1.PluginList)
+	if err := ghClient.List(ctx, pluginList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	existingPlugins := make(map[string]struct{}, len(pluginList.Items))
+	for _, plugin := range pluginList.Items {
+		existingPlugins[plugin.GetName()] = struct{}{}
+	}
+
+	var objects []client.Object
+	for _, rel := range releases {
+		if _, exists := existingPlugins[rel.Name]; exists {
+			fmt.Fprintf(errOut, "skipping release %s/%s: plugin %s/%s already exists\n", rel.Namespace, rel.Name, namespace, rel.Name)
+			continue
+		}
+		plugin, secret, err := helm.PluginForRelease(rel, pluginDefinitionList.Items, namespace, clusterName)
+		if err != nil {
+			fmt.Fprintf(errOut, "skipping release %s/%s: %s\n", rel.Namespace, rel.Name, err)
+			continue
+		}
+		existingPlugins[plugin.GetName()] = struct{}{}
+		if secret != nil {
+			objects = append(objects, secret)
+		}
+		objects = append(objects, plugin)
+	}
+	return objects, nil
+}
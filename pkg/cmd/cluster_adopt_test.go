@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Adopt cluster", func() {
+	newRelease := func(name string) *release.Release {
+		return &release.Release{
+			Name:      name,
+			Namespace: "kube-monitoring",
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "kube-monitoring", Version: "1.0.0"}},
+		}
+	}
+
+	It("should generate plugins for releases with a matching PluginDefinition", func() {
+		pluginDefinition := &greenhousev1alpha1.PluginDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-monitoring"},
+			Spec: greenhousev1alpha1.PluginDefinitionSpec{
+				HelmChart: &greenhousev1alpha1.HelmChartReference{Name: "kube-monitoring", Version: "1.0.0"},
+				Options:   []greenhousev1alpha1.PluginOption{{Name: "password", Type: greenhousev1alpha1.PluginOptionTypeSecret}},
+			},
+		}
+		existingPlugin := &greenhousev1alpha1.Plugin{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "existing"}}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(pluginDefinition, existingPlugin).Build()
+
+		unknownRelease := newRelease("unknown")
+		unknownRelease.Chart.Metadata.Name = "unknown"
+		secretRelease := newRelease("secret-monitoring")
+		secretRelease.Config = map[string]any{"password": "s3cr3t"}
+		releases := []*release.Release{newRelease("monitoring"), newRelease("existing"), unknownRelease, secretRelease}
+
+		var errOut bytes.Buffer
+		objects, err := pluginsForReleases(context.Background(), k8sClient, releases, "test-org", "test-cluster", &errOut)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error generating the plugins")
+		Expect(objects).To(HaveLen(3), "only the releases with a matching PluginDefinition and no existing Plugin should be adopted")
+		plugin, ok := objects[0].(*greenhousev1alpha1.Plugin)
+		Expect(ok).To(BeTrue(), "the first object should be a Plugin")
+		Expect(plugin.GetName()).To(Equal("monitoring"))
+		Expect(plugin.Spec.PluginDefinition).To(Equal("kube-monitoring"))
+		Expect(plugin.Spec.ClusterName).To(Equal("test-cluster"))
+		Expect(plugin.Spec.ReleaseNamespace).To(Equal("kube-monitoring"))
+		Expect(objects[1]).To(BeAssignableToTypeOf(&corev1.Secret{}), "the secret values should precede the Plugin referencing them")
+		Expect(objects[1].GetName()).To(Equal("secret-monitoring-values"))
+		Expect(objects[2].GetName()).To(Equal("secret-monitoring"))
+		Expect(errOut.String()).To(ContainSubstring("skipping release kube-monitoring/existing"))
+		Expect(errOut.String()).To(ContainSubstring("skipping release kube-monitoring/unknown"))
+	})
+})
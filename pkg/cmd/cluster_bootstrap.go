@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"strings"
 	"time"
@@ -71,6 +72,9 @@ type newClusterBootstrapOptions struct {
 	clusterName          string
 	greenhouseKubeConfig string
 	onBehafOfUser        string
+	output               string
+	dryRun               bool
+	manifests            bootstrapManifestOptions
 }
 
 func init() {
@@ -82,10 +86,20 @@ func newClusterBootstrapCmd() *cobra.Command {
 	bootstrapCmd := &cobra.Command{
 		Use:   clusterBootstrapCmdUsage,
 		Short: "Bootstrap a Kubernetes cluster to Greenhouse",
+		Long: `Bootstraps a Kubernetes cluster to Greenhouse by creating a service account with cluster-admin permissions in the cluster and the kubeconfig Secret in the organization.
+With --dry-run, the permissions are checked and the resources that would be created or updated are reported.
+With --output=manifests, the resources are written to stdout instead, to be applied by a GitOps pipeline. No access to the clusters is required,
+the token of the service account is referenced from an ExternalSecret or a SealedSecret, see --token-source.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.fillDefaults(); err != nil {
 				return err
 			}
+			switch {
+			case o.output == bootstrapOutputManifests:
+				return o.writeManifests(cmd.OutOrStdout())
+			case o.dryRun:
+				return o.printPlan(ctx, cmd.OutOrStdout())
+			}
 			return o.run()
 		},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
@@ -93,6 +107,17 @@ func newClusterBootstrapCmd() *cobra.Command {
 				return err
 			}
 			o.kubeconfig = cmd.Flag("kubeconfig").Value.String()
+			switch o.output {
+			case "":
+			case bootstrapOutputManifests:
+				// The manifests are generated without access to the clusters.
+				return o.manifests.validate()
+			default:
+				return fmt.Errorf("unsupported output %q, only %q is supported", o.output, bootstrapOutputManifests)
+			}
+			if o.greenhouseKubeConfig == "" {
+				return errors.New("--greenhouse-kubeconfig is required unless --output=manifests is used")
+			}
 			return o.permissionCheck()
 		},
 	}
@@ -105,6 +130,10 @@ func newClusterBootstrapCmd() *cobra.Command {
 	bootstrapCmd.Flags().StringVar(&o.clusterName, "cluster-name", clientutil.GetEnvOrDefault("GREENHOUSE_CLUSTER_NAME", ""), "The cluster name to use. Can be set via GREENHOUSE_CLUSTER_NAME env var")
 	bootstrapCmd.Flags().StringVar(&o.greenhouseKubeConfig, "greenhouse-kubeconfig", "", "The kubeconfig of the greenhouse cluster")
 	bootstrapCmd.Flags().StringVar(&o.onBehafOfUser, "as", "", "The user to impersonate for the operation")
+	bootstrapCmd.Flags().StringVarP(&o.output, "output", "o", "", "Write the resources to stdout instead of creating them. Supported: "+bootstrapOutputManifests)
+	bootstrapCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Check the permissions and report the resources that would be created or updated")
+	o.manifests.addFlags(bootstrapCmd.Flags())
+	bootstrapCmd.MarkFlagsMutuallyExclusive("output", "dry-run")
 
 	// Mark required flags
 	if err := bootstrapCmd.MarkFlagRequired("org"); err != nil {
@@ -113,7 +142,6 @@ func newClusterBootstrapCmd() *cobra.Command {
 	if err := bootstrapCmd.MarkFlagRequired("bootstrap-kubeconfig"); err != nil {
 		setupLog.Error(err, "Flag could not set as required", "bootstrap-kubeconfig")
 	}
-	bootstrapCmd.MarkFlagsRequiredTogether("org", "greenhouse-kubeconfig")
 	// Silence usage to avoid confusing customers
 	bootstrapCmd.SilenceUsage = true
 
@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhouseapisv1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	clustercontroller "github.com/cloudoperators/greenhouse/pkg/controllers/cluster/utils"
+)
+
+const (
+	bootstrapOutputManifests = "manifests"
+
+	tokenSourceExternalSecret = "external-secret"
+	tokenSourceSealedSecret   = "sealed-secret"
+
+	// serviceAccountTokenSecretName is the name of the Secret holding the long-lived token of the service account in the remote cluster.
+	serviceAccountTokenSecretName = "greenhouse-token"
+	// serviceAccountTokenKey is the key of the token in the Secret referenced by the ExternalSecret or SealedSecret.
+	serviceAccountTokenKey = "token"
+)
+
+// bootstrapManifestOptions configure how the token of the service account is referenced by the manifests written by 'cluster bootstrap --output=manifests'.
+type bootstrapManifestOptions struct {
+	tokenSource     string
+	secretStore     string
+	secretStoreKind string
+	remoteKey       string
+	remoteProperty  string
+	sealedToken     string
+}
+
+func (o *bootstrapManifestOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.tokenSource, "token-source", tokenSourceExternalSecret, "How the manifests reference the token of the service account, one of external-secret, sealed-secret")
+	flags.StringVar(&o.secretStore, "secret-store", "", "The name of the SecretStore the ExternalSecret reads the token from")
+	flags.StringVar(&o.secretStoreKind, "secret-store-kind", "ClusterSecretStore", "The kind of the SecretStore, one of SecretStore, ClusterSecretStore")
+	flags.StringVar(&o.remoteKey, "remote-key", "", "The key of the token in the SecretStore (defaults to <org>/<cluster-name>/"+serviceAccountTokenSecretName+")")
+	flags.StringVar(&o.remoteProperty, "remote-property", serviceAccountTokenKey, "The property of the token within the remote key")
+	flags.StringVar(&o.sealedToken, "sealed-token", "", "The token of the service account encrypted with 'kubeseal --raw' for the kubeconfig Secret of the cluster")
+}
+
+func (o *bootstrapManifestOptions) validate() error {
+	switch o.tokenSource {
+	case tokenSourceExternalSecret:
+		if o.secretStore == "" {
+			return errors.New("--secret-store is required for --token-source=" + tokenSourceExternalSecret)
+		}
+		if o.secretStoreKind != "SecretStore" && o.secretStoreKind != "ClusterSecretStore" {
+			return fmt.Errorf("unsupported secret store kind %q", o.secretStoreKind)
+		}
+	case tokenSourceSealedSecret:
+		if o.sealedToken == "" {
+			return errors.New("--sealed-token is required for --token-source=" + tokenSourceSealedSecret)
+		}
+	default:
+		return fmt.Errorf("unsupported token source %q", o.tokenSource)
+	}
+	return nil
+}
+
+// writeManifests writes the resources created in the remote cluster and in Greenhouse as YAML.
+// The API server and CA of the remote cluster are taken from the kubeconfig, the cluster itself is not accessed.
+func (o *newClusterBootstrapOptions) writeManifests(out io.Writer) error {
+	restConfig, err := config.GetConfigWithContext(o.kubecontext)
+	if err != nil {
+		return err
+	}
+	if err := rest.LoadTLSFiles(restConfig); err != nil {
+		return err
+	}
+	objects := remoteClusterManifests(o.orgName)
+	greenhouseObjects, err := greenhouseManifests(restConfig, o.orgName, o.clusterName, &o.manifests)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "# Resources to apply to the cluster %s\n", o.clusterName)
+	if err := writeObjectsAsYAML(out, objects); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "# Resources to apply to the organization %s in Greenhouse\n", o.orgName)
+	return writeObjectsAsYAML(out, greenhouseObjects)
+}
+
+// remoteClusterManifests returns the resources granting Greenhouse access to the remote cluster.
+// In addition to the resources created by the bootstrap, a long-lived token is requested for the service account, to be synced to Greenhouse by the GitOps pipeline.
+func remoteClusterManifests(orgName string) []client.Object {
+	return []client.Object{
+		&corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: orgName},
+		},
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "ServiceAccount"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: orgName, Name: serviceAccountName},
+		},
+		&rbacv1.ClusterRoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName},
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      serviceAccountName,
+					Namespace: orgName,
+				},
+			},
+			RoleRef: rbacv1.RoleRef{
+				Kind:     "ClusterRole",
+				Name:     "cluster-admin",
+				APIGroup: rbacv1.GroupName,
+			},
+		},
+		&corev1.Secret{
+			TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   orgName,
+				Name:        serviceAccountTokenSecretName,
+				Annotations: map[string]string{corev1.ServiceAccountNameKey: serviceAccountName},
+			},
+			Type: corev1.SecretTypeServiceAccountToken,
+		},
+	}
+}
+
+// greenhouseManifests returns the Cluster and the resource producing its kubeconfig Secret in the organization.
+// The kubeconfig is rendered from a template by the External Secrets Operator or the Sealed Secrets controller, so the token never appears in plain text.
+func greenhouseManifests(restConfig *rest.Config, orgName, clusterName string, o *bootstrapManifestOptions) ([]client.Object, error) {
+	tokenTemplate := `{{ .` + serviceAccountTokenKey + ` }}`
+	if o.tokenSource == tokenSourceSealedSecret {
+		tokenTemplate = `{{ index . "` + serviceAccountTokenKey + `" }}`
+	}
+	generateKubeconfig := &clustercontroller.KubeConfigHelper{
+		Host:          restConfig.Host,
+		TLSServerName: restConfig.TLSClientConfig.ServerName,
+		CAData:        restConfig.CAData,
+		BearerToken:   tokenTemplate,
+		Username:      serviceAccountName,
+		Namespace:     orgName,
+	}
+	kubeconfigTemplate, err := clientcmd.Write(generateKubeconfig.RestConfigToAPIConfig(clusterName))
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := &greenhouseapisv1alpha1.Cluster{
+		TypeMeta: metav1.TypeMeta{APIVersion: greenhouseapisv1alpha1.GroupVersion.String(), Kind: "Cluster"},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   orgName,
+			Name:        clusterName,
+			Annotations: map[string]string{greenhouseapis.ClusterConnectivityAnnotation: greenhouseapis.ClusterConnectivityKubeconfig},
+		},
+		Spec: greenhouseapisv1alpha1.ClusterSpec{AccessMode: greenhouseapisv1alpha1.ClusterAccessModeDirect},
+	}
+	secretTemplate := map[string]any{
+		"type": string(greenhouseapis.SecretTypeKubeConfig),
+		"data": map[string]any{greenhouseapis.KubeConfigKey: string(kubeconfigTemplate)},
+	}
+
+	var secretSource = new(unstructured.Unstructured)
+	switch o.tokenSource {
+	case tokenSourceSealedSecret:
+		secretSource.SetAPIVersion("bitnami.com/v1alpha1")
+		secretSource.SetKind("SealedSecret")
+		secretTemplate["metadata"] = map[string]any{"namespace": orgName, "name": clusterName}
+		secretSource.Object["spec"] = map[string]any{
+			"encryptedData": map[string]any{serviceAccountTokenKey: o.sealedToken},
+			"template":      secretTemplate,
+		}
+	default:
+		remoteKey := o.remoteKey
+		if remoteKey == "" {
+			remoteKey = orgName + "/" + clusterName + "/" + serviceAccountTokenSecretName
+		}
+		secretTemplate["engineVersion"] = "v2"
+		secretSource.SetAPIVersion("external-secrets.io/v1beta1")
+		secretSource.SetKind("ExternalSecret")
+		secretSource.Object["spec"] = map[string]any{
+			"secretStoreRef": map[string]any{"kind": o.secretStoreKind, "name": o.secretStore},
+			"target":         map[string]any{"name": clusterName, "template": secretTemplate},
+			"data": []any{
+				map[string]any{
+					"secretKey": serviceAccountTokenKey,
+					"remoteRef": map[string]any{"key": remoteKey, "property": o.remoteProperty},
+				},
+			},
+		}
+	}
+	secretSource.SetNamespace(orgName)
+	secretSource.SetName(clusterName)
+	return []client.Object{secretSource, cluster}, nil
+}
+
+func writeObjectsAsYAML(out io.Writer, objects []client.Object) error {
+	for _, obj := range objects {
+		jsonBytes, err := json.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		yamlBytes, err := jsonToYaml(jsonBytes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "---\n%s", yamlBytes)
+	}
+	return nil
+}
+
+// printPlan reports the resources the bootstrap would create or update without changing them.
+func (o *newClusterBootstrapOptions) printPlan(ctx context.Context, out io.Writer) error {
+	remoteObjects := []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: o.orgName}},
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: o.orgName, Name: serviceAccountName}},
+		&rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName}},
+	}
+	fmt.Fprintf(out, "Permissions are sufficient. Bootstrapping cluster %s into organization %s would\n", o.clusterName, o.orgName)
+	fmt.Fprintf(out, "in the cluster %s:\n", o.customerConfig.Host)
+	for _, obj := range remoteObjects {
+		if err := printPlannedAction(ctx, o.customerClient, obj, out); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "  request a token for ServiceAccount %s/%s\n", o.orgName, serviceAccountName)
+	fmt.Fprintf(out, "in Greenhouse %s:\n", o.ghConfig.Host)
+	if err := printPlannedAction(ctx, o.ghClient, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: o.orgName, Name: o.clusterName}}, out); err != nil {
+		return err
+	}
+	if err := o.ghClient.Get(ctx, client.ObjectKey{Namespace: o.orgName, Name: o.clusterName}, &greenhouseapisv1alpha1.Cluster{}); apierrors.IsNotFound(err) {
+		fmt.Fprintf(out, "  create Cluster %s/%s, done by Greenhouse once the Secret exists\n", o.orgName, o.clusterName)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// printPlannedAction prints whether the object would be created or updated.
+func printPlannedAction(ctx context.Context, c client.Client, obj client.Object, out io.Writer) error {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err != nil {
+		return err
+	}
+	action := "update"
+	if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); apierrors.IsNotFound(err) {
+		action = "create"
+	} else if err != nil {
+		return fmt.Errorf("failed to get %s %s: %w", gvk.Kind, client.ObjectKeyFromObject(obj), err)
+	}
+	name := obj.GetName()
+	if obj.GetNamespace() != "" {
+		name = obj.GetNamespace() + "/" + name
+	}
+	fmt.Fprintf(out, "  %s %s %s\n", action, gvk.Kind, name)
+	return nil
+}
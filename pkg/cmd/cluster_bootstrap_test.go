@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Bootstrap manifests", func() {
+	restConfig := &rest.Config{Host: "https://api.test-cluster.example.com", TLSClientConfig: rest.TLSClientConfig{CAData: []byte("test-ca")}}
+
+	kubeconfigTemplate := func(secretSource *unstructured.Unstructured, path ...string) string {
+		kubeconfig, found, err := unstructured.NestedString(secretSource.Object, path...)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue(), "the kubeconfig template should be set")
+		return kubeconfig
+	}
+
+	It("should reference the token from an ExternalSecret", func() {
+		objects, err := greenhouseManifests(restConfig, "test-org", "test-cluster", &bootstrapManifestOptions{
+			tokenSource: tokenSourceExternalSecret, secretStore: "vault", secretStoreKind: "ClusterSecretStore", remoteProperty: "token",
+		})
+		Expect(err).ToNot(HaveOccurred(), "there should be no error generating the manifests")
+		Expect(objects).To(HaveLen(2))
+
+		externalSecret, ok := objects[0].(*unstructured.Unstructured)
+		Expect(ok).To(BeTrue())
+		Expect(externalSecret.GetKind()).To(Equal("ExternalSecret"))
+		Expect(externalSecret.GetNamespace()).To(Equal("test-org"))
+		secretType, _, _ := unstructured.NestedString(externalSecret.Object, "spec", "target", "template", "type")
+		Expect(secretType).To(Equal(string(greenhouseapis.SecretTypeKubeConfig)))
+		remoteRefs, _, _ := unstructured.NestedSlice(externalSecret.Object, "spec", "data")
+		Expect(remoteRefs).To(ConsistOf(HaveKeyWithValue("remoteRef", map[string]any{"key": "test-org/test-cluster/greenhouse-token", "property": "token"})))
+
+		kubeconfig := kubeconfigTemplate(externalSecret, "spec", "target", "template", "data", greenhouseapis.KubeConfigKey)
+		Expect(kubeconfig).To(ContainSubstring("{{ .token }}"))
+		apiConfig, err := clientcmd.Load([]byte(kubeconfig))
+		Expect(err).ToNot(HaveOccurred(), "the kubeconfig template should be a valid kubeconfig")
+		Expect(apiConfig.Clusters["test-cluster"].Server).To(Equal(restConfig.Host))
+
+		cluster, ok := objects[1].(*greenhousev1alpha1.Cluster)
+		Expect(ok).To(BeTrue())
+		Expect(cluster.Spec.AccessMode).To(Equal(greenhousev1alpha1.ClusterAccessModeDirect))
+	})
+
+	It("should reference the token from a SealedSecret", func() {
+		objects, err := greenhouseManifests(restConfig, "test-org", "test-cluster", &bootstrapManifestOptions{tokenSource: tokenSourceSealedSecret, sealedToken: "AgBy3i4OJSWK"})
+		Expect(err).ToNot(HaveOccurred(), "there should be no error generating the manifests")
+		sealedSecret, ok := objects[0].(*unstructured.Unstructured)
+		Expect(ok).To(BeTrue())
+		Expect(sealedSecret.GetKind()).To(Equal("SealedSecret"))
+		encryptedToken, _, _ := unstructured.NestedString(sealedSecret.Object, "spec", "encryptedData", "token")
+		Expect(encryptedToken).To(Equal("AgBy3i4OJSWK"))
+		Expect(kubeconfigTemplate(sealedSecret, "spec", "template", "data", greenhouseapis.KubeConfigKey)).To(ContainSubstring(`{{ index . "token" }}`))
+	})
+
+	It("should validate the token source flags", func() {
+		Expect((&bootstrapManifestOptions{tokenSource: tokenSourceExternalSecret}).validate()).To(MatchError(ContainSubstring("--secret-store")))
+		Expect((&bootstrapManifestOptions{tokenSource: tokenSourceSealedSecret}).validate()).To(MatchError(ContainSubstring("--sealed-token")))
+		Expect((&bootstrapManifestOptions{tokenSource: "plain"}).validate()).To(HaveOccurred())
+	})
+
+	It("should write the remote RBAC manifests", func() {
+		var out bytes.Buffer
+		Expect(writeObjectsAsYAML(&out, remoteClusterManifests("test-org"))).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("kind: ClusterRoleBinding"))
+		Expect(out.String()).To(ContainSubstring("kubernetes.io/service-account.name: greenhouse"))
+		Expect(out.String()).To(ContainSubstring("type: kubernetes.io/service-account-token"))
+	})
+
+	It("should report whether resources are created or updated", func() {
+		existing := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName}}
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(existing).Build()
+		var out bytes.Buffer
+		Expect(printPlannedAction(context.Background(), remoteClient, &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName}}, &out)).To(Succeed())
+		Expect(printPlannedAction(context.Background(), remoteClient, &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: serviceAccountName}}, &out)).To(Succeed())
+		Expect(out.String()).To(Equal("  update ClusterRoleBinding greenhouse\n  create ServiceAccount test-org/greenhouse\n"))
+	})
+})
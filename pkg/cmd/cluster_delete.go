@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type clusterScheduleDeleteOptions struct {
+	kubecontext string
+	orgName     string
+	at          string
+	in          time.Duration
+}
+
+type clusterCancelDeleteOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterScheduleDeleteCmd())
+	clusterCmd.AddCommand(newClusterCancelDeleteCmd())
+}
+
+func newClusterScheduleDeleteCmd() *cobra.Command {
+	o := &clusterScheduleDeleteOptions{}
+	scheduleDeleteCmd := &cobra.Command{
+		Use:   "schedule-delete <cluster-name>",
+		Short: "Schedule the deletion of a cluster",
+		Long: `Schedules the deletion of a cluster. Without --at or --in, the cluster is deleted 48 hours from now, or at the time already scheduled.
+Use --in=0s to delete the cluster immediately. The resources removed with the cluster are shown by 'greenhousectl cluster describe'.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var schedule *time.Time
+			switch {
+			case cmd.Flags().Changed("at"):
+				at, err := time.Parse(time.DateTime, o.at)
+				if err != nil {
+					return fmt.Errorf("invalid deletion time %q, expected format %q in UTC: %w", o.at, time.DateTime, err)
+				}
+				schedule = &at
+			case cmd.Flags().Changed("in"):
+				in := time.Now().UTC().Add(o.in)
+				schedule = &in
+			}
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			cluster, err := scheduleClusterDeletion(cmd.Context(), k8sClient, o.orgName, args[0], schedule)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("cluster %s/%s scheduled for deletion at %s UTC\n", o.orgName, args[0], deletionSchedule(cluster))
+			return nil
+		},
+	}
+
+	scheduleDeleteCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	scheduleDeleteCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	scheduleDeleteCmd.Flags().StringVar(&o.at, "at", "", "The time to delete the cluster at in UTC, e.g. \"2025-01-17 11:16:40\"")
+	scheduleDeleteCmd.Flags().DurationVar(&o.in, "in", 0, "The duration after which the cluster is deleted, e.g. 24h")
+	scheduleDeleteCmd.MarkFlagsMutuallyExclusive("at", "in")
+	if err := scheduleDeleteCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return scheduleDeleteCmd
+}
+
+func newClusterCancelDeleteCmd() *cobra.Command {
+	o := &clusterCancelDeleteOptions{}
+	cancelDeleteCmd := &cobra.Command{
+		Use:          "cancel-delete <cluster-name>",
+		Short:        "Cancel the scheduled deletion of a cluster",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			if err := cancelClusterDeletion(cmd.Context(), k8sClient, o.orgName, args[0]); err != nil {
+				return err
+			}
+			cmd.Printf("deletion of cluster %s/%s cancelled\n", o.orgName, args[0])
+			return nil
+		},
+	}
+
+	cancelDeleteCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	cancelDeleteCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := cancelDeleteCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return cancelDeleteCmd
+}
+
+// scheduleClusterDeletion marks the cluster for deletion. Without a schedule, the schedule is defaulted by the cluster webhook.
+func scheduleClusterDeletion(ctx context.Context, k8sClient client.Client, namespace, name string, schedule *time.Time) (*greenhousev1alpha1.Cluster, error) {
+	var cluster = new(greenhousev1alpha1.Cluster)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get cluster %s/%s: %w", namespace, name, err)
+	}
+	_, err := clientutil.Patch(ctx, k8sClient, cluster, func() error {
+		annotations := cluster.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[greenhouseapis.MarkClusterDeletionAnnotation] = "true"
+		if schedule != nil {
+			annotations[greenhouseapis.ScheduleClusterDeletionAnnotation] = schedule.UTC().Format(time.DateTime)
+		}
+		cluster.SetAnnotations(annotations)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule deletion of cluster %s/%s: %w", namespace, name, err)
+	}
+	return cluster, nil
+}
+
+// cancelClusterDeletion removes the deletion annotations from the cluster.
+func cancelClusterDeletion(ctx context.Context, k8sClient client.Client, namespace, name string) error {
+	var cluster = new(greenhousev1alpha1.Cluster)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return fmt.Errorf("failed to get cluster %s/%s: %w", namespace, name, err)
+	}
+	if cluster.GetDeletionTimestamp() != nil {
+		return fmt.Errorf("cluster %s/%s is already being deleted", namespace, name)
+	}
+	_, err := clientutil.Patch(ctx, k8sClient, cluster, func() error {
+		annotations := cluster.GetAnnotations()
+		delete(annotations, greenhouseapis.MarkClusterDeletionAnnotation)
+		delete(annotations, greenhouseapis.ScheduleClusterDeletionAnnotation)
+		cluster.SetAnnotations(annotations)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel deletion of cluster %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Schedule and cancel cluster deletion", func() {
+	var (
+		cluster   *greenhousev1alpha1.Cluster
+		k8sClient client.Client
+	)
+
+	BeforeEach(func() {
+		cluster = &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+		k8sClient = fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster).Build()
+	})
+
+	It("should mark the cluster for deletion at the given time", func() {
+		schedule := time.Date(2025, 1, 17, 11, 16, 40, 0, time.UTC)
+		_, err := scheduleClusterDeletion(context.Background(), k8sClient, "test-org", "test-cluster", &schedule)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error scheduling the deletion")
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.MarkClusterDeletionAnnotation, "true"))
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.ScheduleClusterDeletionAnnotation, "2025-01-17 11:16:40"))
+	})
+
+	It("should leave the default schedule to the webhook", func() {
+		_, err := scheduleClusterDeletion(context.Background(), k8sClient, "test-org", "test-cluster", nil)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error scheduling the deletion")
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.MarkClusterDeletionAnnotation, "true"))
+		Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.ScheduleClusterDeletionAnnotation))
+	})
+
+	It("should remove the deletion annotations when cancelling", func() {
+		schedule := time.Now()
+		_, err := scheduleClusterDeletion(context.Background(), k8sClient, "test-org", "test-cluster", &schedule)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cancelClusterDeletion(context.Background(), k8sClient, "test-org", "test-cluster")).To(Succeed(), "there should be no error cancelling the deletion")
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.MarkClusterDeletionAnnotation))
+		Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.ScheduleClusterDeletionAnnotation))
+	})
+})
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type clusterDescribeOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterDescribeCmd())
+}
+
+func newClusterDescribeCmd() *cobra.Command {
+	o := &clusterDescribeOptions{}
+	describeCmd := &cobra.Command{
+		Use:          "describe <cluster-name>",
+		Short:        "Show the details of a cluster",
+		Long:         "Shows the configuration, the conditions, the nodes, the inventory and the deletion schedule of a cluster.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			var cluster = new(greenhousev1alpha1.Cluster)
+			if err := k8sClient.Get(cmd.Context(), client.ObjectKey{Namespace: o.orgName, Name: args[0]}, cluster); err != nil {
+				return fmt.Errorf("failed to get cluster %s/%s: %w", o.orgName, args[0], err)
+			}
+			return printClusterDescription(cmd.OutOrStdout(), cluster, time.Now())
+		},
+	}
+
+	describeCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	describeCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := describeCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return describeCmd
+}
+
+func printClusterDescription(out io.Writer, cluster *greenhousev1alpha1.Cluster, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", cluster.GetName())
+	fmt.Fprintf(w, "Organization:\t%s\n", cluster.GetNamespace())
+	fmt.Fprintf(w, "Labels:\t%s\n", formatLabels(cluster.GetLabels()))
+	fmt.Fprintf(w, "Access mode:\t%s\n", cluster.Spec.AccessMode)
+	fmt.Fprintf(w, "Deletion policy:\t%s\n", cluster.Spec.DeletionPolicy)
+	fmt.Fprintf(w, "Max token validity:\t%dh\n", cluster.Spec.KubeConfig.MaxTokenValidity)
+	fmt.Fprintf(w, "Ready:\t%s\n", clusterReadyState(cluster))
+	fmt.Fprintf(w, "Kubernetes version:\t%s\n", valueOrDash(cluster.Status.KubernetesVersion))
+	if expiry := cluster.Status.BearerTokenExpirationTimestamp; !expiry.IsZero() {
+		fmt.Fprintf(w, "Token expiry:\t%s (%s)\n", expiry.UTC().Format(time.RFC3339), tokenExpiry(expiry, now))
+	}
+	if rotated := cluster.Status.LastCredentialRotationTimestamp; !rotated.IsZero() {
+		fmt.Fprintf(w, "Last credential rotation:\t%s\n", rotated.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(w, "Deletion scheduled:\t%s\n", deletionSchedule(cluster))
+	if migration := cluster.Status.Migration; migration != nil {
+		fmt.Fprintf(w, "Migration:\tto %s, %s\t%s\n", migration.TargetOrganization, migration.Phase, migration.Message)
+	}
+
+	if inventory := cluster.Status.Inventory; inventory != nil {
+		fmt.Fprintln(w, "\nInventory:")
+		fmt.Fprintf(w, "  Provider:\t%s\n", valueOrDash(inventory.Provider))
+		fmt.Fprintf(w, "  Region:\t%s\n", valueOrDash(inventory.Region))
+		fmt.Fprintf(w, "  Zones:\t%s\n", valueOrDash(strings.Join(inventory.Zones, ",")))
+		fmt.Fprintf(w, "  Allocatable:\t%s CPU, %s memory\n", inventory.AllocatableCPU.String(), inventory.AllocatableMemory.String())
+	}
+
+	printConditions(w, cluster.Status.Conditions)
+
+	if len(cluster.Status.Nodes) > 0 {
+		fmt.Fprintf(w, "\nNODE (%s ready)\tREADY\tMESSAGE\n", clusterNodeCounts(cluster))
+		for _, name := range slices.Sorted(maps.Keys(cluster.Status.Nodes)) {
+			node := cluster.Status.Nodes[name]
+			var message string
+			if !node.Ready {
+				if ready := node.GetConditionByType(greenhousev1alpha1.ReadyCondition); ready != nil {
+					message = ready.Message
+				}
+			}
+			fmt.Fprintf(w, "%s\t%t\t%s\n", name, node.Ready, message)
+		}
+	}
+
+	if impact := cluster.Status.DeletionImpact; impact != nil {
+		fmt.Fprintln(w, "\nRemoved on deletion:")
+		fmt.Fprintf(w, "  Plugins:\t%s\n", valueOrDash(strings.Join(impact.Plugins, ",")))
+		fmt.Fprintf(w, "  Helm releases:\t%s\n", valueOrDash(strings.Join(impact.HelmReleases, ",")))
+		fmt.Fprintf(w, "  TeamRoleBindings:\t%s\n", valueOrDash(strings.Join(impact.TeamRoleBindings, ",")))
+		fmt.Fprintf(w, "  Exposed services:\t%s\n", valueOrDash(strings.Join(impact.ExposedServices, ",")))
+	}
+	return w.Flush()
+}
+
+// printConditions prints the conditions sorted by type as a table.
+func printConditions(w io.Writer, conditions []greenhousev1alpha1.Condition) {
+	fmt.Fprintln(w, "\nCONDITION\tSTATUS\tREASON\tMESSAGE")
+	conditions = slices.Clone(conditions)
+	slices.SortFunc(conditions, func(a, b greenhousev1alpha1.Condition) int {
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+	for _, condition := range conditions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return valueOrDash(strings.Join(pairs, ","))
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type clusterListOptions struct {
+	kubecontext string
+	orgName     string
+	selector    string
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterListCmd())
+}
+
+func newClusterListCmd() *cobra.Command {
+	o := &clusterListOptions{}
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List the clusters of an organization",
+		Long:         "Lists the clusters of an organization with their readiness, Kubernetes version, node readiness, the expiry of the token Greenhouse uses to access them and their deletion schedule.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			selector, err := labels.Parse(o.selector)
+			if err != nil {
+				return fmt.Errorf("invalid selector %q: %w", o.selector, err)
+			}
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			var clusterList = new(greenhousev1alpha1.ClusterList)
+			if err := k8sClient.List(cmd.Context(), clusterList, client.InNamespace(o.orgName), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				return fmt.Errorf("failed to list clusters in organization %s: %w", o.orgName, err)
+			}
+			return printClusterList(cmd.OutOrStdout(), clusterList.Items, time.Now())
+		},
+	}
+
+	listCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	listCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	listCmd.Flags().StringVarP(&o.selector, "selector", "l", "", "Label selector to filter the clusters, e.g. -l region=eu-de-1")
+	if err := listCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return listCmd
+}
+
+func printClusterList(out io.Writer, clusters []greenhousev1alpha1.Cluster, now time.Time) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tACCESSMODE\tREADY\tVERSION\tNODES\tTOKEN EXPIRY\tDELETION")
+	for _, cluster := range clusters {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			cluster.GetName(),
+			cluster.Spec.AccessMode,
+			clusterReadyState(&cluster),
+			valueOrDash(cluster.Status.KubernetesVersion),
+			clusterNodeCounts(&cluster),
+			tokenExpiry(cluster.Status.BearerTokenExpirationTimestamp, now),
+			deletionSchedule(&cluster),
+		)
+	}
+	return w.Flush()
+}
+
+func clusterReadyState(cluster *greenhousev1alpha1.Cluster) string {
+	if ready := cluster.Status.GetConditionByType(greenhousev1alpha1.ReadyCondition); ready != nil {
+		return string(ready.Status)
+	}
+	return string(metav1.ConditionUnknown)
+}
+
+// clusterNodeCounts returns the number of ready nodes and the total number of nodes of the cluster.
+func clusterNodeCounts(cluster *greenhousev1alpha1.Cluster) string {
+	var ready int
+	for _, node := range cluster.Status.Nodes {
+		if node.Ready {
+			ready++
+		}
+	}
+	return fmt.Sprintf("%d/%d", ready, len(cluster.Status.Nodes))
+}
+
+// tokenExpiry returns the remaining validity of the bearer token. Clusters accessed via OIDC have no bearer token.
+func tokenExpiry(expiry metav1.Time, now time.Time) string {
+	switch {
+	case expiry.IsZero():
+		return "-"
+	case !expiry.After(now):
+		return "expired"
+	default:
+		return duration.HumanDuration(expiry.Sub(now))
+	}
+}
+
+// deletionSchedule returns the time the cluster is deleted at, if a deletion is scheduled.
+func deletionSchedule(cluster *greenhousev1alpha1.Cluster) string {
+	scheduled, schedule, err := clientutil.ExtractDeletionSchedule(cluster.GetAnnotations())
+	switch {
+	case err != nil:
+		return "invalid schedule"
+	case !scheduled || schedule.IsZero():
+		return "-"
+	default:
+		return schedule.Format(time.DateTime)
+	}
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
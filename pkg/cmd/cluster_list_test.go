@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+var _ = Describe("List and describe clusters", func() {
+	var (
+		now     = time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+		cluster greenhousev1alpha1.Cluster
+	)
+
+	BeforeEach(func() {
+		cluster = greenhousev1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "test-org",
+				Name:      "test-cluster",
+				Labels:    map[string]string{"region": "eu-de-1"},
+				Annotations: map[string]string{
+					greenhouseapis.MarkClusterDeletionAnnotation:     "true",
+					greenhouseapis.ScheduleClusterDeletionAnnotation: "2025-01-17 11:16:40",
+				},
+			},
+			Spec: greenhousev1alpha1.ClusterSpec{AccessMode: greenhousev1alpha1.ClusterAccessModeDirect},
+			Status: greenhousev1alpha1.ClusterStatus{
+				KubernetesVersion:              "v1.31.2",
+				BearerTokenExpirationTimestamp: metav1.NewTime(now.Add(23 * time.Hour)),
+				Nodes: map[string]greenhousev1alpha1.NodeStatus{
+					"node-a": {Ready: true},
+					"node-b": {
+						StatusConditions: greenhousev1alpha1.StatusConditions{Conditions: []greenhousev1alpha1.Condition{
+							greenhousev1alpha1.FalseCondition(greenhousev1alpha1.ReadyCondition, "", "kubelet stopped posting node status"),
+						}},
+					},
+				},
+				DeletionImpact: &greenhousev1alpha1.ClusterDeletionImpact{Plugins: []string{"ingress-nginx"}},
+			},
+		}
+		cluster.Status.SetConditions(greenhousev1alpha1.TrueCondition(greenhousev1alpha1.ReadyCondition, "", ""))
+	})
+
+	It("should list the state, version, nodes, token expiry and deletion schedule", func() {
+		oidcCluster := greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "oidc-cluster"}}
+		var out bytes.Buffer
+		Expect(printClusterList(&out, []greenhousev1alpha1.Cluster{cluster, oidcCluster}, now)).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`test-cluster\s+direct\s+True\s+v1.31.2\s+1/2\s+23h\s+2025-01-17 11:16:40`))
+		Expect(out.String()).To(MatchRegexp(`oidc-cluster\s+Unknown\s+-\s+0/0\s+-\s+-`))
+	})
+
+	It("should report an expired token", func() {
+		Expect(tokenExpiry(metav1.NewTime(now.Add(-time.Minute)), now)).To(Equal("expired"))
+	})
+
+	It("should describe the nodes and the deletion impact", func() {
+		var out bytes.Buffer
+		Expect(printClusterDescription(&out, &cluster, now)).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`Labels:\s+region=eu-de-1`))
+		Expect(out.String()).To(MatchRegexp(`Deletion scheduled:\s+2025-01-17 11:16:40`))
+		Expect(out.String()).To(MatchRegexp(`node-b\s+false\s+kubelet stopped posting node status`))
+		Expect(out.String()).To(MatchRegexp(`Plugins:\s+ingress-nginx`))
+	})
+})
@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+// clusterMigrationPollInterval is the interval in which the progress of a migration is checked.
+var clusterMigrationPollInterval = 5 * time.Second
+
+type clusterMigrateOptions struct {
+	kubecontext string
+	orgName     string
+	targetOrg   string
+	wait        bool
+	timeout     time.Duration
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterMigrateCmd())
+}
+
+func newClusterMigrateCmd() *cobra.Command {
+	o := &clusterMigrateOptions{}
+	migrateCmd := &cobra.Command{
+		Use:   "migrate <cluster-name>",
+		Short: "Migrate a cluster to another organization",
+		Long: `Requests the migration of a cluster to another organization.
+Greenhouse moves the Cluster and its kubeconfig Secret to the target organization and recreates the Plugins there, which adopt the existing Helm releases.
+TeamRoleBindings for the cluster are recreated in the target organization if their Team and TeamRole exist there.
+Once migrated, the cluster is removed from the source organization without uninstalling any workloads.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			if err := requestClusterMigration(cmd.Context(), k8sClient, o.orgName, args[0], o.targetOrg); err != nil {
+				return err
+			}
+			cmd.Printf("requested migration of cluster %s/%s to organization %s\n", o.orgName, args[0], o.targetOrg)
+			if !o.wait {
+				return nil
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), o.timeout)
+			defer cancel()
+			return waitForClusterMigration(ctx, k8sClient, o.orgName, args[0], o.targetOrg, cmd.OutOrStdout())
+		},
+	}
+
+	migrateCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	migrateCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	migrateCmd.Flags().StringVar(&o.targetOrg, "target-org", "", "The organization to migrate the cluster to")
+	migrateCmd.Flags().BoolVar(&o.wait, "wait", false, "Wait for the migration to complete")
+	migrateCmd.Flags().DurationVar(&o.timeout, "timeout", 10*time.Minute, "The time to wait for the migration to complete")
+	for _, flagName := range []string{"org", "target-org"} {
+		if err := migrateCmd.MarkFlagRequired(flagName); err != nil {
+			setupLog.Error(err, "Flag could not set as required", flagName)
+		}
+	}
+	return migrateCmd
+}
+
+// requestClusterMigration annotates the cluster to request its migration to the target organization.
+func requestClusterMigration(ctx context.Context, k8sClient client.Client, namespace, name, targetOrg string) error {
+	if namespace == targetOrg {
+		return fmt.Errorf("cluster %s already belongs to organization %s", name, targetOrg)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: targetOrg}, &greenhousev1alpha1.Organization{}); err != nil {
+		return fmt.Errorf("failed to get organization %s: %w", targetOrg, err)
+	}
+	var cluster = new(greenhousev1alpha1.Cluster)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return fmt.Errorf("failed to get cluster %s/%s: %w", namespace, name, err)
+	}
+	_, err := clientutil.Patch(ctx, k8sClient, cluster, func() error {
+		annotations := cluster.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[greenhouseapis.ClusterMigrateToOrganizationAnnotation] = targetOrg
+		cluster.SetAnnotations(annotations)
+		return nil
+	})
+	return err
+}
+
+// waitForClusterMigration reports the phases of the migration until the cluster was removed from the source organization.
+func waitForClusterMigration(ctx context.Context, k8sClient client.Client, namespace, name, targetOrg string, out io.Writer) error {
+	var lastPhase greenhousev1alpha1.ClusterMigrationPhase
+	return wait.PollUntilContextCancel(ctx, clusterMigrationPollInterval, true, func(ctx context.Context) (bool, error) {
+		var cluster = new(greenhousev1alpha1.Cluster)
+		err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: targetOrg, Name: name}, cluster); err != nil {
+				return false, fmt.Errorf("cluster %s was removed from organization %s but not found in organization %s: %w", name, namespace, targetOrg, err)
+			}
+			fmt.Fprintf(out, "cluster %s was migrated to organization %s\n", name, targetOrg)
+			return true, nil
+		case err != nil:
+			return false, err
+		}
+		migration := cluster.Status.Migration
+		if migration == nil || migration.TargetOrganization != targetOrg || migration.Phase == lastPhase {
+			return false, nil
+		}
+		lastPhase = migration.Phase
+		if migration.Phase == greenhousev1alpha1.ClusterMigrationPhaseFailed {
+			return false, fmt.Errorf("migration failed in step %s: %s", migration.FailedStep, migration.Message)
+		}
+		fmt.Fprintf(out, "%s: %s\n", migration.Phase, migration.Message)
+		return false, nil
+	})
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Migrate cluster", func() {
+	var (
+		cluster   *greenhousev1alpha1.Cluster
+		targetOrg *greenhousev1alpha1.Organization
+	)
+
+	BeforeEach(func() {
+		cluster = &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+		targetOrg = &greenhousev1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "target-org"}}
+		clusterMigrationPollInterval = 10 * time.Millisecond
+	})
+
+	It("should annotate the cluster to request the migration", func() {
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster, targetOrg).Build()
+		Expect(requestClusterMigration(context.Background(), k8sClient, "test-org", "test-cluster", "target-org")).To(Succeed(), "there should be no error requesting the migration")
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.ClusterMigrateToOrganizationAnnotation, "target-org"), "the cluster should be annotated")
+	})
+
+	It("should fail for an unknown or the same organization", func() {
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster).Build()
+		Expect(requestClusterMigration(context.Background(), k8sClient, "test-org", "test-cluster", "target-org")).ToNot(Succeed(), "the target organization should exist")
+		Expect(requestClusterMigration(context.Background(), k8sClient, "test-org", "test-cluster", "test-org")).ToNot(Succeed(), "the target organization should differ")
+	})
+
+	It("should wait until the cluster was migrated", func() {
+		migratedCluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "test-cluster"}}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(migratedCluster).Build()
+		var out bytes.Buffer
+		Expect(waitForClusterMigration(context.Background(), k8sClient, "test-org", "test-cluster", "target-org", &out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("cluster test-cluster was migrated to organization target-org"))
+	})
+
+	It("should report a failed migration", func() {
+		cluster.Status.Migration = &greenhousev1alpha1.ClusterMigrationStatus{
+			TargetOrganization: "target-org",
+			Phase:              greenhousev1alpha1.ClusterMigrationPhaseFailed,
+			FailedStep:         greenhousev1alpha1.ClusterMigrationPhaseMigratingPlugins,
+			Message:            "failed to create plugin ingress in organization target-org: object already exists",
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster).Build()
+		var out bytes.Buffer
+		err := waitForClusterMigration(context.Background(), k8sClient, "test-org", "test-cluster", "target-org", &out)
+		Expect(err).To(MatchError(ContainSubstring("step MigratingPlugins")), "the failed step should be reported")
+		Expect(err).To(MatchError(ContainSubstring("already exists")), "the failure should be reported")
+	})
+})
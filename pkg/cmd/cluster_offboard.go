@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type clusterOffboardOptions struct {
+	kubecontext          string
+	orgName              string
+	clusterName          string
+	greenhouseKubeConfig string
+	force                bool
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterOffboardCmd())
+}
+
+func newClusterOffboardCmd() *cobra.Command {
+	o := &clusterOffboardOptions{}
+	offboardCmd := &cobra.Command{
+		Use:   "offboard",
+		Short: "Remove the access of Greenhouse from a cluster",
+		Long: `Removes the service account and the ClusterRoleBinding created by 'greenhousectl cluster bootstrap' from the cluster.
+Greenhouse removes them itself when the cluster is deleted, this command cleans up if that was not possible, e.g. because the token of Greenhouse had expired.
+The cluster has to be deleted from the organization first, use 'greenhousectl cluster schedule-delete'. The check is skipped with --force.
+If the cluster was migrated to another organization, only the access of the given organization is removed.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+
+	offboardCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the cluster to offboard (defaults to current-context)")
+	offboardCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	offboardCmd.Flags().StringVar(&o.clusterName, "cluster-name", clientutil.GetEnvOrDefault("GREENHOUSE_CLUSTER_NAME", ""), "The name of the cluster in Greenhouse. Can be set via GREENHOUSE_CLUSTER_NAME env var")
+	offboardCmd.Flags().StringVar(&o.greenhouseKubeConfig, "greenhouse-kubeconfig", "", "The kubeconfig of the greenhouse cluster, used to check the cluster was deleted from the organization")
+	offboardCmd.Flags().BoolVar(&o.force, "force", false, "Remove the access even if the cluster still exists in the organization")
+	for _, flagName := range []string{"org", "cluster-name"} {
+		if err := offboardCmd.MarkFlagRequired(flagName); err != nil {
+			setupLog.Error(err, "Flag could not set as required", flagName)
+		}
+	}
+	return offboardCmd
+}
+
+func (o *clusterOffboardOptions) run(ctx context.Context, out io.Writer) error {
+	if !o.force {
+		if o.greenhouseKubeConfig == "" {
+			return errors.New("--greenhouse-kubeconfig is required to check the cluster was deleted from the organization, use --force to skip the check")
+		}
+		ghConfig, err := clientcmd.BuildConfigFromFlags("", o.greenhouseKubeConfig)
+		if err != nil {
+			return err
+		}
+		ghClient, err := clientutil.NewK8sClient(ghConfig)
+		if err != nil {
+			return err
+		}
+		if err := checkClusterDeleted(ctx, ghClient, o.orgName, o.clusterName); err != nil {
+			return err
+		}
+	}
+	clusterConfig, err := config.GetConfigWithContext(o.kubecontext)
+	if err != nil {
+		return err
+	}
+	remoteClient, err := clientutil.NewK8sClient(clusterConfig)
+	if err != nil {
+		return err
+	}
+	return offboardCluster(ctx, remoteClient, o.orgName, out)
+}
+
+// checkClusterDeleted returns an error if the cluster still exists in the organization.
+// Removing the access of Greenhouse before would leave the Plugins of the cluster behind.
+func checkClusterDeleted(ctx context.Context, ghClient client.Client, namespace, name string) error {
+	err := ghClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &greenhousev1alpha1.Cluster{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get cluster %s/%s: %w", namespace, name, err)
+	default:
+		return fmt.Errorf("cluster %s/%s still exists in Greenhouse, delete it with 'greenhousectl cluster schedule-delete' first", namespace, name)
+	}
+}
+
+// offboardCluster removes the ClusterRoleBinding and the service account of the organization from the cluster.
+// The ClusterRoleBinding is kept if it still grants access to the service account of another organization the cluster was migrated to.
+func offboardCluster(ctx context.Context, remoteClient client.Client, orgName string, out io.Writer) error {
+	var clusterRoleBinding = new(rbacv1.ClusterRoleBinding)
+	err := remoteClient.Get(ctx, client.ObjectKey{Name: serviceAccountName}, clusterRoleBinding)
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Fprintf(out, "clusterRoleBinding %s not found\n", serviceAccountName)
+	case err != nil:
+		return fmt.Errorf("failed to get clusterRoleBinding %s: %w", serviceAccountName, err)
+	default:
+		subjects := slices.DeleteFunc(slices.Clone(clusterRoleBinding.Subjects), func(s rbacv1.Subject) bool {
+			return s.Kind == rbacv1.ServiceAccountKind && s.Name == serviceAccountName && s.Namespace == orgName
+		})
+		if len(subjects) == 0 {
+			if err := remoteClient.Delete(ctx, clusterRoleBinding); client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("failed to delete clusterRoleBinding %s: %w", serviceAccountName, err)
+			}
+			fmt.Fprintf(out, "deleted clusterRoleBinding %s\n", serviceAccountName)
+			break
+		}
+		if len(subjects) < len(clusterRoleBinding.Subjects) {
+			if _, err := clientutil.Patch(ctx, remoteClient, clusterRoleBinding, func() error {
+				clusterRoleBinding.Subjects = subjects
+				return nil
+			}); err != nil {
+				return fmt.Errorf("failed to update clusterRoleBinding %s: %w", serviceAccountName, err)
+			}
+		}
+		fmt.Fprintf(out, "removed service account %s/%s from clusterRoleBinding %s, it is still used by other subjects\n", orgName, serviceAccountName, serviceAccountName)
+	}
+
+	var serviceAccount = new(corev1.ServiceAccount)
+	serviceAccount.Name = serviceAccountName
+	serviceAccount.Namespace = orgName
+	err = remoteClient.Delete(ctx, serviceAccount)
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Fprintf(out, "serviceAccount %s/%s not found\n", orgName, serviceAccountName)
+	case err != nil:
+		return fmt.Errorf("failed to delete serviceAccount %s/%s: %w", orgName, serviceAccountName, err)
+	default:
+		fmt.Fprintf(out, "deleted serviceAccount %s/%s\n", orgName, serviceAccountName)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Offboard a cluster", func() {
+	var serviceAccount *corev1.ServiceAccount
+
+	BeforeEach(func() {
+		serviceAccount = &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: serviceAccountName}}
+	})
+
+	newClusterRoleBinding := func(orgNames ...string) *rbacv1.ClusterRoleBinding {
+		crb := &rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceAccountName},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin", APIGroup: rbacv1.GroupName},
+		}
+		for _, orgName := range orgNames {
+			crb.Subjects = append(crb.Subjects, rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: orgName})
+		}
+		return crb
+	}
+
+	It("should delete the service account and the cluster role binding", func() {
+		crb := newClusterRoleBinding("test-org")
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(serviceAccount, crb).Build()
+		var out bytes.Buffer
+		Expect(offboardCluster(context.Background(), remoteClient, "test-org", &out)).To(Succeed(), "there should be no error offboarding the cluster")
+		err := remoteClient.Get(context.Background(), client.ObjectKeyFromObject(crb), crb)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "the cluster role binding should be deleted")
+		err = remoteClient.Get(context.Background(), client.ObjectKeyFromObject(serviceAccount), serviceAccount)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "the service account should be deleted")
+	})
+
+	It("should keep the cluster role binding used by another organization", func() {
+		crb := newClusterRoleBinding("test-org", "other-org")
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(serviceAccount, crb).Build()
+		Expect(offboardCluster(context.Background(), remoteClient, "test-org", &bytes.Buffer{})).To(Succeed(), "there should be no error offboarding the cluster")
+		Expect(remoteClient.Get(context.Background(), client.ObjectKeyFromObject(crb), crb)).To(Succeed(), "the cluster role binding should be kept")
+		Expect(crb.Subjects).To(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: serviceAccountName, Namespace: "other-org"}))
+	})
+
+	It("should succeed if the resources are already gone", func() {
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).Build()
+		var out bytes.Buffer
+		Expect(offboardCluster(context.Background(), remoteClient, "test-org", &out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("not found"))
+	})
+
+	It("should refuse to offboard a cluster still existing in Greenhouse", func() {
+		cluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+		ghClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster).Build()
+		Expect(checkClusterDeleted(context.Background(), ghClient, "test-org", "test-cluster")).To(MatchError(ContainSubstring("still exists")))
+		Expect(checkClusterDeleted(context.Background(), ghClient, "test-org", "other-cluster")).To(Succeed())
+	})
+})
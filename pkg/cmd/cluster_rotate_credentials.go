@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type clusterRotateCredentialsOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	clusterCmd.AddCommand(newClusterRotateCredentialsCmd())
+}
+
+func newClusterRotateCredentialsCmd() *cobra.Command {
+	o := &clusterRotateCredentialsOptions{}
+	rotateCredentialsCmd := &cobra.Command{
+		Use:   "rotate-credentials <cluster-name>",
+		Short: "Rotate the credentials Greenhouse uses to access a cluster",
+		Long: `Requests the rotation of the credentials Greenhouse uses to access a cluster.
+The service account Greenhouse uses in the cluster is recreated, which revokes all tokens issued for it, and a new token is written to the kubeconfig Secret of the cluster.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			if err := requestCredentialRotation(cmd.Context(), k8sClient, o.orgName, args[0]); err != nil {
+				return err
+			}
+			cmd.Printf("requested credential rotation for cluster %s/%s\n", o.orgName, args[0])
+			return nil
+		},
+	}
+
+	rotateCredentialsCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	rotateCredentialsCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := rotateCredentialsCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return rotateCredentialsCmd
+}
+
+// requestCredentialRotation annotates the cluster to request the rotation of its credentials.
+func requestCredentialRotation(ctx context.Context, k8sClient client.Client, namespace, name string) error {
+	var cluster = new(greenhousev1alpha1.Cluster)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, cluster); err != nil {
+		return fmt.Errorf("failed to get cluster %s/%s: %w", namespace, name, err)
+	}
+	_, err := clientutil.Patch(ctx, k8sClient, cluster, func() error {
+		annotations := cluster.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[greenhouseapis.ClusterRotateCredentialsAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		cluster.SetAnnotations(annotations)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Rotate cluster credentials", func() {
+	It("should annotate the cluster to request the rotation", func() {
+		cluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster).Build()
+
+		Expect(requestCredentialRotation(context.Background(), k8sClient, "test-org", "test-cluster")).To(Succeed(), "there should be no error requesting the rotation")
+		Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cluster), cluster)).To(Succeed())
+		Expect(cluster.GetAnnotations()).To(HaveKey(greenhouseapis.ClusterRotateCredentialsAnnotation), "the cluster should be annotated")
+	})
+
+	It("should fail for an unknown cluster", func() {
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).Build()
+		err := requestCredentialRotation(context.Background(), k8sClient, "test-org", "unknown")
+		Expect(apierrors.IsNotFound(err)).To(BeTrue(), "the error should be a not found error")
+	})
+})
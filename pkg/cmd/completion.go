@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+const (
+	// completionCacheTTL is the time completions are served from the cache before the Greenhouse API is queried again.
+	completionCacheTTL = 30 * time.Second
+	// completionTimeout limits the time a completion waits for the Greenhouse API.
+	completionTimeout = 5 * time.Second
+)
+
+var (
+	// completionCacheDir is the directory the completion results are cached in.
+	completionCacheDir = defaultCompletionCacheDir()
+
+	// newCompletionClient returns the client to query the Greenhouse API for completions of the command.
+	// Commands working on another cluster, e.g. 'cluster bootstrap', access Greenhouse via --greenhouse-kubeconfig.
+	newCompletionClient = func(cmd *cobra.Command) (client.Client, error) {
+		var restConfig *rest.Config
+		var err error
+		if cmd.Flags().Lookup("greenhouse-kubeconfig") != nil {
+			greenhouseKubeconfig := flagValue(cmd, "greenhouse-kubeconfig")
+			if greenhouseKubeconfig == "" {
+				return nil, errors.New("--greenhouse-kubeconfig is required to complete from the Greenhouse API")
+			}
+			restConfig, err = clientcmd.BuildConfigFromFlags("", greenhouseKubeconfig)
+		} else {
+			restConfig, err = config.GetConfigWithContext(flagValue(cmd, "kubecontext"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		return clientutil.NewK8sClient(restConfig)
+	}
+)
+
+// completionLister lists the completions for the command from the Greenhouse API.
+type completionLister func(ctx context.Context, k8sClient client.Client, cmd *cobra.Command, args []string) ([]string, error)
+
+var (
+	completeOrganizations     = completeFromAPI("organizations", cobra.ShellCompDirectiveNoFileComp, listOrganizationNames)
+	completeClusterNames      = completeFromAPI("clusters", cobra.ShellCompDirectiveNoFileComp, listClusterNames)
+	completePluginNames       = completeFromAPI("plugins", cobra.ShellCompDirectiveNoFileComp, listPluginNames)
+	completeTeamNames         = completeFromAPI("teams", cobra.ShellCompDirectiveNoFileComp, listTeamNames)
+	completePluginDefinitions = completeFromAPI("pluginDefinitions", cobra.ShellCompDirectiveNoFileComp, listPluginDefinitionNames)
+	completeOptionValues      = completeFromAPI("optionValues", cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, listOptionNames("=", isNotSecretOption))
+	completeSecretOptionNames = completeFromAPI("secretOptionValues", cobra.ShellCompDirectiveNoFileComp|cobra.ShellCompDirectiveNoSpace, listOptionNames("=", isSecretOption))
+	completeOptionNames       = completeFromAPI("optionNames", cobra.ShellCompDirectiveNoFileComp, listOptionNames("", nil))
+)
+
+// flagCompletions maps flag names to their completion. They are registered for all commands having the flag.
+var flagCompletions = map[string]cobra.CompletionFunc{
+	"org":               completeOrganizations,
+	"target-org":        completeOrganizations,
+	"cluster":           completeClusterNames,
+	"cluster-name":      completeClusterNames,
+	"plugin":            completePluginNames,
+	"plugin-definition": completePluginDefinitions,
+	"set":               completeOptionValues,
+	"set-secret":        completeSecretOptionNames,
+	"unset":             completeOptionNames,
+}
+
+// argCompletions maps the path of commands to the completion of their arguments.
+var argCompletions = map[string]cobra.CompletionFunc{
+	programName + " cluster cancel-delete":      completeClusterNames,
+	programName + " cluster describe":           completeClusterNames,
+	programName + " cluster migrate":            completeClusterNames,
+	programName + " cluster rotate-credentials": completeClusterNames,
+	programName + " cluster schedule-delete":    completeClusterNames,
+	programName + " org check":                  completeOrganizations,
+	programName + " org describe":               completeOrganizations,
+	programName + " plugin diff":                completePluginNames,
+	programName + " plugin status":              completePluginNames,
+	programName + " plugin upgrade":             completePluginNames,
+	programName + " team members":               completeTeamNames,
+}
+
+// registerCompletions registers the completions backed by the Greenhouse API for the command and all its sub commands.
+func registerCompletions(cmd *cobra.Command) {
+	for flagName, completion := range flagCompletions {
+		if cmd.Flags().Lookup(flagName) == nil {
+			continue
+		}
+		if _, ok := cmd.GetFlagCompletionFunc(flagName); ok {
+			continue
+		}
+		if err := cmd.RegisterFlagCompletionFunc(flagName, completion); err != nil {
+			setupLog.Error(err, "Flag completion could not be registered", "flag", flagName)
+		}
+	}
+	if completion, ok := argCompletions[cmd.CommandPath()]; ok && cmd.ValidArgsFunction == nil {
+		cmd.ValidArgsFunction = completion
+	}
+	for _, subCmd := range cmd.Commands() {
+		registerCompletions(subCmd)
+	}
+}
+
+// completeFromAPI returns a completion function querying the Greenhouse API with the kubeconfig of the user.
+// The results are cached briefly, as shells request completions repeatedly while typing.
+func completeFromAPI(name string, directive cobra.ShellCompDirective, list completionLister) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		key := []string{name, os.Getenv("KUBECONFIG")}
+		for _, flagName := range []string{"kubecontext", "greenhouse-kubeconfig", "org", "plugin-definition"} {
+			key = append(key, flagValue(cmd, flagName))
+		}
+		completions, err := cachedCompletions(append(key, args...), func() ([]string, error) {
+			k8sClient, err := newCompletionClient(cmd)
+			if err != nil {
+				return nil, err
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+			defer cancel()
+			return list(ctx, k8sClient, cmd, args)
+		})
+		if err != nil {
+			cobra.CompDebugln(err.Error(), false)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		matching := make([]cobra.Completion, 0, len(completions))
+		for _, completion := range completions {
+			if strings.HasPrefix(completion, toComplete) {
+				matching = append(matching, completion)
+			}
+		}
+		return matching, directive
+	}
+}
+
+// cachedCompletions returns the completions cached for the key if they are recent, otherwise they are listed and cached.
+// Failing to read or write the cache is not an error, the completions are listed instead.
+func cachedCompletions(key []string, list func() ([]string, error)) ([]string, error) {
+	sum := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+	cacheFile := filepath.Join(completionCacheDir, hex.EncodeToString(sum[:])+".json")
+	if info, err := os.Stat(cacheFile); err == nil && time.Since(info.ModTime()) < completionCacheTTL {
+		if data, err := os.ReadFile(cacheFile); err == nil {
+			var completions []string
+			if err := json.Unmarshal(data, &completions); err == nil {
+				return completions, nil
+			}
+		}
+	}
+	completions, err := list()
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(completions); err == nil && os.MkdirAll(completionCacheDir, 0o700) == nil {
+		if err := os.WriteFile(cacheFile, data, 0o600); err != nil {
+			cobra.CompDebugln(err.Error(), false)
+		}
+	}
+	return completions, nil
+}
+
+func defaultCompletionCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, programName, "completion")
+}
+
+// flagValue returns the value of the flag or an empty string if the command has no such flag.
+func flagValue(cmd *cobra.Command, flagName string) string {
+	flag := cmd.Flags().Lookup(flagName)
+	if flag == nil {
+		return ""
+	}
+	return flag.Value.String()
+}
+
+func listOrganizationNames(ctx context.Context, k8sClient client.Client, _ *cobra.Command, _ []string) ([]string, error) {
+	var organizations = new(greenhousev1alpha1.OrganizationList)
+	if err := k8sClient.List(ctx, organizations); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(organizations.Items))
+	for _, org := range organizations.Items {
+		names = append(names, cobra.CompletionWithDesc(org.GetName(), org.Spec.DisplayName))
+	}
+	return names, nil
+}
+
+func listClusterNames(ctx context.Context, k8sClient client.Client, cmd *cobra.Command, _ []string) ([]string, error) {
+	return listNamesInOrganization(ctx, k8sClient, flagValue(cmd, "org"), "Cluster")
+}
+
+func listPluginNames(ctx context.Context, k8sClient client.Client, cmd *cobra.Command, _ []string) ([]string, error) {
+	return listNamesInOrganization(ctx, k8sClient, flagValue(cmd, "org"), "Plugin")
+}
+
+func listTeamNames(ctx context.Context, k8sClient client.Client, cmd *cobra.Command, _ []string) ([]string, error) {
+	return listNamesInOrganization(ctx, k8sClient, flagValue(cmd, "org"), "Team")
+}
+
+// listNamesInOrganization returns the names of the Greenhouse objects of the kind in the namespace of the organization.
+// Nothing is listed as long as the organization is not given.
+func listNamesInOrganization(ctx context.Context, k8sClient client.Client, orgName, kind string) ([]string, error) {
+	if orgName == "" {
+		return nil, nil
+	}
+	var objects = new(metav1.PartialObjectMetadataList)
+	objects.SetGroupVersionKind(greenhousev1alpha1.GroupVersion.WithKind(kind + "List"))
+	if err := k8sClient.List(ctx, objects, client.InNamespace(orgName)); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(objects.Items))
+	for _, obj := range objects.Items {
+		names = append(names, obj.GetName())
+	}
+	return names, nil
+}
+
+func listPluginDefinitionNames(ctx context.Context, k8sClient client.Client, _ *cobra.Command, _ []string) ([]string, error) {
+	var pluginDefinitions = new(greenhousev1alpha1.PluginDefinitionList)
+	if err := k8sClient.List(ctx, pluginDefinitions); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(pluginDefinitions.Items))
+	for _, pluginDefinition := range pluginDefinitions.Items {
+		names = append(names, cobra.CompletionWithDesc(pluginDefinition.GetName(), pluginDefinition.Spec.Description))
+	}
+	return names, nil
+}
+
+// listOptionNames returns a lister for the options of the PluginDefinition given via --plugin-definition or used by the Plugin given as argument.
+// The suffix is appended to the option names, the options are restricted by filter if given.
+func listOptionNames(suffix string, filter func(greenhousev1alpha1.PluginOption) bool) completionLister {
+	return func(ctx context.Context, k8sClient client.Client, cmd *cobra.Command, args []string) ([]string, error) {
+		pluginDefinitionName := flagValue(cmd, "plugin-definition")
+		if pluginDefinitionName == "" {
+			orgName := flagValue(cmd, "org")
+			if orgName == "" || len(args) == 0 {
+				return nil, nil
+			}
+			var plugin = new(greenhousev1alpha1.Plugin)
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: orgName, Name: args[0]}, plugin); err != nil {
+				return nil, err
+			}
+			pluginDefinitionName = plugin.Spec.PluginDefinition
+		}
+		var pluginDefinition = new(greenhousev1alpha1.PluginDefinition)
+		if err := k8sClient.Get(ctx, client.ObjectKey{Name: pluginDefinitionName}, pluginDefinition); err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(pluginDefinition.Spec.Options))
+		for _, option := range pluginDefinition.Spec.Options {
+			if filter != nil && !filter(option) {
+				continue
+			}
+			names = append(names, cobra.CompletionWithDesc(option.Name+suffix, option.Description))
+		}
+		return names, nil
+	}
+}
+
+func isSecretOption(option greenhousev1alpha1.PluginOption) bool {
+	return option.Type == greenhousev1alpha1.PluginOptionTypeSecret
+}
+
+func isNotSecretOption(option greenhousev1alpha1.PluginOption) bool {
+	return !isSecretOption(option)
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Completions backed by the Greenhouse API", func() {
+	var (
+		k8sClient client.Client
+		requests  int
+	)
+
+	BeforeEach(func() {
+		k8sClient = fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			&greenhousev1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "test-org"}, Spec: greenhousev1alpha1.OrganizationSpec{DisplayName: "Test"}},
+			&greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "cluster-eu-de-1-production"}},
+			&greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "cluster-na-us-1-production"}},
+			&greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "other-org", Name: "cluster-other"}},
+			&greenhousev1alpha1.PluginDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-plugin"},
+				Spec: greenhousev1alpha1.PluginDefinitionSpec{Options: []greenhousev1alpha1.PluginOption{
+					{Name: "replicas", Type: greenhousev1alpha1.PluginOptionTypeInt, Description: "Number of replicas"},
+					{Name: "password", Type: greenhousev1alpha1.PluginOptionTypeSecret, Description: "Admin password"},
+				}},
+			},
+			&greenhousev1alpha1.Plugin{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "my-plugin"}, Spec: greenhousev1alpha1.PluginSpec{PluginDefinition: "test-plugin"}},
+		).Build()
+		requests = 0
+		originalClient, originalCacheDir := newCompletionClient, completionCacheDir
+		newCompletionClient = func(*cobra.Command) (client.Client, error) {
+			requests++
+			return k8sClient, nil
+		}
+		completionCacheDir = GinkgoT().TempDir()
+		DeferCleanup(func() {
+			newCompletionClient, completionCacheDir = originalClient, originalCacheDir
+		})
+	})
+
+	It("should complete the clusters of the organization", func() {
+		cmd := newClusterDescribeCmd()
+		Expect(cmd.Flags().Set("org", "test-org")).To(Succeed())
+		completions, directive := completeClusterNames(cmd, nil, "cluster-eu")
+		Expect(completions).To(ConsistOf("cluster-eu-de-1-production"))
+		Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+	})
+
+	It("should complete the organizations with their display name", func() {
+		completions, _ := completeOrganizations(newClusterListCmd(), nil, "")
+		Expect(completions).To(ConsistOf("test-org\tTest"))
+	})
+
+	It("should complete the option names of the PluginDefinition of a Plugin", func() {
+		cmd := newPluginUpgradeCmd()
+		Expect(cmd.Flags().Set("org", "test-org")).To(Succeed())
+		completions, directive := completeOptionValues(cmd, []string{"my-plugin"}, "")
+		Expect(completions).To(ConsistOf("replicas=\tNumber of replicas"))
+		Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace))
+		completions, _ = completeSecretOptionNames(cmd, []string{"my-plugin"}, "")
+		Expect(completions).To(ConsistOf("password=\tAdmin password"))
+	})
+
+	It("should complete the option names of the PluginDefinition given via flag", func() {
+		cmd := newPluginInstallCmd()
+		Expect(cmd.Flags().Set("plugin-definition", "test-plugin")).To(Succeed())
+		completions, _ := completeOptionNames(cmd, []string{"new-plugin"}, "pass")
+		Expect(completions).To(ConsistOf("password\tAdmin password"))
+	})
+
+	It("should serve repeated completions from the cache", func() {
+		cmd := newClusterDescribeCmd()
+		Expect(cmd.Flags().Set("org", "test-org")).To(Succeed())
+		completeClusterNames(cmd, nil, "")
+		completions, _ := completeClusterNames(cmd, nil, "")
+		Expect(completions).To(HaveLen(2))
+		Expect(requests).To(Equal(1), "the second completion should be served from the cache")
+
+		Expect(cmd.Flags().Set("org", "other-org")).To(Succeed())
+		completions, _ = completeClusterNames(cmd, nil, "")
+		Expect(completions).To(ConsistOf("cluster-other"), "the cache should be separate per organization")
+	})
+
+	It("should not complete anything if the API is not reachable", func() {
+		newCompletionClient = func(*cobra.Command) (client.Client, error) {
+			return nil, errors.New("no kubeconfig")
+		}
+		completions, directive := completeOrganizations(newClusterListCmd(), nil, "")
+		Expect(completions).To(BeEmpty())
+		Expect(directive).To(Equal(cobra.ShellCompDirectiveNoFileComp))
+	})
+
+	It("should register the completions for flags and arguments", func() {
+		root := &cobra.Command{Use: programName}
+		cluster := &cobra.Command{Use: "cluster"}
+		root.AddCommand(cluster)
+		describeCmd := newClusterDescribeCmd()
+		cluster.AddCommand(describeCmd)
+		registerCompletions(root)
+		Expect(describeCmd.ValidArgsFunction).NotTo(BeNil(), "the cluster name argument should be completed")
+		_, ok := describeCmd.GetFlagCompletionFunc("org")
+		Expect(ok).To(BeTrue(), "the org flag should be completed")
+	})
+
+	It("should complete the arguments via cobra", func() {
+		root := &cobra.Command{Use: programName}
+		cluster := &cobra.Command{Use: "cluster"}
+		root.AddCommand(cluster)
+		cluster.AddCommand(newClusterDescribeCmd())
+		registerCompletions(root)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs([]string{cobra.ShellCompNoDescRequestCmd, "cluster", "describe", "--org", "test-org", "cluster-na"})
+		Expect(root.Execute()).To(Succeed())
+		Expect(out.String()).To(HavePrefix("cluster-na-us-1-production\n:4\n"))
+	})
+})
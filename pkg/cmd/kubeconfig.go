@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1"
+
+func init() {
+	kubeconfigCmd.AddCommand(newKubeconfigSyncCmd())
+	kubeconfigCmd.AddCommand(newKubeconfigGetTokenCmd())
+	rootCmd.AddCommand(kubeconfigCmd)
+}
+
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Kubeconfig related commands",
+}
+
+type kubeconfigSyncOptions struct {
+	kubecontext    string
+	kubeconfigPath string
+	contextPrefix  string
+	login          oidcLoginOptions
+}
+
+func newKubeconfigSyncCmd() *cobra.Command {
+	o := &kubeconfigSyncOptions{}
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Merge the kubeconfigs of all clusters of an organization into the local kubeconfig",
+		Long: `Merges the ClusterKubeconfigs of all clusters of the organization, which are accessible to the user, into the local kubeconfig.
+The users are configured to use 'greenhousectl kubeconfig get-token' as credential plugin.
+By default the OIDC provider of the ClusterKubeconfig is used, set --issuer to use the tokens obtained via 'greenhousectl login' instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			kubeconfigs, err := listReadyClusterKubeconfigs(cmd.Context(), k8sClient, o.login.orgName)
+			if err != nil {
+				return err
+			}
+			kubeCfg, err := clientcmd.LoadFromFile(o.kubeconfigPath)
+			switch {
+			case os.IsNotExist(err):
+				kubeCfg = clientcmdapi.NewConfig()
+			case err != nil:
+				return err
+			}
+			contexts, err := mergeClusterKubeconfigs(kubeCfg, kubeconfigs, &o.login, o.contextPrefix)
+			if err != nil {
+				return err
+			}
+			if err := clientcmd.WriteToFile(*kubeCfg, o.kubeconfigPath); err != nil {
+				return err
+			}
+			for _, name := range contexts {
+				cmd.Printf("context %s updated\n", name)
+			}
+			return nil
+		},
+	}
+
+	o.login.addFlags(syncCmd.Flags())
+	syncCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	syncCmd.Flags().StringVar(&o.kubeconfigPath, "kubeconfig-path", clientcmd.RecommendedHomeFile, "The kubeconfig file to merge the cluster contexts into")
+	syncCmd.Flags().StringVar(&o.contextPrefix, "context-prefix", "", "The prefix for the names of the merged clusters, users and contexts")
+	if err := syncCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return syncCmd
+}
+
+func newKubeconfigGetTokenCmd() *cobra.Command {
+	o := &oidcLoginOptions{}
+	getTokenCmd := &cobra.Command{
+		Use:   "get-token",
+		Short: "Print an ExecCredential with a cached ID token",
+		Long: `Prints an ExecCredential with the cached ID token for use as kubectl credential plugin.
+An expired ID token is refreshed, a new login is started if no valid token is cached.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			token, err := o.token(cmd.Context())
+			if errors.Is(err, errNoCachedToken) {
+				token, err = o.login(cmd.Context(), cmd.ErrOrStderr())
+			}
+			if err != nil {
+				return err
+			}
+			return writeExecCredential(cmd.OutOrStdout(), token)
+		},
+	}
+	o.addFlags(getTokenCmd.Flags())
+	if err := getTokenCmd.MarkFlagRequired("issuer"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "issuer")
+	}
+	return getTokenCmd
+}
+
+// listReadyClusterKubeconfigs returns the ClusterKubeconfigs of the organization which are ready.
+func listReadyClusterKubeconfigs(ctx context.Context, k8sClient client.Client, orgName string) ([]greenhousev1alpha1.ClusterKubeconfig, error) {
+	var kubeconfigList = new(greenhousev1alpha1.ClusterKubeconfigList)
+	if err := k8sClient.List(ctx, kubeconfigList, client.InNamespace(orgName)); err != nil {
+		return nil, fmt.Errorf("failed to list cluster kubeconfigs in organization %s: %w", orgName, err)
+	}
+	return slices.DeleteFunc(kubeconfigList.Items, func(kubeconfig greenhousev1alpha1.ClusterKubeconfig) bool {
+		return !kubeconfig.Status.Conditions.IsReadyTrue()
+	}), nil
+}
+
+// mergeClusterKubeconfigs adds or replaces the clusters, users and contexts of the ClusterKubeconfigs in kubeCfg.
+// The users authenticate via 'greenhousectl kubeconfig get-token'. The issuer and client of the login options
+// take precedence over the OIDC provider configured in the ClusterKubeconfig.
+func mergeClusterKubeconfigs(kubeCfg *clientcmdapi.Config, kubeconfigs []greenhousev1alpha1.ClusterKubeconfig, login *oidcLoginOptions, prefix string) ([]string, error) {
+	var contexts = make([]string, 0, len(kubeconfigs))
+	for _, kubeconfig := range kubeconfigs {
+		data := kubeconfig.Spec.Kubeconfig
+		for _, item := range data.Clusters {
+			cluster := clientcmdapi.NewCluster()
+			cluster.Server = item.Cluster.Server
+			cluster.CertificateAuthorityData = item.Cluster.CertificateAuthorityData
+			kubeCfg.Clusters[prefix+item.Name] = cluster
+		}
+		for _, item := range data.AuthInfo {
+			exec, err := execConfigForAuthInfo(item.AuthInfo, login)
+			if err != nil {
+				return nil, fmt.Errorf("cluster kubeconfig %s: %w", kubeconfig.Name, err)
+			}
+			authInfo := clientcmdapi.NewAuthInfo()
+			authInfo.Exec = exec
+			kubeCfg.AuthInfos[prefix+item.Name] = authInfo
+		}
+		for _, item := range data.Contexts {
+			kubeContext := clientcmdapi.NewContext()
+			kubeContext.Cluster = prefix + item.Context.Cluster
+			kubeContext.AuthInfo = prefix + item.Context.AuthInfo
+			kubeContext.Namespace = item.Context.Namespace
+			kubeCfg.Contexts[prefix+item.Name] = kubeContext
+			contexts = append(contexts, prefix+item.Name)
+		}
+	}
+	return contexts, nil
+}
+
+// execConfigForAuthInfo configures 'greenhousectl kubeconfig get-token' as credential plugin.
+// A credential plugin chosen by the organization is kept unless the issuer of the login options is set.
+func execConfigForAuthInfo(authInfo greenhousev1alpha1.ClusterKubeconfigAuthInfo, login *oidcLoginOptions) (*clientcmdapi.ExecConfig, error) {
+	issuer, clientID, clientSecret, orgName := login.issuer, login.getClientID(), login.clientSecret, login.orgName
+	if issuer == "" {
+		if authInfo.Exec != nil {
+			return authInfo.Exec.ToExecConfig(), nil
+		}
+		if authInfo.AuthProvider == nil {
+			return nil, errors.New("no OIDC issuer and client configured")
+		}
+		// the connector of the organization only needs to be selected for the idproxy
+		providerConfig := authInfo.AuthProvider.Config
+		issuer, clientID, clientSecret, orgName = providerConfig["idp-issuer-url"], providerConfig["client-id"], providerConfig["client-secret"], ""
+	}
+	if issuer == "" || clientID == "" {
+		return nil, errors.New("no OIDC issuer and client configured")
+	}
+	args := []string{"kubeconfig", "get-token", "--issuer", issuer, "--client-id", clientID}
+	if clientSecret != "" {
+		args = append(args, "--client-secret", clientSecret)
+	}
+	if orgName != "" {
+		args = append(args, "--org", orgName)
+	}
+	if login.tokenCacheDir != defaultTokenCacheDir() {
+		args = append(args, "--token-cache-dir", login.tokenCacheDir)
+	}
+	return &clientcmdapi.ExecConfig{
+		APIVersion:      execCredentialAPIVersion,
+		Command:         programName,
+		Args:            args,
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	}, nil
+}
+
+func writeExecCredential(out io.Writer, token *cachedToken) error {
+	expiry := metav1.NewTime(token.Expiry)
+	execCredential := clientauthv1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: execCredentialAPIVersion,
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthv1.ExecCredentialStatus{
+			Token:               token.IDToken,
+			ExpirationTimestamp: &expiry,
+		},
+	}
+	return json.NewEncoder(out).Encode(execCredential)
+}
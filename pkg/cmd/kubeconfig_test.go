@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+func clusterKubeconfigForTest(name string, ready bool) *greenhousev1alpha1.ClusterKubeconfig {
+	kubeconfig := &greenhousev1alpha1.ClusterKubeconfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: name},
+		Spec: greenhousev1alpha1.ClusterKubeconfigSpec{
+			Kubeconfig: greenhousev1alpha1.ClusterKubeconfigData{
+				Clusters: []greenhousev1alpha1.ClusterKubeconfigClusterItem{
+					{Name: name, Cluster: greenhousev1alpha1.ClusterKubeconfigCluster{Server: "https://" + name + ".example.com"}},
+				},
+				AuthInfo: []greenhousev1alpha1.ClusterKubeconfigAuthInfoItem{
+					{Name: "oidc@" + name, AuthInfo: greenhousev1alpha1.ClusterKubeconfigAuthInfo{
+						AuthProvider: &clientcmdapi.AuthProviderConfig{Name: "oidc", Config: map[string]string{
+							"idp-issuer-url": "https://idp.example.com",
+							"client-id":      "upstream-client",
+							"client-secret":  "upstream-secret",
+						}},
+					}},
+				},
+				Contexts: []greenhousev1alpha1.ClusterKubeconfigContextItem{
+					{Name: name, Context: greenhousev1alpha1.ClusterKubeconfigContext{Cluster: name, AuthInfo: "oidc@" + name, Namespace: "default"}},
+				},
+			},
+		},
+	}
+	status := metav1.ConditionFalse
+	if ready {
+		status = metav1.ConditionTrue
+	}
+	kubeconfig.Status.Conditions.SetConditions(greenhousev1alpha1.Condition{Type: greenhousev1alpha1.KubeconfigReadyCondition, Status: status})
+	return kubeconfig
+}
+
+var _ = Describe("Kubeconfig sync", func() {
+	It("should only list ready cluster kubeconfigs", func() {
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).
+			WithObjects(clusterKubeconfigForTest("ready-cluster", true), clusterKubeconfigForTest("failed-cluster", false)).Build()
+		kubeconfigs, err := listReadyClusterKubeconfigs(context.Background(), k8sClient, "test-org")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error listing the cluster kubeconfigs")
+		Expect(kubeconfigs).To(HaveLen(1), "only the ready cluster kubeconfig should be listed")
+		Expect(kubeconfigs[0].Name).To(Equal("ready-cluster"))
+	})
+
+	It("should merge the contexts with the OIDC provider of the cluster kubeconfig", func() {
+		kubeCfg := clientcmdapi.NewConfig()
+		kubeCfg.Contexts["existing"] = clientcmdapi.NewContext()
+		login := &oidcLoginOptions{orgName: "test-org", tokenCacheDir: defaultTokenCacheDir()}
+
+		contexts, err := mergeClusterKubeconfigs(kubeCfg, []greenhousev1alpha1.ClusterKubeconfig{*clusterKubeconfigForTest("test-cluster", true)}, login, "")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error merging the cluster kubeconfigs")
+		Expect(contexts).To(ConsistOf("test-cluster"))
+		Expect(kubeCfg.Contexts).To(HaveKey("existing"), "existing contexts should be kept")
+		Expect(kubeCfg.Contexts).To(HaveKeyWithValue("test-cluster", HaveField("AuthInfo", "oidc@test-cluster")))
+		Expect(kubeCfg.Clusters).To(HaveKeyWithValue("test-cluster", HaveField("Server", "https://test-cluster.example.com")))
+		Expect(kubeCfg.AuthInfos).To(HaveKey("oidc@test-cluster"))
+		exec := kubeCfg.AuthInfos["oidc@test-cluster"].Exec
+		Expect(exec).ToNot(BeNil(), "the user should use the exec credential plugin")
+		Expect(exec.Command).To(Equal(programName))
+		Expect(exec.Args).To(Equal([]string{"kubeconfig", "get-token", "--issuer", "https://idp.example.com", "--client-id", "upstream-client", "--client-secret", "upstream-secret"}))
+	})
+
+	It("should keep the credential plugin chosen by the organization", func() {
+		kubeconfig := clusterKubeconfigForTest("test-cluster", true)
+		kubeconfig.Spec.Kubeconfig.AuthInfo[0].AuthInfo = greenhousev1alpha1.ClusterKubeconfigAuthInfo{
+			Exec: &greenhousev1alpha1.ClusterKubeconfigExecConfig{
+				APIVersion: "client.authentication.k8s.io/v1beta1",
+				Command:    "kubectl",
+				Args:       []string{"oidc-login", "get-token"},
+				Env:        []greenhousev1alpha1.ClusterKubeconfigExecEnvVar{{Name: "FOO", Value: "bar"}},
+			},
+		}
+		kubeCfg := clientcmdapi.NewConfig()
+		_, err := mergeClusterKubeconfigs(kubeCfg, []greenhousev1alpha1.ClusterKubeconfig{*kubeconfig}, &oidcLoginOptions{orgName: "test-org"}, "")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error merging the cluster kubeconfigs")
+		exec := kubeCfg.AuthInfos["oidc@test-cluster"].Exec
+		Expect(exec.Command).To(Equal("kubectl"))
+		Expect(exec.Args).To(Equal([]string{"oidc-login", "get-token"}))
+		Expect(exec.Env).To(ConsistOf(clientcmdapi.ExecEnvVar{Name: "FOO", Value: "bar"}))
+		Expect(greenhousev1alpha1.NewClusterKubeconfigExecConfig(exec)).To(Equal(kubeconfig.Spec.Kubeconfig.AuthInfo[0].AuthInfo.Exec), "the conversion should be lossless")
+	})
+
+	It("should merge the contexts with the idproxy login and a prefix", func() {
+		kubeCfg := clientcmdapi.NewConfig()
+		login := &oidcLoginOptions{orgName: "test-org", issuer: "https://auth.example.com", tokenCacheDir: defaultTokenCacheDir()}
+
+		contexts, err := mergeClusterKubeconfigs(kubeCfg, []greenhousev1alpha1.ClusterKubeconfig{*clusterKubeconfigForTest("test-cluster", true)}, login, "test-org-")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error merging the cluster kubeconfigs")
+		Expect(contexts).To(ConsistOf("test-org-test-cluster"))
+		Expect(kubeCfg.Contexts).To(HaveKeyWithValue("test-org-test-cluster", HaveField("Cluster", "test-org-test-cluster")))
+		Expect(kubeCfg.AuthInfos).To(HaveKey("test-org-oidc@test-cluster"))
+		Expect(kubeCfg.AuthInfos["test-org-oidc@test-cluster"].Exec.Args).To(Equal([]string{"kubeconfig", "get-token", "--issuer", "https://auth.example.com", "--client-id", "test-org", "--org", "test-org"}))
+	})
+})
+
+var _ = Describe("Kubeconfig get-token", func() {
+	var login *oidcLoginOptions
+
+	BeforeEach(func() {
+		login = &oidcLoginOptions{orgName: "test-org", issuer: "https://auth.example.com", tokenCacheDir: GinkgoT().TempDir()}
+	})
+
+	It("should return an error if no token is cached", func() {
+		_, err := login.token(context.Background())
+		Expect(err).To(MatchError(errNoCachedToken))
+	})
+
+	It("should require a new login for an expired token without refresh token", func() {
+		Expect(login.saveToken(&cachedToken{IDToken: "expired", Expiry: time.Now().Add(-time.Minute)})).To(Succeed())
+		_, err := login.token(context.Background())
+		Expect(err).To(MatchError(errNoCachedToken))
+	})
+
+	It("should print an ExecCredential for a cached token", func() {
+		expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+		Expect(login.saveToken(&cachedToken{IDToken: "id-token", RefreshToken: "refresh-token", Expiry: expiry})).To(Succeed())
+		token, err := login.token(context.Background())
+		Expect(err).ToNot(HaveOccurred(), "the cached token should be returned without contacting the issuer")
+
+		var out bytes.Buffer
+		Expect(writeExecCredential(&out, token)).To(Succeed())
+		var execCredential clientauthv1.ExecCredential
+		Expect(json.Unmarshal(out.Bytes(), &execCredential)).To(Succeed())
+		Expect(execCredential.Kind).To(Equal("ExecCredential"))
+		Expect(execCredential.Status.Token).To(Equal("id-token"))
+		Expect(execCredential.Status.ExpirationTimestamp.Time).To(BeTemporally("==", expiry))
+	})
+
+	It("should cache tokens per issuer and client", func() {
+		otherClient := *login
+		otherClient.clientID = "other-client"
+		Expect(login.tokenCachePath()).ToNot(Equal(otherClient.tokenCachePath()))
+	})
+})
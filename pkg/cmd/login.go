@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/oauth2"
+
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+const (
+	// defaultLoginCallbackPort is the port of the local redirect URI used by the browser flow.
+	// The idproxy registers http://localhost:8000 as redirect URI for the OAuth2 client of each organization.
+	defaultLoginCallbackPort = 8000
+	// tokenExpiryLeeway is subtracted from the expiry of a cached ID token to account for clock skew.
+	tokenExpiryLeeway = 30 * time.Second
+)
+
+// errNoCachedToken is returned if neither a valid ID token nor a refresh token is cached.
+var errNoCachedToken = errors.New("no valid token cached")
+
+// oidcLoginOptions configure the OIDC flow against the Greenhouse idproxy or another OIDC provider.
+type oidcLoginOptions struct {
+	orgName       string
+	issuer        string
+	clientID      string
+	clientSecret  string
+	deviceFlow    bool
+	callbackPort  int
+	tokenCacheDir string
+}
+
+// cachedToken is persisted in the token cache after a successful login.
+type cachedToken struct {
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+func (t *cachedToken) isValid() bool {
+	return t != nil && t.IDToken != "" && time.Now().Add(tokenExpiryLeeway).Before(t.Expiry)
+}
+
+func init() {
+	rootCmd.AddCommand(newLoginCmd())
+}
+
+func newLoginCmd() *cobra.Command {
+	o := &oidcLoginOptions{}
+	loginCmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in to a Greenhouse organization",
+		Long: `Runs the OIDC browser or device flow against the Greenhouse idproxy and caches the obtained tokens.
+The cached tokens are used by the credential plugin configured with 'greenhousectl kubeconfig sync'.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			token, err := o.login(cmd.Context(), cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			cmd.Printf("logged in to organization %s, token valid until %s\n", o.orgName, token.Expiry.Format(time.RFC3339))
+			return nil
+		},
+	}
+	o.addFlags(loginCmd.Flags())
+	for _, flagName := range []string{"org", "issuer"} {
+		if err := loginCmd.MarkFlagRequired(flagName); err != nil {
+			setupLog.Error(err, "Flag could not set as required", flagName)
+		}
+	}
+	return loginCmd
+}
+
+func (o *oidcLoginOptions) addFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	flags.StringVar(&o.issuer, "issuer", clientutil.GetEnvOrDefault("GREENHOUSE_ISSUER", ""), "The URL of the Greenhouse idproxy, e.g. https://auth.<greenhouse-domain>. Can be set via GREENHOUSE_ISSUER env var")
+	flags.StringVar(&o.clientID, "client-id", "", "The OAuth2 client ID (defaults to the organization name)")
+	flags.StringVar(&o.clientSecret, "client-secret", "", "The OAuth2 client secret, not required for the Greenhouse idproxy")
+	flags.BoolVar(&o.deviceFlow, "device", false, "Use the device flow instead of opening a browser")
+	flags.IntVar(&o.callbackPort, "callback-port", defaultLoginCallbackPort, "The local port to receive the redirect of the browser flow")
+	flags.StringVar(&o.tokenCacheDir, "token-cache-dir", defaultTokenCacheDir(), "The directory the tokens are cached in")
+}
+
+func defaultTokenCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, programName, "tokens")
+}
+
+func (o *oidcLoginOptions) getClientID() string {
+	if o.clientID != "" {
+		return o.clientID
+	}
+	return o.orgName
+}
+
+// tokenCachePath returns the cache file for the issuer and client.
+func (o *oidcLoginOptions) tokenCachePath() string {
+	sum := sha256.Sum256([]byte(o.issuer + "\n" + o.getClientID()))
+	return filepath.Join(o.tokenCacheDir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (o *oidcLoginOptions) loadToken() (*cachedToken, error) {
+	data, err := os.ReadFile(o.tokenCachePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errNoCachedToken
+		}
+		return nil, err
+	}
+	var token = new(cachedToken)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("failed to read token cache %s: %w", o.tokenCachePath(), err)
+	}
+	return token, nil
+}
+
+func (o *oidcLoginOptions) saveToken(token *cachedToken) error {
+	if err := os.MkdirAll(o.tokenCacheDir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(o.tokenCachePath(), data, 0o600)
+}
+
+// token returns a valid ID token from the cache and uses the cached refresh token if the ID token expired.
+// errNoCachedToken is returned if a new login is required.
+func (o *oidcLoginOptions) token(ctx context.Context) (*cachedToken, error) {
+	token, err := o.loadToken()
+	if err != nil {
+		return nil, err
+	}
+	if token.isValid() {
+		return token, nil
+	}
+	if token.RefreshToken == "" {
+		return nil, errNoCachedToken
+	}
+	provider, config, err := o.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refreshed, err := config.TokenSource(ctx, &oauth2.Token{RefreshToken: token.RefreshToken}).Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh token, please log in again: %w", err)
+	}
+	return o.verifyAndSave(ctx, provider, refreshed)
+}
+
+// login runs the browser or device flow and caches the obtained tokens.
+func (o *oidcLoginOptions) login(ctx context.Context, out io.Writer) (*cachedToken, error) {
+	provider, config, err := o.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var token *oauth2.Token
+	if o.deviceFlow {
+		token, err = o.deviceLogin(ctx, config, out)
+	} else {
+		token, err = o.browserLogin(ctx, config, out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return o.verifyAndSave(ctx, provider, token)
+}
+
+func (o *oidcLoginOptions) oauth2Config(ctx context.Context) (*oidc.Provider, *oauth2.Config, error) {
+	if o.issuer == "" {
+		return nil, nil, errors.New("no issuer given")
+	}
+	provider, err := oidc.NewProvider(ctx, o.issuer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover issuer %s: %w", o.issuer, err)
+	}
+	return provider, &oauth2.Config{
+		ClientID:     o.getClientID(),
+		ClientSecret: o.clientSecret,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, oidc.ScopeOfflineAccess, "profile", "email", "groups"},
+	}, nil
+}
+
+func (o *oidcLoginOptions) verifyAndSave(ctx context.Context, provider *oidc.Provider, token *oauth2.Token) (*cachedToken, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("no id_token in token response")
+	}
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: o.getClientID()}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify id_token: %w", err)
+	}
+	cached := &cachedToken{
+		IDToken:      rawIDToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       idToken.Expiry,
+	}
+	if err := o.saveToken(cached); err != nil {
+		return nil, fmt.Errorf("failed to cache token: %w", err)
+	}
+	return cached, nil
+}
+
+// authCodeOptions selects the connector of the organization in the idproxy to skip the connector selection.
+func (o *oidcLoginOptions) authCodeOptions() []oauth2.AuthCodeOption {
+	if o.orgName == "" {
+		return nil
+	}
+	return []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("connector_id", o.orgName)}
+}
+
+func (o *oidcLoginOptions) deviceLogin(ctx context.Context, config *oauth2.Config, out io.Writer) (*oauth2.Token, error) {
+	if config.Endpoint.DeviceAuthURL == "" {
+		return nil, fmt.Errorf("issuer %s does not support the device flow", o.issuer)
+	}
+	deviceAuth, err := config.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the device flow: %w", err)
+	}
+	if deviceAuth.VerificationURIComplete != "" {
+		fmt.Fprintf(out, "Open %s to log in\n", deviceAuth.VerificationURIComplete)
+	} else {
+		fmt.Fprintf(out, "Open %s and enter the code %s to log in\n", deviceAuth.VerificationURI, deviceAuth.UserCode)
+	}
+	return config.DeviceAccessToken(ctx, deviceAuth)
+}
+
+func (o *oidcLoginOptions) browserLogin(ctx context.Context, config *oauth2.Config, out io.Writer) (*oauth2.Token, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("localhost", strconv.Itoa(o.callbackPort)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the login callback: %w", err)
+	}
+	config.RedirectURL = "http://localhost:" + strconv.Itoa(o.callbackPort)
+
+	state, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+	verifier := oauth2.GenerateVerifier()
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			switch {
+			case query.Get("state") != state:
+				http.Error(w, "invalid state", http.StatusBadRequest)
+				return
+			case query.Get("error") != "":
+				http.Error(w, "login failed", http.StatusUnauthorized)
+				errCh <- fmt.Errorf("login failed: %s %s", query.Get("error"), query.Get("error_description"))
+				return
+			}
+			fmt.Fprintln(w, "Logged in to Greenhouse. You can close this window.")
+			codeCh <- query.Get("code")
+		}),
+	}
+	go server.Serve(listener) //nolint:errcheck
+	defer server.Close()
+
+	authURL := config.AuthCodeURL(state, append(o.authCodeOptions(), oauth2.S256ChallengeOption(verifier))...)
+	fmt.Fprintf(out, "Open %s to log in\n", authURL)
+	openBrowser(authURL)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case err := <-errCh:
+		return nil, err
+	case code := <-codeCh:
+		return config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	}
+}
+
+// openBrowser tries to open the URL in the default browser. Errors are ignored as the URL is printed as well.
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	_ = cmd.Start()
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
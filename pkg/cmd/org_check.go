@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-logr/logr/funcr"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/cloudoperators/greenhouse/pkg/admission"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/util"
+)
+
+type orgCheckOptions struct {
+	kubecontext string
+}
+
+func init() {
+	orgCmd.AddCommand(newOrgCheckCmd())
+}
+
+func newOrgCheckCmd() *cobra.Command {
+	o := &orgCheckOptions{}
+	checkCmd := &cobra.Command{
+		Use:   "check <org-name>",
+		Short: "Check the OIDC and SCIM configuration of an organization",
+		Long: `Checks the configuration of an organization with the rules applied by Greenhouse on admission,
+the connectivity to the SCIM API and the admin group in it as done by Greenhouse, and the discovery of the OIDC provider.
+The command fails if any check fails.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			var org = new(greenhousev1alpha1.Organization)
+			if err := k8sClient.Get(cmd.Context(), client.ObjectKey{Name: args[0]}, org); err != nil {
+				return fmt.Errorf("failed to get organization %s: %w", args[0], err)
+			}
+			// The SCIM client only logs the cause of failed requests.
+			ctx := log.IntoContext(cmd.Context(), funcr.New(func(prefix, args string) {
+				fmt.Fprintln(cmd.ErrOrStderr(), args)
+			}, funcr.Options{}))
+			return checkOrganization(ctx, k8sClient, org, cmd.OutOrStdout())
+		},
+	}
+
+	checkCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	return checkCmd
+}
+
+type orgCheckResult struct {
+	check   string
+	status  string
+	message string
+}
+
+const (
+	orgCheckPassed  = "PASS"
+	orgCheckFailed  = "FAIL"
+	orgCheckSkipped = "SKIP"
+)
+
+// checkOrganization runs the checks of the organization configuration and returns an error if any of them failed.
+func checkOrganization(ctx context.Context, k8sClient client.Client, org *greenhousev1alpha1.Organization, out io.Writer) error {
+	results := []orgCheckResult{checkOrganizationAdmission(ctx, org)}
+
+	scimCondition := util.CheckSCIMAPIAvailability(ctx, k8sClient, org)
+	switch {
+	case scimCondition.IsTrue():
+		results = append(results, orgCheckResult{"SCIM", orgCheckPassed, scimCondition.Message})
+	case scimCondition.IsFalse():
+		results = append(results, orgCheckResult{"SCIM", orgCheckFailed, scimCondition.Message})
+	default:
+		results = append(results, orgCheckResult{"SCIM", orgCheckSkipped, scimCondition.Message})
+	}
+
+	results = append(results, checkOIDCProvider(ctx, k8sClient, org))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tRESULT\tMESSAGE")
+	failed := 0
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.check, result.status, result.message)
+		if result.status == orgCheckFailed {
+			failed++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d checks of organization %s failed", failed, org.GetName())
+	}
+	return nil
+}
+
+func checkOrganizationAdmission(ctx context.Context, org *greenhousev1alpha1.Organization) orgCheckResult {
+	if _, err := admission.ValidateCreateOrganization(ctx, nil, org); err != nil {
+		return orgCheckResult{"Configuration", orgCheckFailed, err.Error()}
+	}
+	return orgCheckResult{"Configuration", orgCheckPassed, "organization is valid"}
+}
+
+// checkOIDCProvider verifies the client credentials of the organization can be read and the OIDC provider can be discovered.
+func checkOIDCProvider(ctx context.Context, k8sClient client.Client, org *greenhousev1alpha1.Organization) orgCheckResult {
+	if org.Spec.Authentication == nil || org.Spec.Authentication.OIDCConfig == nil {
+		return orgCheckResult{"OIDC", orgCheckSkipped, "OIDC Config not provided"}
+	}
+	oidcConfig := org.Spec.Authentication.OIDCConfig
+	for _, ref := range []greenhousev1alpha1.SecretKeyReference{oidcConfig.ClientIDReference, oidcConfig.ClientSecretReference} {
+		if _, err := clientutil.GetSecretKeyFromSecretKeyReference(ctx, k8sClient, org.GetName(), ref); err != nil {
+			return orgCheckResult{"OIDC", orgCheckFailed, fmt.Sprintf("failed to read %s from secret %s: %s", ref.Key, ref.Name, err)}
+		}
+	}
+	provider, err := oidc.NewProvider(ctx, oidcConfig.Issuer)
+	if err != nil {
+		return orgCheckResult{"OIDC", orgCheckFailed, fmt.Sprintf("discovery of %s failed: %s", oidcConfig.Issuer, err)}
+	}
+	return orgCheckResult{"OIDC", orgCheckPassed, "discovered OIDC provider with authorization endpoint " + provider.Endpoint().AuthURL}
+}
@@ -0,0 +1,202 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/cloudoperators/greenhouse/pkg/admission"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/scim"
+)
+
+const (
+	oidcClientIDKey     = "clientID"
+	oidcClientSecretKey = "clientSecret"
+	scimUserKey         = "scimUser"
+	scimPasswordKey     = "scimPassword"
+	scimTokenKey        = "scimToken"
+)
+
+type orgCreateOptions struct {
+	kubecontext      string
+	displayName      string
+	description      string
+	adminGroup       string
+	oidcIssuer       string
+	oidcClientID     string
+	oidcClientSecret string
+	oidcRedirectURI  string
+	credentialPlugin string
+	scimURL          string
+	scimAuthType     string
+	scimUser         string
+	scimPassword     string
+	scimToken        string
+	dryRun           bool
+}
+
+func init() {
+	orgCmd.AddCommand(newOrgCreateCmd())
+}
+
+func newOrgCreateCmd() *cobra.Command {
+	o := &orgCreateOptions{}
+	createCmd := &cobra.Command{
+		Use:   "create <org-name>",
+		Short: "Create an organization with its OIDC and SCIM configuration",
+		Long: `Creates an organization and the Secret holding the credentials of its OIDC and SCIM configuration.
+The organization is validated with the rules applied by Greenhouse on admission before anything is created.
+The Secret is created in the namespace of the organization, which is created as well and adopted by Greenhouse.
+Use --dry-run to print the manifests instead, e.g. to commit them to a Git repository.
+Run 'greenhousectl org check' afterwards to verify the connectivity to the SCIM API and the OIDC provider.`,
+		Example: `  greenhousectl org create my-org --admin-group=my-org-admins \
+    --oidc-issuer=https://idp.example.com --oidc-client-id=greenhouse --oidc-client-secret=... \
+    --scim-url=https://idp.example.com/scim --scim-user=greenhouse --scim-password=...`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			objects, err := o.organizationManifests(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			if o.dryRun {
+				return writeObjectsAsYAML(cmd.OutOrStdout(), objects)
+			}
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			return createOrganization(cmd.Context(), k8sClient, objects, cmd.OutOrStdout())
+		},
+	}
+
+	createCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	createCmd.Flags().StringVar(&o.displayName, "display-name", "", "The name of the organization displayed in the Greenhouse UI")
+	createCmd.Flags().StringVar(&o.description, "description", "", "The description of the organization")
+	createCmd.Flags().StringVar(&o.adminGroup, "admin-group", "", "The IdP group identifying the admins of the organization")
+	createCmd.Flags().StringVar(&o.oidcIssuer, "oidc-issuer", "", "The URL of the OIDC provider")
+	createCmd.Flags().StringVar(&o.oidcClientID, "oidc-client-id", "", "The client ID registered at the OIDC provider")
+	createCmd.Flags().StringVar(&o.oidcClientSecret, "oidc-client-secret", clientutil.GetEnvOrDefault("GREENHOUSE_OIDC_CLIENT_SECRET", ""), "The client secret registered at the OIDC provider. Can be set via GREENHOUSE_OIDC_CLIENT_SECRET env var")
+	createCmd.Flags().StringVar(&o.oidcRedirectURI, "oidc-redirect-uri", "", "The redirect URI for the OIDC flow against the OIDC provider (defaults to the Greenhouse ID proxy)")
+	createCmd.Flags().StringVar(&o.credentialPlugin, "kubeconfig-credential-plugin", "", "The tool used by the kubeconfigs of the organization to obtain tokens, one of auth-provider, kubelogin, greenhousectl")
+	createCmd.Flags().StringVar(&o.scimURL, "scim-url", "", "The URL of the SCIM API to sync the members of teams from")
+	createCmd.Flags().StringVar(&o.scimAuthType, "scim-auth-type", string(scim.Basic), "The authentication type of the SCIM API, one of basic, token")
+	createCmd.Flags().StringVar(&o.scimUser, "scim-user", "", "The user for basic authentication to the SCIM API")
+	createCmd.Flags().StringVar(&o.scimPassword, "scim-password", clientutil.GetEnvOrDefault("GREENHOUSE_SCIM_PASSWORD", ""), "The password for basic authentication to the SCIM API. Can be set via GREENHOUSE_SCIM_PASSWORD env var")
+	createCmd.Flags().StringVar(&o.scimToken, "scim-token", clientutil.GetEnvOrDefault("GREENHOUSE_SCIM_TOKEN", ""), "The bearer token for the SCIM API. Can be set via GREENHOUSE_SCIM_TOKEN env var")
+	createCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only validate and print the manifests without creating them")
+	if err := createCmd.MarkFlagRequired("admin-group"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "admin-group")
+	}
+	return createCmd
+}
+
+// organizationAuthSecretName returns the name of the Secret holding the OIDC and SCIM credentials of the organization.
+func organizationAuthSecretName(orgName string) string {
+	return orgName + "-authentication"
+}
+
+// organizationManifests returns the namespace, the Secret with the credentials and the organization to create.
+// The organization is defaulted and validated as on admission.
+func (o *orgCreateOptions) organizationManifests(ctx context.Context, orgName string) ([]client.Object, error) {
+	org := &greenhousev1alpha1.Organization{
+		TypeMeta:   metav1.TypeMeta{APIVersion: greenhousev1alpha1.GroupVersion.String(), Kind: "Organization"},
+		ObjectMeta: metav1.ObjectMeta{Name: orgName},
+		Spec: greenhousev1alpha1.OrganizationSpec{
+			DisplayName:            o.displayName,
+			Description:            o.description,
+			MappedOrgAdminIDPGroup: o.adminGroup,
+		},
+	}
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: orgName, Name: organizationAuthSecretName(orgName)},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: make(map[string]string),
+	}
+	secretKeyReference := func(key, value string) *greenhousev1alpha1.SecretKeyReference {
+		secret.StringData[key] = value
+		return &greenhousev1alpha1.SecretKeyReference{Name: secret.Name, Key: key}
+	}
+
+	if o.oidcIssuer != "" || o.oidcClientID != "" || o.oidcClientSecret != "" {
+		if o.oidcIssuer == "" || o.oidcClientID == "" || o.oidcClientSecret == "" {
+			return nil, errors.New("--oidc-issuer, --oidc-client-id and --oidc-client-secret must be set together")
+		}
+		org.Spec.Authentication = &greenhousev1alpha1.Authentication{}
+		org.Spec.Authentication.OIDCConfig = &greenhousev1alpha1.OIDCConfig{
+			Issuer:                     o.oidcIssuer,
+			RedirectURI:                o.oidcRedirectURI,
+			ClientIDReference:          *secretKeyReference(oidcClientIDKey, o.oidcClientID),
+			ClientSecretReference:      *secretKeyReference(oidcClientSecretKey, o.oidcClientSecret),
+			KubeconfigCredentialPlugin: greenhousev1alpha1.KubeconfigCredentialPlugin(o.credentialPlugin),
+		}
+	}
+
+	if o.scimURL != "" {
+		if org.Spec.Authentication == nil {
+			org.Spec.Authentication = &greenhousev1alpha1.Authentication{}
+		}
+		scimConfig := &greenhousev1alpha1.SCIMConfig{BaseURL: o.scimURL, AuthType: scim.AuthType(o.scimAuthType)}
+		if o.scimUser != "" {
+			scimConfig.BasicAuthUser.Secret = secretKeyReference(scimUserKey, o.scimUser)
+		}
+		if o.scimPassword != "" {
+			scimConfig.BasicAuthPw.Secret = secretKeyReference(scimPasswordKey, o.scimPassword)
+		}
+		if o.scimToken != "" {
+			scimConfig.BearerToken.Secret = secretKeyReference(scimTokenKey, o.scimToken)
+		}
+		org.Spec.Authentication.SCIMConfig = scimConfig
+	}
+
+	if err := admission.DefaultOrganization(ctx, nil, org); err != nil {
+		return nil, err
+	}
+	if _, err := admission.ValidateCreateOrganization(ctx, nil, org); err != nil {
+		return nil, fmt.Errorf("organization %s is invalid: %w", orgName, err)
+	}
+
+	objects := []client.Object{&corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: orgName},
+	}}
+	if len(secret.StringData) > 0 {
+		objects = append(objects, secret)
+	}
+	return append(objects, org), nil
+}
+
+// createOrganization creates the objects in the given order. An existing namespace is reused, all other objects must not exist yet.
+func createOrganization(ctx context.Context, k8sClient client.Client, objects []client.Object, out io.Writer) error {
+	for _, obj := range objects {
+		kind := obj.GetObjectKind().GroupVersionKind().Kind
+		err := k8sClient.Create(ctx, obj)
+		switch {
+		case apierrors.IsAlreadyExists(err) && kind == "Namespace":
+			fmt.Fprintf(out, "namespace %s already exists\n", obj.GetName())
+		case err != nil:
+			return fmt.Errorf("failed to create %s %s: %w", kind, client.ObjectKeyFromObject(obj), err)
+		default:
+			fmt.Fprintf(out, "created %s %s\n", kind, client.ObjectKeyFromObject(obj))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type orgDescribeOptions struct {
+	kubecontext string
+}
+
+func init() {
+	orgCmd.AddCommand(newOrgDescribeCmd())
+}
+
+func newOrgDescribeCmd() *cobra.Command {
+	o := &orgDescribeOptions{}
+	describeCmd := &cobra.Command{
+		Use:          "describe <org-name>",
+		Short:        "Show the details of an organization",
+		Long:         "Shows the OIDC and SCIM configuration, the cluster label rules, the conditions and the number of teams and clusters of an organization.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			return describeOrganization(cmd.Context(), k8sClient, args[0], cmd.OutOrStdout())
+		},
+	}
+
+	describeCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	return describeCmd
+}
+
+func describeOrganization(ctx context.Context, k8sClient client.Client, orgName string, out io.Writer) error {
+	var org = new(greenhousev1alpha1.Organization)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: orgName}, org); err != nil {
+		return fmt.Errorf("failed to get organization %s: %w", orgName, err)
+	}
+	var teams = new(greenhousev1alpha1.TeamList)
+	if err := k8sClient.List(ctx, teams, client.InNamespace(orgName)); err != nil {
+		return fmt.Errorf("failed to list teams of organization %s: %w", orgName, err)
+	}
+	var clusters = new(greenhousev1alpha1.ClusterList)
+	if err := k8sClient.List(ctx, clusters, client.InNamespace(orgName)); err != nil {
+		return fmt.Errorf("failed to list clusters of organization %s: %w", orgName, err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", org.GetName())
+	fmt.Fprintf(w, "Display name:\t%s\n", valueOrDash(org.Spec.DisplayName))
+	fmt.Fprintf(w, "Description:\t%s\n", valueOrDash(org.Spec.Description))
+	fmt.Fprintf(w, "Admin IdP group:\t%s\n", valueOrDash(org.Spec.MappedOrgAdminIDPGroup))
+	fmt.Fprintf(w, "Teams:\t%d\n", len(teams.Items))
+	fmt.Fprintf(w, "Clusters:\t%d\n", len(clusters.Items))
+
+	var oidcConfig *greenhousev1alpha1.OIDCConfig
+	var scimConfig *greenhousev1alpha1.SCIMConfig
+	if org.Spec.Authentication != nil {
+		oidcConfig = org.Spec.Authentication.OIDCConfig
+		scimConfig = org.Spec.Authentication.SCIMConfig
+	}
+	fmt.Fprintln(w, "\nOIDC:")
+	if oidcConfig == nil {
+		fmt.Fprintln(w, "  not configured")
+	} else {
+		fmt.Fprintf(w, "  Issuer:\t%s\n", oidcConfig.Issuer)
+		fmt.Fprintf(w, "  Redirect URI:\t%s\n", valueOrDash(oidcConfig.RedirectURI))
+		fmt.Fprintf(w, "  Client ID:\tsecret %s\n", formatSecretKeyReference(&oidcConfig.ClientIDReference))
+		fmt.Fprintf(w, "  Client secret:\tsecret %s\n", formatSecretKeyReference(&oidcConfig.ClientSecretReference))
+		fmt.Fprintf(w, "  Kubeconfig credential plugin:\t%s\n", valueOrDash(string(oidcConfig.KubeconfigCredentialPlugin)))
+		fmt.Fprintf(w, "  OAuth2 client redirect URIs:\t%s\n", valueOrDash(strings.Join(oidcConfig.OAuth2ClientRedirectURIs, ",")))
+	}
+	fmt.Fprintln(w, "\nSCIM:")
+	if scimConfig == nil {
+		fmt.Fprintln(w, "  not configured")
+	} else {
+		fmt.Fprintf(w, "  URL:\t%s\n", scimConfig.BaseURL)
+		fmt.Fprintf(w, "  Auth type:\t%s\n", scimConfig.AuthType)
+		if scimConfig.BasicAuthUser.Secret != nil || scimConfig.BasicAuthPw.Secret != nil {
+			fmt.Fprintf(w, "  User:\tsecret %s\n", formatSecretKeyReference(scimConfig.BasicAuthUser.Secret))
+			fmt.Fprintf(w, "  Password:\tsecret %s\n", formatSecretKeyReference(scimConfig.BasicAuthPw.Secret))
+		}
+		if scimConfig.BearerToken.Secret != nil {
+			fmt.Fprintf(w, "  Bearer token:\tsecret %s\n", formatSecretKeyReference(scimConfig.BearerToken.Secret))
+		}
+	}
+
+	if len(org.Spec.ClusterLabelRules) > 0 {
+		fmt.Fprintln(w, "\nCLUSTER LABEL\tSOURCE")
+		for _, rule := range org.Spec.ClusterLabelRules {
+			fmt.Fprintf(w, "%s\t%s\n", rule.Label, clusterLabelRuleSource(rule))
+		}
+	}
+
+	printConditions(w, org.Status.Conditions)
+	return w.Flush()
+}
+
+func formatSecretKeyReference(ref *greenhousev1alpha1.SecretKeyReference) string {
+	if ref == nil {
+		return "-"
+	}
+	return ref.Name + "/" + ref.Key
+}
+
+func clusterLabelRuleSource(rule greenhousev1alpha1.ClusterLabelRule) string {
+	switch {
+	case rule.NodeLabel != "":
+		return "node label " + rule.NodeLabel
+	case rule.KubernetesVersion:
+		return "kubernetes version"
+	case rule.Namespace != "":
+		return "namespace " + rule.Namespace
+	case rule.CustomResourceDefinition != "":
+		return "customResourceDefinition " + rule.CustomResourceDefinition
+	default:
+		return "-"
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/scim"
+)
+
+var _ = Describe("Create an organization", func() {
+	It("should reference the credentials from the secret of the organization", func() {
+		o := &orgCreateOptions{
+			adminGroup:       "test-org-admins",
+			oidcIssuer:       "https://idp.example.com",
+			oidcClientID:     "client-id",
+			oidcClientSecret: "client-secret",
+			scimURL:          "https://idp.example.com/scim",
+			scimAuthType:     string(scim.Basic),
+			scimUser:         "user",
+			scimPassword:     "password",
+		}
+		objects, err := o.organizationManifests(context.Background(), "test-org")
+		Expect(err).NotTo(HaveOccurred(), "the organization should be valid")
+		Expect(objects).To(HaveLen(3))
+		Expect(objects[0].GetObjectKind().GroupVersionKind().Kind).To(Equal("Namespace"))
+
+		secret, ok := objects[1].(*corev1.Secret)
+		Expect(ok).To(BeTrue(), "the second object should be the secret")
+		Expect(secret.Namespace).To(Equal("test-org"))
+		Expect(secret.StringData).To(Equal(map[string]string{
+			oidcClientIDKey: "client-id", oidcClientSecretKey: "client-secret", scimUserKey: "user", scimPasswordKey: "password",
+		}))
+
+		org, ok := objects[2].(*greenhousev1alpha1.Organization)
+		Expect(ok).To(BeTrue(), "the third object should be the organization")
+		Expect(org.Spec.DisplayName).To(Equal("test org"), "the display name should be defaulted")
+		Expect(org.Spec.Authentication.OIDCConfig.ClientSecretReference).To(Equal(greenhousev1alpha1.SecretKeyReference{Name: secret.Name, Key: oidcClientSecretKey}))
+		Expect(org.Spec.Authentication.SCIMConfig.BasicAuthPw.Secret).To(Equal(&greenhousev1alpha1.SecretKeyReference{Name: secret.Name, Key: scimPasswordKey}))
+	})
+
+	It("should reject an invalid SCIM config", func() {
+		o := &orgCreateOptions{adminGroup: "test-org-admins", scimURL: "https://idp.example.com/scim", scimAuthType: string(scim.BearerToken)}
+		_, err := o.organizationManifests(context.Background(), "test-org")
+		Expect(err).To(MatchError(ContainSubstring("BearerToken")), "the missing bearer token should be reported")
+	})
+
+	It("should reject an incomplete OIDC config", func() {
+		o := &orgCreateOptions{adminGroup: "test-org-admins", oidcIssuer: "https://idp.example.com"}
+		_, err := o.organizationManifests(context.Background(), "test-org")
+		Expect(err).To(HaveOccurred(), "the client credentials should be required")
+	})
+
+	It("should create the organization in an existing namespace", func() {
+		o := &orgCreateOptions{adminGroup: "test-org-admins"}
+		objects, err := o.organizationManifests(context.Background(), "test-org")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects).To(HaveLen(2), "no secret should be created without credentials")
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).
+			WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-org"}}).Build()
+		var out bytes.Buffer
+		Expect(createOrganization(context.Background(), k8sClient, objects, &out)).To(Succeed())
+		Expect(k8sClient.Get(context.Background(), client.ObjectKey{Name: "test-org"}, &greenhousev1alpha1.Organization{})).To(Succeed(), "the organization should be created")
+		Expect(out.String()).To(ContainSubstring("namespace test-org already exists"))
+	})
+})
+
+var _ = Describe("Check an organization", func() {
+	var (
+		scimServer, oidcServer *httptest.Server
+		scimGroups             []scim.Resource
+	)
+
+	BeforeEach(func() {
+		scimGroups = []scim.Resource{{}}
+		scimServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			Expect(json.NewEncoder(w).Encode(scim.ResponseBody{Resources: scimGroups})).To(Succeed())
+		}))
+		oidcServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewEncoder(w).Encode(map[string]string{
+				"issuer":                 oidcServer.URL,
+				"authorization_endpoint": oidcServer.URL + "/auth",
+				"token_endpoint":         oidcServer.URL + "/token",
+				"jwks_uri":               oidcServer.URL + "/keys",
+			})).To(Succeed())
+		}))
+		DeferCleanup(scimServer.Close)
+		DeferCleanup(oidcServer.Close)
+	})
+
+	newClient := func() (client.Client, *greenhousev1alpha1.Organization) {
+		o := &orgCreateOptions{
+			adminGroup:       "test-org-admins",
+			oidcIssuer:       oidcServer.URL,
+			oidcClientID:     "client-id",
+			oidcClientSecret: "client-secret",
+			scimURL:          scimServer.URL,
+			scimAuthType:     string(scim.Basic),
+			scimUser:         "user",
+			scimPassword:     "password",
+		}
+		objects, err := o.organizationManifests(context.Background(), "test-org")
+		Expect(err).NotTo(HaveOccurred())
+		// The fake client does not convert stringData, the credentials are read from data.
+		secret := objects[1].(*corev1.Secret)
+		secret.Data = make(map[string][]byte)
+		for key, value := range secret.StringData {
+			secret.Data[key] = []byte(value)
+		}
+		return fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(secret).Build(), objects[2].(*greenhousev1alpha1.Organization)
+	}
+
+	It("should pass if SCIM and OIDC are available", func() {
+		k8sClient, org := newClient()
+		var out bytes.Buffer
+		Expect(checkOrganization(context.Background(), k8sClient, org, &out)).To(Succeed(), out.String())
+		Expect(out.String()).To(ContainSubstring("SCIM API is available"))
+		Expect(out.String()).To(ContainSubstring(oidcServer.URL + "/auth"))
+	})
+
+	It("should fail if the admin group is not found in SCIM", func() {
+		scimGroups = nil
+		k8sClient, org := newClient()
+		var out bytes.Buffer
+		Expect(checkOrganization(context.Background(), k8sClient, org, &out)).To(MatchError(ContainSubstring("1 checks")))
+		Expect(out.String()).To(ContainSubstring("test-org-admins Group not found in SCIM API"))
+	})
+
+	It("should fail if the OIDC client credentials are missing", func() {
+		_, org := newClient()
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).Build()
+		var out bytes.Buffer
+		Expect(checkOrganization(context.Background(), k8sClient, org, &out)).To(MatchError(ContainSubstring("2 checks")))
+		Expect(out.String()).To(ContainSubstring("failed to read clientID from secret test-org-authentication"))
+	})
+})
@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+)
+
+type pluginDiffOptions struct {
+	kubecontext  string
+	orgName      string
+	unset        []string
+	optionValues pluginOptionValueFlags
+}
+
+func init() {
+	pluginCmd.AddCommand(newPluginDiffCmd())
+}
+
+func newPluginDiffCmd() *cobra.Command {
+	o := &pluginDiffOptions{}
+	diffCmd := &cobra.Command{
+		Use:   "diff <plugin-name>",
+		Short: "Show the pending changes of a Plugin",
+		Long: `Shows the changes Greenhouse would apply to the resources deployed by the Plugin, computed the same way as the drift detection of the Plugin controller.
+The option values given with --set, --set-secret and --unset are applied before computing the changes.
+Access to the kubeconfig Secret of the cluster in the organization is required.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			plugin, pluginDefinition, err := getPluginWithOptionValues(cmd.Context(), k8sClient, o.orgName, args[0], &o.optionValues, o.unset)
+			if err != nil {
+				return err
+			}
+			restClientGetter, err := restClientGetterForPlugin(cmd.Context(), k8sClient, restConfig, plugin)
+			if err != nil {
+				return err
+			}
+			return diffPlugin(cmd.Context(), k8sClient, restClientGetter, pluginDefinition, plugin, cmd.OutOrStdout())
+		},
+	}
+
+	diffCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	diffCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	diffCmd.Flags().StringArrayVar(&o.unset, "unset", nil, "Remove an option value, the default of the option is used instead")
+	o.optionValues.addFlags(diffCmd.Flags())
+	if err := diffCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return diffCmd
+}
+
+// restClientGetterForPlugin returns a RESTClientGetter for the cluster the Plugin is deployed to.
+// Plugins without a cluster are deployed to the Greenhouse cluster.
+func restClientGetterForPlugin(ctx context.Context, k8sClient client.Client, restConfig *rest.Config, plugin *greenhousev1alpha1.Plugin) (genericclioptions.RESTClientGetter, error) {
+	if plugin.Spec.ClusterName == "" {
+		return clientutil.NewRestClientGetterFromRestConfig(restConfig, plugin.Spec.ReleaseNamespace), nil
+	}
+	var secret = new(corev1.Secret)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: plugin.GetNamespace(), Name: plugin.Spec.ClusterName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig secret of cluster %s: %w", plugin.Spec.ClusterName, err)
+	}
+	return clientutil.NewRestClientGetterFromSecret(secret, plugin.Spec.ReleaseNamespace)
+}
+
+// diffPlugin prints the changes to the deployed resources of the Plugin.
+func diffPlugin(ctx context.Context, k8sClient client.Client, restClientGetter genericclioptions.RESTClientGetter, pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin, out io.Writer) error {
+	if pluginDefinition.Spec.HelmChart == nil {
+		fmt.Fprintf(out, "plugin definition %s has no helm chart, nothing to deploy\n", pluginDefinition.GetName())
+		return nil
+	}
+	diffs, isDrift, err := helm.DiffChartToDeployedResources(ctx, k8sClient, restClientGetter, pluginDefinition, plugin)
+	if err != nil {
+		return fmt.Errorf("failed to diff plugin %s/%s: %w", plugin.GetNamespace(), plugin.GetName(), err)
+	}
+	switch {
+	case len(diffs) > 0:
+		fmt.Fprint(out, diffs.String())
+	case isDrift:
+		fmt.Fprintf(out, "the helm release of plugin %s/%s will be upgraded to %s %s\n", plugin.GetNamespace(), plugin.GetName(), pluginDefinition.Spec.HelmChart.String(), pluginDefinition.Spec.Version)
+	default:
+		fmt.Fprintf(out, "no changes for plugin %s/%s\n", plugin.GetNamespace(), plugin.GetName())
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sort"
@@ -30,13 +31,18 @@ func init() {
 
 type pluginGenerateOptions struct {
 	helmChartPath, outPath string
+	ignoreSchema           bool
+	filter                 valuePathFilter
 }
 
 func newPluginGenerateCmd() *cobra.Command {
 	o := &pluginGenerateOptions{}
-	return &cobra.Command{
+	generateCmd := &cobra.Command{
 		Use:   pluginGenerateCmdUsage,
 		Short: "Create a Greenhouse PluginDefinition based on an existing Helm Chart",
+		Long: `Creates a Greenhouse PluginDefinition based on an existing Helm Chart.
+The options are derived from the values.schema.json of the chart if present, including their types, descriptions, defaults, required flags and patterns.
+Otherwise, they are derived from the values.yaml. Use --include and --exclude to select the value paths options are generated for.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.validate(args); err != nil {
 				return err
@@ -44,9 +50,13 @@ func newPluginGenerateCmd() *cobra.Command {
 			if err := o.complete(args); err != nil {
 				return err
 			}
-			return o.run()
+			return o.run(cmd.ErrOrStderr())
 		},
 	}
+	generateCmd.Flags().BoolVar(&o.ignoreSchema, "ignore-schema", false, "Derive the options from the values.yaml even if the chart has a values.schema.json")
+	generateCmd.Flags().StringSliceVar(&o.filter.include, "include", nil, "Only generate options for these value paths and the values nested below them, e.g. --include=ingress,image.tag")
+	generateCmd.Flags().StringSliceVar(&o.filter.exclude, "exclude", nil, "Do not generate options for these value paths and the values nested below them")
+	return generateCmd
 }
 
 func (o *pluginGenerateOptions) validate(args []string) error {
@@ -66,7 +76,7 @@ func (o *pluginGenerateOptions) complete(args []string) error {
 	return err
 }
 
-func (o *pluginGenerateOptions) run() error {
+func (o *pluginGenerateOptions) run(errOut io.Writer) error {
 	helmChart, err := loader.Load(o.helmChartPath)
 	if err != nil {
 		return err
@@ -82,7 +92,10 @@ func (o *pluginGenerateOptions) run() error {
 		return err
 	}
 	// Write output.
-	pluginDefinition, err := helmChartToPlugin(helmChart)
+	if o.ignoreSchema {
+		helmChart.Schema = nil
+	}
+	pluginDefinition, err := helmChartToPlugin(helmChart, o.filter, errOut)
 	if err != nil {
 		return err
 	}
@@ -100,12 +113,18 @@ func (o *pluginGenerateOptions) run() error {
 	return nil
 }
 
-func helmChartToPlugin(helmChart *chart.Chart) (*greenhousev1alpha1.PluginDefinition, error) {
+func helmChartToPlugin(helmChart *chart.Chart, filter valuePathFilter, errOut io.Writer) (*greenhousev1alpha1.PluginDefinition, error) {
 	pluginVersion := "1.0.0"
 	if helmChart.Metadata != nil && helmChart.Metadata.Version != "" {
 		pluginVersion = helmChart.Metadata.Version
 	}
-	pluginValues, err := chartValuesToNamedValues(helmChart.Values)
+	var pluginValues []greenhousev1alpha1.PluginOption
+	var err error
+	if len(helmChart.Schema) > 0 {
+		pluginValues, err = chartSchemaToOptions(helmChart.Schema, helmChart.Values, filter, errOut)
+	} else {
+		pluginValues, err = chartValuesToNamedValues(helmChart.Values, filter)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +154,7 @@ func helmChartToPlugin(helmChart *chart.Chart) (*greenhousev1alpha1.PluginDefini
 	}, nil
 }
 
-func chartValuesToNamedValues(chartValues map[string]interface{}) ([]greenhousev1alpha1.PluginOption, error) {
+func chartValuesToNamedValues(chartValues map[string]interface{}, filter valuePathFilter) ([]greenhousev1alpha1.PluginOption, error) {
 	if chartValues == nil {
 		return nil, nil
 	}
@@ -146,6 +165,9 @@ func chartValuesToNamedValues(chartValues map[string]interface{}) ([]greenhousev
 
 	namedValues := make([]greenhousev1alpha1.PluginOption, 0)
 	for k, v := range flatChartValues {
+		if !filter.matches(k) {
+			continue
+		}
 		raw, err := json.Marshal(v)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+// valuesSchema is the subset of a JSON schema of the values of a Helm chart that is mapped to PluginOptions.
+type valuesSchema struct {
+	Type        schemaType               `json:"type"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	Default     json.RawMessage          `json:"default"`
+	Enum        []json.RawMessage        `json:"enum"`
+	Pattern     string                   `json:"pattern"`
+	Required    []string                 `json:"required"`
+	Properties  map[string]*valuesSchema `json:"properties"`
+}
+
+// schemaType is the type of a JSON schema, given either as a single type or a list of types.
+type schemaType []string
+
+func (t *schemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaType{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// primary returns the first type other than null.
+func (t schemaType) primary() string {
+	for _, typ := range t {
+		if typ != "null" {
+			return typ
+		}
+	}
+	return ""
+}
+
+// valuePathFilter selects the value paths options are generated for.
+// A path matches an entry if it is equal to it or nested below it.
+type valuePathFilter struct {
+	include []string
+	exclude []string
+}
+
+func (f valuePathFilter) matches(path string) bool {
+	within := func(prefixes []string) bool {
+		return slices.ContainsFunc(prefixes, func(prefix string) bool {
+			return path == prefix || strings.HasPrefix(path, prefix+".")
+		})
+	}
+	if len(f.include) > 0 && !within(f.include) {
+		return false
+	}
+	return !within(f.exclude)
+}
+
+// chartSchemaToOptions maps the properties of the values.schema.json of a Helm chart to PluginOptions.
+// Nested objects are flattened to dotted paths, free-form objects become map options. Defaults not given in the schema are taken from the values.
+// Properties with types that cannot be expressed as a PluginOption are reported to errOut and skipped.
+func chartSchemaToOptions(rawSchema []byte, chartValues map[string]any, filter valuePathFilter, errOut io.Writer) ([]greenhousev1alpha1.PluginOption, error) {
+	var schema = new(valuesSchema)
+	if err := json.Unmarshal(rawSchema, schema); err != nil {
+		return nil, fmt.Errorf("failed to parse values schema: %w", err)
+	}
+	options := make([]greenhousev1alpha1.PluginOption, 0)
+	if err := schemaPropertiesToOptions(schema, "", true, chartValues, filter, errOut, &options); err != nil {
+		return nil, err
+	}
+	sort.Slice(options, func(i, j int) bool {
+		return options[i].Name < options[j].Name
+	})
+	return options, nil
+}
+
+// schemaPropertiesToOptions appends an option for each property of the object schema.
+// A property is only required if all objects it is nested in are required as well.
+func schemaPropertiesToOptions(schema *valuesSchema, prefix string, parentRequired bool, values map[string]any, filter valuePathFilter, errOut io.Writer, options *[]greenhousev1alpha1.PluginOption) error {
+	for name, property := range schema.Properties {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		required := parentRequired && slices.Contains(schema.Required, name)
+		value, hasValue := values[name]
+		if property.Type.primary() == "object" && len(property.Properties) > 0 {
+			nestedValues, _ := value.(map[string]any)
+			if err := schemaPropertiesToOptions(property, path, required, nestedValues, filter, errOut, options); err != nil {
+				return err
+			}
+			continue
+		}
+		if !filter.matches(path) {
+			continue
+		}
+		option, err := schemaPropertyToOption(path, property, required)
+		if err != nil {
+			fmt.Fprintf(errOut, "skipping value %s: %s\n", path, err)
+			continue
+		}
+		if option.Default == nil && hasValue && value != nil {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			option.Default = &apiextensionsv1.JSON{Raw: raw}
+		}
+		if err := option.IsValid(); err != nil {
+			fmt.Fprintf(errOut, "ignoring default of value %s: %s\n", path, err)
+			option.Default = nil
+		}
+		*options = append(*options, option)
+	}
+	return nil
+}
+
+func schemaPropertyToOption(path string, property *valuesSchema, required bool) (greenhousev1alpha1.PluginOption, error) {
+	option := greenhousev1alpha1.PluginOption{
+		Name:        path,
+		DisplayName: property.Title,
+		Description: property.Description,
+		Required:    required,
+		Regex:       property.Pattern,
+	}
+	switch typ := property.Type.primary(); typ {
+	case "string":
+		option.Type = greenhousev1alpha1.PluginOptionTypeString
+	case "integer":
+		option.Type = greenhousev1alpha1.PluginOptionTypeInt
+	case "boolean":
+		option.Type = greenhousev1alpha1.PluginOptionTypeBool
+	case "array":
+		option.Type = greenhousev1alpha1.PluginOptionTypeList
+	case "object":
+		option.Type = greenhousev1alpha1.PluginOptionTypeMap
+	case "":
+		return option, fmt.Errorf("no type given")
+	default:
+		return option, fmt.Errorf("type %s is not supported", typ)
+	}
+	if option.Description == "" {
+		option.Description = path
+	}
+	if len(property.Default) > 0 && string(property.Default) != "null" {
+		option.Default = &apiextensionsv1.JSON{Raw: property.Default}
+	}
+	if len(property.Enum) > 0 {
+		allowed := make([]string, 0, len(property.Enum))
+		for _, value := range property.Enum {
+			allowed = append(allowed, string(value))
+		}
+		option.Description = fmt.Sprintf("%s (one of %s)", option.Description, strings.Join(allowed, ", "))
+		// PluginOptions have no enum, the allowed strings are enforced with a regex instead.
+		if option.Type == greenhousev1alpha1.PluginOptionTypeString && option.Regex == "" {
+			option.Regex = enumRegex(property.Enum)
+		}
+	}
+	return option, nil
+}
+
+// enumRegex returns a regex matching exactly the string values of the enum.
+func enumRegex(enum []json.RawMessage) string {
+	alternatives := make([]string, 0, len(enum))
+	for _, value := range enum {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			continue
+		}
+		alternatives = append(alternatives, regexp.QuoteMeta(s))
+	}
+	if len(alternatives) == 0 {
+		return ""
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
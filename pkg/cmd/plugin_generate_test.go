@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+const testValuesSchema = `{
+  "$schema": "https://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["image", "logLevel"],
+  "properties": {
+    "image": {
+      "type": "object",
+      "required": ["repository"],
+      "properties": {
+        "repository": {"type": "string", "description": "The image repository", "pattern": "^[a-z./-]+$"},
+        "tag": {"type": ["string", "null"]}
+      }
+    },
+    "ingress": {
+      "type": "object",
+      "required": ["host"],
+      "properties": {
+        "host": {"type": "string", "title": "Host"},
+        "annotations": {"type": "object"}
+      }
+    },
+    "logLevel": {"type": "string", "enum": ["debug", "info"], "default": "info"},
+    "replicas": {"type": "integer", "default": "two"},
+    "ratio": {"type": "number"},
+    "extraArgs": {"type": "array"}
+  }
+}`
+
+var _ = Describe("Generate a PluginDefinition", func() {
+	var helmChart *chart.Chart
+
+	BeforeEach(func() {
+		helmChart = &chart.Chart{
+			Metadata: &chart.Metadata{Name: "test-chart", Version: "1.2.3"},
+			Values: map[string]any{
+				"image":    map[string]any{"repository": "registry/test", "tag": "1.0"},
+				"replicas": 2,
+			},
+			Schema: []byte(testValuesSchema),
+		}
+	})
+
+	optionsByName := func(options []greenhousev1alpha1.PluginOption) map[string]greenhousev1alpha1.PluginOption {
+		byName := make(map[string]greenhousev1alpha1.PluginOption, len(options))
+		for _, option := range options {
+			byName[option.Name] = option
+		}
+		return byName
+	}
+
+	It("should map the values schema to plugin options", func() {
+		var errOut bytes.Buffer
+		pluginDefinition, err := helmChartToPlugin(helmChart, valuePathFilter{}, &errOut)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error generating the plugin definition")
+		options := optionsByName(pluginDefinition.Spec.Options)
+		Expect(options).To(HaveLen(7))
+
+		Expect(options["image.repository"]).To(Equal(greenhousev1alpha1.PluginOption{
+			Name: "image.repository", Description: "The image repository", Type: greenhousev1alpha1.PluginOptionTypeString,
+			Required: true, Regex: "^[a-z./-]+$", Default: options["image.repository"].Default,
+		}))
+		Expect(string(options["image.repository"].Default.Raw)).To(Equal(`"registry/test"`), "the default should be taken from the values")
+		Expect(options["image.tag"].Required).To(BeFalse())
+		Expect(options["image.tag"].Type).To(Equal(greenhousev1alpha1.PluginOptionTypeString))
+		Expect(options["ingress.host"].Required).To(BeFalse(), "values nested in optional objects should not be required")
+		Expect(options["ingress.host"].DisplayName).To(Equal("Host"))
+		Expect(options["ingress.annotations"].Type).To(Equal(greenhousev1alpha1.PluginOptionTypeMap))
+		Expect(options["logLevel"].Regex).To(Equal("^(debug|info)$"))
+		Expect(options["logLevel"].Description).To(Equal(`logLevel (one of "debug", "info")`))
+		Expect(string(options["logLevel"].Default.Raw)).To(Equal(`"info"`))
+		Expect(options["extraArgs"].Type).To(Equal(greenhousev1alpha1.PluginOptionTypeList))
+
+		Expect(options).ToNot(HaveKey("ratio"), "numbers cannot be expressed as plugin options")
+		Expect(errOut.String()).To(ContainSubstring("skipping value ratio: type number is not supported"))
+		Expect(options["replicas"].Default).To(BeNil(), "the default not matching the type should be ignored")
+		Expect(errOut.String()).To(ContainSubstring("ignoring default of value replicas"))
+	})
+
+	It("should only generate options for the selected value paths", func() {
+		filter := valuePathFilter{include: []string{"image", "ingress"}, exclude: []string{"ingress.annotations"}}
+		pluginDefinition, err := helmChartToPlugin(helmChart, filter, &bytes.Buffer{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(optionsByName(pluginDefinition.Spec.Options)).To(SatisfyAll(
+			HaveLen(3), HaveKey("image.repository"), HaveKey("image.tag"), HaveKey("ingress.host"),
+		))
+	})
+
+	It("should derive the options from the values without a schema", func() {
+		helmChart.Schema = nil
+		pluginDefinition, err := helmChartToPlugin(helmChart, valuePathFilter{exclude: []string{"replicas"}}, &bytes.Buffer{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(optionsByName(pluginDefinition.Spec.Options)).To(SatisfyAll(
+			HaveLen(2), HaveKey("image.repository"), HaveKey("image.tag"),
+		))
+	})
+})
@@ -0,0 +1,327 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type pluginInstallOptions struct {
+	kubecontext      string
+	orgName          string
+	pluginDefinition string
+	clusterName      string
+	releaseNamespace string
+	interactive      bool
+	dryRun           bool
+	optionValues     pluginOptionValueFlags
+}
+
+// pluginOptionValueFlags are the flags to set the option values of a Plugin.
+type pluginOptionValueFlags struct {
+	values       []string
+	secretValues []string
+}
+
+func init() {
+	pluginCmd.AddCommand(newPluginInstallCmd())
+}
+
+func newPluginInstallCmd() *cobra.Command {
+	o := &pluginInstallOptions{}
+	installCmd := &cobra.Command{
+		Use:   "install <plugin-name>",
+		Short: "Install a Plugin from a PluginDefinition",
+		Long: `Creates a Plugin from a PluginDefinition in the organization.
+Option values are set with --set and --set-secret. In interactive mode, the values of all other options of the PluginDefinition are prompted for.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			var pluginDefinition = new(greenhousev1alpha1.PluginDefinition)
+			if err := k8sClient.Get(cmd.Context(), client.ObjectKey{Name: o.pluginDefinition}, pluginDefinition); err != nil {
+				return fmt.Errorf("failed to get plugin definition %s: %w", o.pluginDefinition, err)
+			}
+			optionValues, err := o.optionValues.parse(pluginDefinition)
+			if err != nil {
+				return err
+			}
+			if o.interactive {
+				optionValues, err = promptPluginOptionValues(cmd.InOrStdin(), cmd.OutOrStdout(), pluginDefinition, optionValues)
+				if err != nil {
+					return err
+				}
+			}
+			plugin := o.newPlugin(args[0], pluginDefinition, optionValues)
+			return createPlugin(cmd.Context(), k8sClient, plugin, o.dryRun, cmd.OutOrStdout())
+		},
+	}
+
+	installCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	installCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	installCmd.Flags().StringVar(&o.pluginDefinition, "plugin-definition", "", "The name of the PluginDefinition to install")
+	installCmd.Flags().StringVar(&o.clusterName, "cluster-name", "", "The name of the cluster to deploy the Plugin to")
+	installCmd.Flags().StringVar(&o.releaseNamespace, "release-namespace", "", "The namespace in the cluster to deploy the Plugin to (defaults to the organization namespace)")
+	installCmd.Flags().BoolVar(&o.interactive, "interactive", term.IsTerminal(int(os.Stdin.Fd())), "Prompt for the values of all options not set via flags")
+	installCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only validate and print the Plugin without creating it")
+	o.optionValues.addFlags(installCmd.Flags())
+	for _, flagName := range []string{"org", "plugin-definition"} {
+		if err := installCmd.MarkFlagRequired(flagName); err != nil {
+			setupLog.Error(err, "Flag could not set as required", flagName)
+		}
+	}
+	return installCmd
+}
+
+func (o *pluginInstallOptions) newPlugin(name string, pluginDefinition *greenhousev1alpha1.PluginDefinition, optionValues []greenhousev1alpha1.PluginOptionValue) *greenhousev1alpha1.Plugin {
+	return &greenhousev1alpha1.Plugin{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: greenhousev1alpha1.GroupVersion.String(),
+			Kind:       "Plugin",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: o.orgName,
+		},
+		Spec: greenhousev1alpha1.PluginSpec{
+			PluginDefinition: pluginDefinition.GetName(),
+			ClusterName:      o.clusterName,
+			ReleaseNamespace: o.releaseNamespace,
+			OptionValues:     optionValues,
+		},
+	}
+}
+
+// createPlugin creates the Plugin. The Plugin is validated by the admission webhooks without being persisted if dryRun is set.
+func createPlugin(ctx context.Context, k8sClient client.Client, plugin *greenhousev1alpha1.Plugin, dryRun bool, out io.Writer) error {
+	if !dryRun {
+		if err := k8sClient.Create(ctx, plugin); err != nil {
+			return fmt.Errorf("failed to create plugin %s/%s: %w", plugin.GetNamespace(), plugin.GetName(), err)
+		}
+		fmt.Fprintf(out, "plugin %s/%s created\n", plugin.GetNamespace(), plugin.GetName())
+		return nil
+	}
+	if err := k8sClient.Create(ctx, plugin, client.DryRunAll); err != nil {
+		return fmt.Errorf("plugin %s/%s is invalid: %w", plugin.GetNamespace(), plugin.GetName(), err)
+	}
+	plugin.ObjectMeta = metav1.ObjectMeta{Name: plugin.GetName(), Namespace: plugin.GetNamespace(), Labels: plugin.GetLabels()}
+	plugin.Status = greenhousev1alpha1.PluginStatus{}
+	pluginYAML, err := yaml.Marshal(plugin)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(pluginYAML)
+	return err
+}
+
+func (f *pluginOptionValueFlags) addFlags(flags *pflag.FlagSet) {
+	flags.StringArrayVar(&f.values, "set", nil, "Set an option value, e.g. --set name=value. Lists and maps are given in YAML, e.g. --set 'hosts=[a, b]'")
+	flags.StringArrayVar(&f.secretValues, "set-secret", nil, "Set an option value from a key of a Secret in the organization, e.g. --set-secret name=secret-name/key")
+}
+
+// parse returns the option values given via the flags. Values are validated against the options of the PluginDefinition.
+func (f *pluginOptionValueFlags) parse(pluginDefinition *greenhousev1alpha1.PluginDefinition) ([]greenhousev1alpha1.PluginOptionValue, error) {
+	var optionValues []greenhousev1alpha1.PluginOptionValue
+	for _, value := range f.values {
+		name, rawValue, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid option value %q, expected name=value", value)
+		}
+		option, err := getPluginOption(pluginDefinition, name)
+		if err != nil {
+			return nil, err
+		}
+		optionValue, err := parsePluginOptionValue(option, rawValue)
+		if err != nil {
+			return nil, err
+		}
+		optionValues = append(optionValues, *optionValue)
+	}
+	for _, value := range f.secretValues {
+		name, rawValue, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid secret option value %q, expected name=secret-name/key", value)
+		}
+		if _, err := getPluginOption(pluginDefinition, name); err != nil {
+			return nil, err
+		}
+		optionValue, err := parsePluginOptionSecretValue(name, rawValue)
+		if err != nil {
+			return nil, err
+		}
+		optionValues = append(optionValues, *optionValue)
+	}
+	return optionValues, nil
+}
+
+func getPluginOption(pluginDefinition *greenhousev1alpha1.PluginDefinition, name string) (*greenhousev1alpha1.PluginOption, error) {
+	for _, option := range pluginDefinition.Spec.Options {
+		if option.Name == name {
+			return &option, nil
+		}
+	}
+	return nil, fmt.Errorf("plugin definition %s has no option %s", pluginDefinition.GetName(), name)
+}
+
+// parsePluginOptionValue converts the raw value to the type of the option.
+// Strings are taken verbatim, all other values are parsed as YAML.
+func parsePluginOptionValue(option *greenhousev1alpha1.PluginOption, rawValue string) (*greenhousev1alpha1.PluginOptionValue, error) {
+	if option.Type == greenhousev1alpha1.PluginOptionTypeSecret {
+		return parsePluginOptionSecretValue(option.Name, rawValue)
+	}
+	var value []byte
+	var err error
+	switch option.Type {
+	case greenhousev1alpha1.PluginOptionTypeString:
+		if option.Regex != "" {
+			matched, err := regexp.MatchString(option.Regex, rawValue)
+			if err != nil {
+				return nil, fmt.Errorf("option %s has an invalid regex: %w", option.Name, err)
+			}
+			if !matched {
+				return nil, fmt.Errorf("option %s must match %s", option.Name, option.Regex)
+			}
+		}
+		value, err = json.Marshal(rawValue)
+	default:
+		value, err = yaml.YAMLToJSON([]byte(rawValue))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for option %s: %w", option.Name, err)
+	}
+	optionValue := &greenhousev1alpha1.PluginOptionValue{Name: option.Name, Value: &apiextensionsv1.JSON{Raw: value}}
+	if err := option.IsValidValue(optionValue.Value); err != nil {
+		return nil, err
+	}
+	return optionValue, nil
+}
+
+func parsePluginOptionSecretValue(name, rawValue string) (*greenhousev1alpha1.PluginOptionValue, error) {
+	secretName, key, found := strings.Cut(rawValue, "/")
+	if !found || secretName == "" || key == "" {
+		return nil, fmt.Errorf("invalid secret reference %q for option %s, expected secret-name/key", rawValue, name)
+	}
+	return &greenhousev1alpha1.PluginOptionValue{
+		Name: name,
+		ValueFrom: &greenhousev1alpha1.ValueFromSource{
+			Secret: &greenhousev1alpha1.SecretKeyReference{Name: secretName, Key: key},
+		},
+	}, nil
+}
+
+// promptPluginOptionValues prompts for the values of all options of the PluginDefinition which are not set yet.
+// Options with a default value are skipped on empty input, required options are prompted for until a valid value is given.
+func promptPluginOptionValues(in io.Reader, out io.Writer, pluginDefinition *greenhousev1alpha1.PluginDefinition, optionValues []greenhousev1alpha1.PluginOptionValue) ([]greenhousev1alpha1.PluginOptionValue, error) {
+	scanner := bufio.NewScanner(in)
+	for _, option := range pluginDefinition.Spec.Options {
+		if hasPluginOptionValue(optionValues, option.Name) {
+			continue
+		}
+		for {
+			fmt.Fprint(out, pluginOptionPrompt(&option))
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return nil, err
+				}
+				if option.Required && option.Default == nil {
+					return nil, fmt.Errorf("no value given for required option %s", option.Name)
+				}
+				fmt.Fprintln(out)
+				break
+			}
+			rawValue := strings.TrimSpace(scanner.Text())
+			if rawValue == "" {
+				if option.Required && option.Default == nil {
+					fmt.Fprintf(out, "option %s is required\n", option.Name)
+					continue
+				}
+				break
+			}
+			optionValue, err := parsePluginOptionValue(&option, rawValue)
+			if err != nil {
+				fmt.Fprintln(out, err.Error())
+				continue
+			}
+			optionValues = append(optionValues, *optionValue)
+			break
+		}
+	}
+	return optionValues, nil
+}
+
+func pluginOptionPrompt(option *greenhousev1alpha1.PluginOption) string {
+	var b strings.Builder
+	if option.Description != "" {
+		fmt.Fprintf(&b, "# %s\n", option.Description)
+	}
+	name := option.Name
+	if option.DisplayName != "" {
+		name = fmt.Sprintf("%s (%s)", option.DisplayName, option.Name)
+	}
+	fmt.Fprintf(&b, "%s [%s", name, option.Type)
+	switch {
+	case option.Type == greenhousev1alpha1.PluginOptionTypeSecret:
+		b.WriteString(", secret-name/key")
+	case option.Default != nil:
+		fmt.Fprintf(&b, ", default %s", string(option.Default.Raw))
+	case option.Required:
+		b.WriteString(", required")
+	}
+	b.WriteString("]: ")
+	return b.String()
+}
+
+func hasPluginOptionValue(optionValues []greenhousev1alpha1.PluginOptionValue, name string) bool {
+	for _, optionValue := range optionValues {
+		if optionValue.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergePluginOptionValues sets the updated option values and removes the unset ones.
+func mergePluginOptionValues(optionValues, updates []greenhousev1alpha1.PluginOptionValue, unset []string) ([]greenhousev1alpha1.PluginOptionValue, error) {
+	var merged = make([]greenhousev1alpha1.PluginOptionValue, 0, len(optionValues)+len(updates))
+	for _, optionValue := range optionValues {
+		if slices.Contains(unset, optionValue.Name) || hasPluginOptionValue(updates, optionValue.Name) {
+			continue
+		}
+		merged = append(merged, optionValue)
+	}
+	for _, name := range unset {
+		if hasPluginOptionValue(updates, name) {
+			return nil, errors.New("option " + name + " cannot be set and unset")
+		}
+	}
+	return append(merged, updates...), nil
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("Plugin lifecycle commands", func() {
+	var pluginDefinition *greenhousev1alpha1.PluginDefinition
+
+	BeforeEach(func() {
+		pluginDefinition = &greenhousev1alpha1.PluginDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-plugindefinition"},
+			Spec: greenhousev1alpha1.PluginDefinitionSpec{
+				Version: "1.0.0",
+				Options: []greenhousev1alpha1.PluginOption{
+					{Name: "host", Type: greenhousev1alpha1.PluginOptionTypeString, Required: true, Regex: "^[a-z.]+$", Description: "The host name"},
+					{Name: "replicas", Type: greenhousev1alpha1.PluginOptionTypeInt, Default: &apiextensionsv1.JSON{Raw: []byte("1")}},
+					{Name: "enabled", Type: greenhousev1alpha1.PluginOptionTypeBool},
+					{Name: "users", Type: greenhousev1alpha1.PluginOptionTypeList},
+					{Name: "password", Type: greenhousev1alpha1.PluginOptionTypeSecret},
+				},
+			},
+		}
+	})
+
+	Context("parsing option values", func() {
+		It("should convert the values to the option types", func() {
+			flags := &pluginOptionValueFlags{
+				values:       []string{"host=example.com", "replicas=3", "enabled=true", "users=[a, b]"},
+				secretValues: []string{"password=my-secret/password"},
+			}
+			optionValues, err := flags.parse(pluginDefinition)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error parsing the option values")
+			Expect(optionValues).To(HaveLen(5))
+			Expect(optionValues[0].ValueJSON()).To(Equal(`"example.com"`))
+			Expect(optionValues[1].ValueJSON()).To(Equal(`3`))
+			Expect(optionValues[2].ValueJSON()).To(Equal(`true`))
+			Expect(optionValues[3].ValueJSON()).To(Equal(`["a","b"]`))
+			Expect(optionValues[4].ValueFrom.Secret).To(Equal(&greenhousev1alpha1.SecretKeyReference{Name: "my-secret", Key: "password"}))
+		})
+
+		It("should reject invalid values", func() {
+			for _, value := range []string{"unknown=value", "replicas=three", "host=Not Valid", "enabled"} {
+				flags := &pluginOptionValueFlags{values: []string{value}}
+				_, err := flags.parse(pluginDefinition)
+				Expect(err).To(HaveOccurred(), "value %s should be rejected", value)
+			}
+			_, err := (&pluginOptionValueFlags{secretValues: []string{"password=my-secret"}}).parse(pluginDefinition)
+			Expect(err).To(HaveOccurred(), "the secret reference must contain a key")
+		})
+	})
+
+	Context("prompting for option values", func() {
+		It("should prompt for all options not set yet", func() {
+			optionValues := []greenhousev1alpha1.PluginOptionValue{{Name: "enabled", Value: &apiextensionsv1.JSON{Raw: []byte("false")}}}
+			in := strings.NewReader("\nNot Valid\nexample.com\n\n[a]\nmy-secret/password\n")
+			var out bytes.Buffer
+			optionValues, err := promptPluginOptionValues(in, &out, pluginDefinition, optionValues)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error prompting for the option values")
+			Expect(out.String()).To(ContainSubstring("# The host name\nhost [string, required]: "))
+			Expect(out.String()).To(ContainSubstring("option host is required"))
+			Expect(out.String()).To(ContainSubstring("option host must match"))
+			Expect(out.String()).To(ContainSubstring("replicas [int, default 1]: "))
+			Expect(out.String()).ToNot(ContainSubstring("enabled ["), "options already set should not be prompted for")
+
+			names := make([]string, 0, len(optionValues))
+			for _, optionValue := range optionValues {
+				names = append(names, optionValue.Name)
+			}
+			Expect(names).To(Equal([]string{"enabled", "host", "users", "password"}), "the default should be kept for an empty input")
+		})
+
+		It("should fail if a required option is missing at the end of the input", func() {
+			_, err := promptPluginOptionValues(strings.NewReader(""), &bytes.Buffer{}, pluginDefinition, nil)
+			Expect(err).To(MatchError(ContainSubstring("no value given for required option host")))
+		})
+	})
+
+	Context("installing and upgrading", func() {
+		It("should print the plugin in dry-run mode without creating it", func() {
+			k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).Build()
+			o := &pluginInstallOptions{orgName: "test-org", clusterName: "test-cluster"}
+			plugin := o.newPlugin("test-plugin", pluginDefinition, []greenhousev1alpha1.PluginOptionValue{{Name: "host", Value: &apiextensionsv1.JSON{Raw: []byte(`"example.com"`)}}})
+			var out bytes.Buffer
+			Expect(createPlugin(context.Background(), k8sClient, plugin, true, &out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("kind: Plugin"))
+			Expect(out.String()).To(ContainSubstring("pluginDefinition: test-plugindefinition"))
+			Expect(out.String()).To(ContainSubstring("clusterName: test-cluster"))
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(plugin), &greenhousev1alpha1.Plugin{})).ToNot(Succeed(), "the plugin should not be created")
+		})
+
+		It("should update and remove option values", func() {
+			plugin := &greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-plugin"},
+				Spec: greenhousev1alpha1.PluginSpec{
+					PluginDefinition: pluginDefinition.Name,
+					OptionValues: []greenhousev1alpha1.PluginOptionValue{
+						{Name: "host", Value: &apiextensionsv1.JSON{Raw: []byte(`"example.com"`)}},
+						{Name: "replicas", Value: &apiextensionsv1.JSON{Raw: []byte(`2`)}},
+					},
+				},
+			}
+			k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(pluginDefinition, plugin).Build()
+			_, err := upgradePlugin(context.Background(), k8sClient, "test-org", "test-plugin", &pluginOptionValueFlags{values: []string{"host=other.com"}}, []string{"replicas"}, false)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error upgrading the plugin")
+			Expect(k8sClient.Get(context.Background(), client.ObjectKeyFromObject(plugin), plugin)).To(Succeed())
+			Expect(plugin.Spec.OptionValues).To(HaveLen(1))
+			Expect(plugin.Spec.OptionValues[0].ValueJSON()).To(Equal(`"other.com"`))
+		})
+
+		It("should not upgrade plugins managed by a plugin preset", func() {
+			plugin := &greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-plugin", Labels: map[string]string{greenhouseapis.LabelKeyPluginPreset: "test-preset"}},
+				Spec:       greenhousev1alpha1.PluginSpec{PluginDefinition: pluginDefinition.Name},
+			}
+			k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(pluginDefinition, plugin).Build()
+			_, err := upgradePlugin(context.Background(), k8sClient, "test-org", "test-plugin", &pluginOptionValueFlags{values: []string{"host=other.com"}}, nil, false)
+			Expect(err).To(MatchError(ContainSubstring("managed by the plugin preset test-preset")))
+		})
+
+		It("should not set and unset the same option", func() {
+			_, err := mergePluginOptionValues(nil, []greenhousev1alpha1.PluginOptionValue{{Name: "host"}}, []string{"host"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("status", func() {
+		It("should render the conditions and the workload state", func() {
+			healthy := true
+			plugin := &greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-plugin"},
+				Spec:       greenhousev1alpha1.PluginSpec{PluginDefinition: pluginDefinition.Name, ClusterName: "test-cluster"},
+				Status: greenhousev1alpha1.PluginStatus{
+					Version:           "1.0.0",
+					HelmReleaseStatus: &greenhousev1alpha1.HelmReleaseStatus{Status: "deployed"},
+					ExposedServices: map[string]greenhousev1alpha1.Service{
+						"https://test.example.com": {Namespace: "test-ns", Name: "test-svc", Port: 80, Healthy: &healthy},
+					},
+				},
+			}
+			plugin.SetCondition(greenhousev1alpha1.FalseCondition(greenhousev1alpha1.WorkloadReadyCondition, "", "deployment test-ns/test is not ready"))
+			plugin.SetCondition(greenhousev1alpha1.TrueCondition(greenhousev1alpha1.ReadyCondition, "", ""))
+
+			var out bytes.Buffer
+			Expect(printPluginStatus(&out, plugin)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("Helm release:"))
+			Expect(out.String()).To(MatchRegexp(`Workload:\s+not ready: deployment test-ns/test is not ready`))
+			Expect(out.String()).To(MatchRegexp(`WorkloadReady\s+False`))
+			Expect(out.String()).To(MatchRegexp(`https://test.example.com\s+test-ns/test-svc:80\s+true`))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type pluginStatusOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	pluginCmd.AddCommand(newPluginStatusCmd())
+}
+
+func newPluginStatusCmd() *cobra.Command {
+	o := &pluginStatusOptions{}
+	statusCmd := &cobra.Command{
+		Use:          "status <plugin-name>",
+		Short:        "Show the status of a Plugin",
+		Long:         "Shows the conditions, the Helm release, the workload state and the exposed services of a Plugin.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			var plugin = new(greenhousev1alpha1.Plugin)
+			if err := k8sClient.Get(cmd.Context(), client.ObjectKey{Namespace: o.orgName, Name: args[0]}, plugin); err != nil {
+				return fmt.Errorf("failed to get plugin %s/%s: %w", o.orgName, args[0], err)
+			}
+			return printPluginStatus(cmd.OutOrStdout(), plugin)
+		},
+	}
+
+	statusCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	statusCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := statusCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return statusCmd
+}
+
+func printPluginStatus(out io.Writer, plugin *greenhousev1alpha1.Plugin) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", plugin.GetName())
+	fmt.Fprintf(w, "Organization:\t%s\n", plugin.GetNamespace())
+	fmt.Fprintf(w, "PluginDefinition:\t%s\n", plugin.Spec.PluginDefinition)
+	fmt.Fprintf(w, "Version:\t%s\n", plugin.Status.Version)
+	fmt.Fprintf(w, "Cluster:\t%s\n", plugin.Spec.ClusterName)
+	fmt.Fprintf(w, "Release namespace:\t%s\n", plugin.Spec.ReleaseNamespace)
+	fmt.Fprintf(w, "Disabled:\t%t\n", plugin.Spec.Disabled)
+	if release := plugin.Status.HelmReleaseStatus; release != nil {
+		fmt.Fprintf(w, "Helm release:\t%s, last deployed %s\n", release.Status, release.LastDeployed.Format(time.RFC3339))
+	}
+	if workload := plugin.Status.GetConditionByType(greenhousev1alpha1.WorkloadReadyCondition); workload != nil {
+		fmt.Fprintf(w, "Workload:\t%s\n", workloadState(workload))
+	}
+
+	printConditions(w, plugin.Status.Conditions)
+
+	if len(plugin.Status.ExposedServices) > 0 {
+		fmt.Fprintln(w, "\nEXPOSED SERVICE\tSERVICE\tHEALTHY")
+		urls := make([]string, 0, len(plugin.Status.ExposedServices))
+		for url := range plugin.Status.ExposedServices {
+			urls = append(urls, url)
+		}
+		slices.Sort(urls)
+		for _, url := range urls {
+			svc := plugin.Status.ExposedServices[url]
+			healthy := "unknown"
+			if svc.Healthy != nil {
+				healthy = fmt.Sprintf("%t", *svc.Healthy)
+			}
+			fmt.Fprintf(w, "%s\t%s/%s:%d\t%s\n", url, svc.Namespace, svc.Name, svc.Port, healthy)
+		}
+	}
+	if release := plugin.Status.HelmReleaseStatus; release != nil && release.Diff != "" {
+		fmt.Fprintf(w, "\nDrift detected in the last reconciliation:\n%s\n", release.Diff)
+	}
+	return w.Flush()
+}
+
+func workloadState(condition *greenhousev1alpha1.Condition) string {
+	switch {
+	case condition.IsTrue():
+		return "ready"
+	case condition.Message != "":
+		return "not ready: " + condition.Message
+	case condition.IsUnknown():
+		return "unknown"
+	default:
+		return "not ready"
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type pluginUpgradeOptions struct {
+	kubecontext  string
+	orgName      string
+	unset        []string
+	dryRun       bool
+	optionValues pluginOptionValueFlags
+}
+
+func init() {
+	pluginCmd.AddCommand(newPluginUpgradeCmd())
+}
+
+func newPluginUpgradeCmd() *cobra.Command {
+	o := &pluginUpgradeOptions{}
+	upgradeCmd := &cobra.Command{
+		Use:   "upgrade <plugin-name>",
+		Short: "Update the option values of a Plugin",
+		Long: `Updates the option values of a Plugin. Values given with --set and --set-secret replace the current values, values given with --unset are removed.
+Use 'greenhousectl plugin diff' with the same flags to review the changes to the deployed resources before.`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			plugin, err := upgradePlugin(cmd.Context(), k8sClient, o.orgName, args[0], &o.optionValues, o.unset, o.dryRun)
+			if err != nil {
+				return err
+			}
+			if o.dryRun {
+				cmd.Printf("plugin %s/%s is valid\n", plugin.GetNamespace(), plugin.GetName())
+				return nil
+			}
+			cmd.Printf("plugin %s/%s upgraded\n", plugin.GetNamespace(), plugin.GetName())
+			return nil
+		},
+	}
+
+	upgradeCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	upgradeCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	upgradeCmd.Flags().StringArrayVar(&o.unset, "unset", nil, "Remove an option value, the default of the option is used instead")
+	upgradeCmd.Flags().BoolVar(&o.dryRun, "dry-run", false, "Only validate the updated Plugin without persisting it")
+	o.optionValues.addFlags(upgradeCmd.Flags())
+	if err := upgradeCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return upgradeCmd
+}
+
+// getPluginWithOptionValues returns the Plugin with the option values of the flags applied, without persisting it.
+func getPluginWithOptionValues(ctx context.Context, k8sClient client.Client, namespace, name string, flags *pluginOptionValueFlags, unset []string) (*greenhousev1alpha1.Plugin, *greenhousev1alpha1.PluginDefinition, error) {
+	var plugin = new(greenhousev1alpha1.Plugin)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, plugin); err != nil {
+		return nil, nil, fmt.Errorf("failed to get plugin %s/%s: %w", namespace, name, err)
+	}
+	var pluginDefinition = new(greenhousev1alpha1.PluginDefinition)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: plugin.Spec.PluginDefinition}, pluginDefinition); err != nil {
+		return nil, nil, fmt.Errorf("failed to get plugin definition %s: %w", plugin.Spec.PluginDefinition, err)
+	}
+	updates, err := flags.parse(pluginDefinition)
+	if err != nil {
+		return nil, nil, err
+	}
+	plugin.Spec.OptionValues, err = mergePluginOptionValues(plugin.Spec.OptionValues, updates, unset)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plugin, pluginDefinition, nil
+}
+
+// upgradePlugin updates the option values of the Plugin. The update is validated by the admission webhooks without being persisted if dryRun is set.
+func upgradePlugin(ctx context.Context, k8sClient client.Client, namespace, name string, flags *pluginOptionValueFlags, unset []string, dryRun bool) (*greenhousev1alpha1.Plugin, error) {
+	plugin, _, err := getPluginWithOptionValues(ctx, k8sClient, namespace, name, flags, unset)
+	if err != nil {
+		return nil, err
+	}
+	if presetName, ok := plugin.GetLabels()[greenhouseapis.LabelKeyPluginPreset]; ok {
+		return nil, fmt.Errorf("plugin %s/%s is managed by the plugin preset %s, update the preset instead", namespace, name, presetName)
+	}
+	var opts []client.UpdateOption
+	if dryRun {
+		opts = append(opts, client.DryRunAll)
+	}
+	if err := k8sClient.Update(ctx, plugin, opts...); err != nil {
+		return nil, fmt.Errorf("failed to update plugin %s/%s: %w", namespace, name, err)
+	}
+	return plugin, nil
+}
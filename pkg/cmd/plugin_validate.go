@@ -4,159 +4,437 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
-	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
+	"github.com/cloudoperators/greenhouse/pkg/admission"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	plugincontrollers "github.com/cloudoperators/greenhouse/pkg/controllers/plugin"
 	"github.com/cloudoperators/greenhouse/pkg/helm"
 )
 
-var pluginDefinitionValidateCmdUsage = "validate [plugindefinition.yaml path] [plugin.yaml path]"
+var pluginDefinitionValidateCmdUsage = "validate <path>..."
+
+const (
+	validateOutputText  = "text"
+	validateOutputJSON  = "json"
+	validateOutputJUnit = "junit"
+)
 
 func init() {
 	pluginCmd.AddCommand(newPluginValidateCmd())
 }
 
 type pluginValidateOptions struct {
-	pathToPluginDefinition, pathToPlugin string
+	paths        []string
+	catalogPaths []string
+	kubecontext  string
+	output       string
+	skipTemplate bool
 }
 
 func newPluginValidateCmd() *cobra.Command {
 	o := &pluginValidateOptions{}
-	return &cobra.Command{
+	validateCmd := &cobra.Command{
 		Use:   pluginDefinitionValidateCmdUsage,
-		Short: "Validate a PluginDefinition",
+		Short: "Validate Plugins and PluginPresets against their PluginDefinitions",
+		Long: `Validates the Plugins and PluginPresets in the given files and directories against their PluginDefinitions.
+The option values are checked with the same rules as applied by Greenhouse on admission. The Helm chart of the PluginDefinition is rendered
+for each Plugin, and for each PluginPreset with and without the overrides of each cluster. Values referenced from secrets are replaced by placeholders.
+PluginDefinitions are read from the given paths and --catalog, otherwise they are fetched from the Greenhouse cluster.
+The command fails if any check fails. Use --output to write the results as JSON or JUnit for CI systems.`,
+		Example: `  greenhousectl plugin validate plugins/ --catalog ../greenhouse-extensions --output junit > report.xml
+  greenhousectl plugin validate plugindefinition.yaml plugin.yaml`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := o.validate(args); err != nil {
+			o.paths = args
+			if !slices.Contains([]string{validateOutputText, validateOutputJSON, validateOutputJUnit}, o.output) {
+				return fmt.Errorf("unsupported output %q", o.output)
+			}
+			return o.run(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+	validateCmd.Flags().StringSliceVar(&o.catalogPaths, "catalog", nil, "Files or directories containing PluginDefinitions. If not set, PluginDefinitions are fetched from the Greenhouse cluster")
+	validateCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster to fetch PluginDefinitions from (defaults to current-context)")
+	validateCmd.Flags().StringVarP(&o.output, "output", "o", validateOutputText, "The output format, one of text, json, junit")
+	validateCmd.Flags().BoolVar(&o.skipTemplate, "skip-template", false, "Only validate the option values without rendering the Helm charts")
+	return validateCmd
+}
+
+func (o *pluginValidateOptions) run(ctx context.Context, out io.Writer) error {
+	objects, err := loadGreenhouseObjects(o.paths)
+	if err != nil {
+		return err
+	}
+	catalog, err := loadGreenhouseObjects(o.catalogPaths)
+	if err != nil {
+		return err
+	}
+	definitions := newPluginDefinitionSource(append(catalog.pluginDefinitions, objects.pluginDefinitions...))
+	if len(o.catalogPaths) == 0 {
+		// The client is only created if a PluginDefinition is missing, validating with a complete catalog works without a cluster.
+		var k8sClient client.Client
+		definitions.fetch = func(ctx context.Context, name string) (*greenhousev1alpha1.PluginDefinition, error) {
+			if k8sClient == nil {
+				restConfig, err := config.GetConfigWithContext(o.kubecontext)
+				if err != nil {
+					return nil, err
+				}
+				if k8sClient, err = clientutil.NewK8sClient(restConfig); err != nil {
+					return nil, err
+				}
+			}
+			var pluginDefinition = new(greenhousev1alpha1.PluginDefinition)
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: name}, pluginDefinition); err != nil {
+				return nil, err
+			}
+			return pluginDefinition, nil
+		}
+	}
+
+	var templateFn templateFunc = helm.TemplateHelmChartFromPluginWithoutCluster
+	if o.skipTemplate {
+		templateFn = nil
+	}
+	results := validateGreenhouseObjects(ctx, objects, definitions, templateFn)
+	if err := writeValidationResults(out, o.output, results); err != nil {
+		return err
+	}
+	if failed := countFailedResults(results); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// greenhouseObject is a Greenhouse resource loaded from a file.
+type greenhouseObject[T client.Object] struct {
+	file   string
+	object T
+}
+
+type greenhouseObjects struct {
+	pluginDefinitions []greenhouseObject[*greenhousev1alpha1.PluginDefinition]
+	plugins           []greenhouseObject[*greenhousev1alpha1.Plugin]
+	pluginPresets     []greenhouseObject[*greenhousev1alpha1.PluginPreset]
+}
+
+// loadGreenhouseObjects reads the PluginDefinitions, Plugins and PluginPresets from the YAML files in the given paths.
+// Directories are walked recursively, other resources are ignored.
+func loadGreenhouseObjects(paths []string) (*greenhouseObjects, error) {
+	objects := &greenhouseObjects{}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
 				return err
 			}
-			if err := o.complete(args); err != nil {
+			if d.IsDir() || (file != path && !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(file))) {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
 				return err
 			}
-			return o.run()
-		},
+			return objects.decode(file, data)
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
+	return objects, nil
 }
 
-func (o *pluginValidateOptions) validate(args []string) error {
-	if len(args) != 2 {
-		return errors.New(pluginDefinitionValidateCmdUsage)
+func (o *greenhouseObjects) decode(file string, data []byte) error {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var obj map[string]any
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		if obj == nil || obj["apiVersion"] != greenhousev1alpha1.GroupVersion.String() {
+			continue
+		}
+		var err error
+		switch obj["kind"] {
+		case "PluginDefinition":
+			pluginDefinition := new(greenhousev1alpha1.PluginDefinition)
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, pluginDefinition)
+			o.pluginDefinitions = append(o.pluginDefinitions, greenhouseObject[*greenhousev1alpha1.PluginDefinition]{file, pluginDefinition})
+		case "Plugin":
+			plugin := new(greenhousev1alpha1.Plugin)
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, plugin)
+			o.plugins = append(o.plugins, greenhouseObject[*greenhousev1alpha1.Plugin]{file, plugin})
+		case greenhousev1alpha1.PluginPresetKind:
+			pluginPreset := new(greenhousev1alpha1.PluginPreset)
+			err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, pluginPreset)
+			o.pluginPresets = append(o.pluginPresets, greenhouseObject[*greenhousev1alpha1.PluginPreset]{file, pluginPreset})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode %s %s: %w", obj["kind"], file, err)
+		}
 	}
-	return nil
 }
 
-func (o *pluginValidateOptions) complete(args []string) error {
-	var err error
-	o.pathToPluginDefinition, err = filepath.Abs(args[0])
-	if err != nil {
-		return err
+// pluginDefinitionSource looks up PluginDefinitions by name, falling back to fetch if they were not loaded from files.
+type pluginDefinitionSource struct {
+	pluginDefinitions map[string]*greenhousev1alpha1.PluginDefinition
+	fetch             func(ctx context.Context, name string) (*greenhousev1alpha1.PluginDefinition, error)
+}
+
+func newPluginDefinitionSource(pluginDefinitions []greenhouseObject[*greenhousev1alpha1.PluginDefinition]) *pluginDefinitionSource {
+	s := &pluginDefinitionSource{pluginDefinitions: make(map[string]*greenhousev1alpha1.PluginDefinition, len(pluginDefinitions))}
+	for _, pluginDefinition := range pluginDefinitions {
+		s.pluginDefinitions[pluginDefinition.object.GetName()] = pluginDefinition.object
 	}
-	o.pathToPlugin, err = filepath.Abs(args[1])
-	return err
+	return s
 }
 
-func (o *pluginValidateOptions) run() error {
-	fmt.Printf("validating pluginDefinition %s with plugin %s\n", o.pathToPluginDefinition, o.pathToPlugin)
-	// Load both the PluginDefinition and Plugin from the provided files.
-	pluginDefinition, err := loadPluginDefinition(o.pathToPluginDefinition)
-	if err != nil {
-		return err
+func (s *pluginDefinitionSource) get(ctx context.Context, name string) (*greenhousev1alpha1.PluginDefinition, error) {
+	if pluginDefinition, ok := s.pluginDefinitions[name]; ok {
+		return pluginDefinition, nil
+	}
+	if s.fetch == nil {
+		return nil, fmt.Errorf("plugin definition %s not found in the catalog", name)
 	}
-	plugin, err := loadPlugin(o.pathToPlugin)
+	pluginDefinition, err := s.fetch(ctx, name)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to get plugin definition %s: %w", name, err)
 	}
+	s.pluginDefinitions[name] = pluginDefinition
+	return pluginDefinition, nil
+}
 
-	// Validate the Plugin against the PluginDefinition.
-	if err = validateOptions(pluginDefinition, plugin); err != nil {
-		return err
-	}
+// validationResult is the result of a single check of a resource.
+type validationResult struct {
+	File   string   `json:"file"`
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Check  string   `json:"check"`
+	Errors []string `json:"errors,omitempty"`
+}
 
-	// Start validation of Helm Chart
-	if err = validateHelmChart(pluginDefinition, plugin); err != nil {
-		return err
+func (r validationResult) failed() bool {
+	return len(r.Errors) > 0
+}
+
+func newValidationResult(file, kind string, obj client.Object, check string, errs ...error) validationResult {
+	name := obj.GetName()
+	if obj.GetNamespace() != "" {
+		name = obj.GetNamespace() + "/" + name
 	}
-	fmt.Printf("successfully validated pluginDefinition %s\n", pluginDefinition.GetName())
-	return nil
+	result := validationResult{File: file, Kind: kind, Name: name, Check: check}
+	for _, err := range errs {
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+	return result
 }
 
-// validateOptions validates that all required options are set and that the values are valid.
-func validateOptions(pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) error {
-	// Validate that all required options are set.
-	errList := []error{}
-	for _, option := range pluginDefinition.Spec.Options {
-		var isSet = false
-		for _, optionValue := range plugin.Spec.OptionValues {
-			if optionValue.Name == option.Name {
-				isSet = true
-				if err := option.IsValidValue(optionValue.Value); err != nil {
-					errList = append(errList, err)
-				}
+// templateFunc renders the Helm chart of the PluginDefinition for the Plugin.
+type templateFunc func(ctx context.Context, pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) (*release.Release, error)
+
+// validateGreenhouseObjects checks the loaded resources and returns a result per check.
+// The Helm charts are not rendered if template is nil.
+func validateGreenhouseObjects(ctx context.Context, objects *greenhouseObjects, definitions *pluginDefinitionSource, template templateFunc) []validationResult {
+	var results []validationResult
+	for _, pluginDefinition := range objects.pluginDefinitions {
+		var errs []error
+		for _, option := range pluginDefinition.object.Spec.Options {
+			if err := option.IsValid(); err != nil {
+				errs = append(errs, fmt.Errorf("option %s: %w", option.Name, err))
 			}
 		}
-		if option.Required && !isSet {
-			errList = append(errList, fmt.Errorf("required option %s not set", option.Name))
+		results = append(results, newValidationResult(pluginDefinition.file, "PluginDefinition", pluginDefinition.object, "options", errs...))
+	}
+
+	for _, plugin := range objects.plugins {
+		pluginDefinition, err := definitions.get(ctx, plugin.object.Spec.PluginDefinition)
+		if err != nil {
+			results = append(results, newValidationResult(plugin.file, "Plugin", plugin.object, "pluginDefinition", err))
+			continue
 		}
+		results = append(results, validatePlugin(ctx, plugin.file, "Plugin", plugin.object, plugin.object.DeepCopy(), pluginDefinition, "", template)...)
 	}
-	switch {
-	case len(errList) == 0:
-		return nil
-	default:
-		errString := ""
-		for _, err := range errList {
-			errString += err.Error() + "\n"
+
+	for _, pluginPreset := range objects.pluginPresets {
+		preset := pluginPreset.object
+		pluginDefinition, err := definitions.get(ctx, preset.Spec.Plugin.PluginDefinition)
+		if err != nil {
+			results = append(results, newValidationResult(pluginPreset.file, greenhousev1alpha1.PluginPresetKind, preset, "pluginDefinition", err))
+			continue
+		}
+		results = append(results, newValidationResult(pluginPreset.file, greenhousev1alpha1.PluginPresetKind, preset, "options",
+			admission.ValidatePluginPresetOptionValues(preset, pluginDefinition).ToAggregate()))
+		// Clusters without overrides get the Plugin as defined by the PluginPreset.
+		results = append(results, validatePlugin(ctx, pluginPreset.file, greenhousev1alpha1.PluginPresetKind, preset, pluginForPreset(preset, ""), pluginDefinition, "", template)...)
+		for _, override := range preset.Spec.ClusterOptionOverrides {
+			plugin := pluginForPreset(preset, override.ClusterName)
+			results = append(results, validatePlugin(ctx, pluginPreset.file, greenhousev1alpha1.PluginPresetKind, preset, plugin, pluginDefinition, override.ClusterName, template)...)
 		}
-		return fmt.Errorf("pluginDefinition %v and plugin %v are not compatible: %v", pluginDefinition.GetName(), plugin.GetName(), errString)
 	}
+	return results
 }
 
-func validateHelmChart(pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) error {
-	if pluginDefinition.Spec.HelmChart == nil {
-		return nil
+// validatePlugin checks the option values of the Plugin and renders the Helm chart.
+// The results are reported for obj, the resource the Plugin was loaded from or is created by.
+func validatePlugin(ctx context.Context, file, kind string, obj client.Object, plugin *greenhousev1alpha1.Plugin, pluginDefinition *greenhousev1alpha1.PluginDefinition, clusterName string, template templateFunc) []validationResult {
+	suffix := ""
+	if clusterName != "" {
+		suffix = fmt.Sprintf(" (cluster %s)", clusterName)
+	}
+	check := "options"
+	if kind == greenhousev1alpha1.PluginPresetKind {
+		// The Plugins created by a PluginPreset must set all required options, either by the PluginPreset or the overrides.
+		check = "required options"
+	}
+	results := []validationResult{newValidationResult(file, kind, obj, check+suffix, validateOptions(pluginDefinition, plugin))}
+	if template == nil || pluginDefinition.Spec.HelmChart == nil {
+		return results
 	}
+	if plugin.Spec.ReleaseNamespace == "" {
+		plugin.Spec.ReleaseNamespace = plugin.GetNamespace()
+	}
+	_, err := template(ctx, pluginDefinition, plugin)
+	return append(results, newValidationResult(file, kind, obj, "template"+suffix, err))
+}
 
-	restClientGetter := clientutil.NewRestClientGetterFromRestConfig(ctrl.GetConfigOrDie(), plugin.Namespace)
+// pluginForPreset returns the Plugin the PluginPreset creates for the cluster, with the option values overridden for this cluster.
+func pluginForPreset(preset *greenhousev1alpha1.PluginPreset, clusterName string) *greenhousev1alpha1.Plugin {
+	plugin := &greenhousev1alpha1.Plugin{}
+	plugin.SetNamespace(preset.GetNamespace())
+	plugin.SetName(preset.GetName())
+	plugin.Spec = *preset.Spec.Plugin.DeepCopy()
+	if clusterName == "" {
+		return plugin
+	}
+	plugin.SetName(plugincontrollers.GeneratePluginName(preset, clusterName))
+	plugin.Spec.ClusterName = clusterName
+	plugincontrollers.OverridesPluginOptionValues(plugin, preset)
+	return plugin
+}
 
-	local, err := clientutil.NewK8sClientFromRestClientGetter(restClientGetter)
+// validateOptions validates that all required options are set and that the values are valid.
+func validateOptions(pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) error {
+	if err := admission.ValidatePluginOptionValues(plugin, pluginDefinition).ToAggregate(); err != nil {
+		return fmt.Errorf("pluginDefinition %s and plugin %s are not compatible: %w", pluginDefinition.GetName(), plugin.GetName(), err)
+	}
+	return nil
+}
 
-	if err != nil {
-		return err
+func countFailedResults(results []validationResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.failed() {
+			failed++
+		}
 	}
+	return failed
+}
 
-	fmt.Printf("rendering helm chart %s\n", pluginDefinition.Spec.HelmChart.String())
-	_, err = helm.TemplateHelmChartFromPlugin(context.Background(), local, restClientGetter, pluginDefinition, plugin)
-	return err
+func writeValidationResults(out io.Writer, output string, results []validationResult) error {
+	switch output {
+	case validateOutputJSON:
+		if results == nil {
+			results = []validationResult{}
+		}
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case validateOutputJUnit:
+		return writeJUnitReport(out, results)
+	default:
+		for _, result := range results {
+			if !result.failed() {
+				fmt.Fprintf(out, "PASS %s %s: %s\n", result.Kind, result.Name, result.Check)
+				continue
+			}
+			fmt.Fprintf(out, "FAIL %s %s: %s\n", result.Kind, result.Name, result.Check)
+			for _, err := range result.Errors {
+				fmt.Fprintf(out, "  %s\n", err)
+			}
+		}
+		return nil
+	}
 }
 
-func loadPluginDefinition(path string) (*greenhousev1alpha1.PluginDefinition, error) {
-	var pluginDefinition *greenhousev1alpha1.PluginDefinition
-	err := loadAndUnmarshalObject(path, &pluginDefinition)
-	return pluginDefinition, err
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
 }
 
-func loadPlugin(path string) (*greenhousev1alpha1.Plugin, error) {
-	var plugin *greenhousev1alpha1.Plugin
-	err := loadAndUnmarshalObject(path, &plugin)
-	return plugin, err
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
 }
 
-func loadAndUnmarshalObject(path string, o interface{}) error {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the results as JUnit XML with a test suite per file.
+func writeJUnitReport(out io.Writer, results []validationResult) error {
+	report := junitTestSuites{Name: "greenhousectl plugin validate", Tests: len(results), Failures: countFailedResults(results)}
+	suites := make(map[string]int)
+	for _, result := range results {
+		idx, ok := suites[result.File]
+		if !ok {
+			idx = len(report.Suites)
+			suites[result.File] = idx
+			report.Suites = append(report.Suites, junitTestSuite{Name: result.File})
+		}
+		testCase := junitTestCase{
+			Name:      fmt.Sprintf("%s %s: %s", result.Kind, result.Name, result.Check),
+			ClassName: result.File,
+		}
+		if result.failed() {
+			testCase.Failure = &junitFailure{Message: result.Errors[0], Text: strings.Join(result.Errors, "\n")}
+			report.Suites[idx].Failures++
+		}
+		report.Suites[idx].Tests++
+		report.Suites[idx].TestCases = append(report.Suites[idx].TestCases, testCase)
+	}
+	if _, err := io.WriteString(out, xml.Header); err != nil {
 		return err
 	}
-	f, err := os.ReadFile(absPath)
-	if err != nil {
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
 		return err
 	}
-	err = yaml.Unmarshal(f, &o)
+	_, err := io.WriteString(out, "\n")
 	return err
 }
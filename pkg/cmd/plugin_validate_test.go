@@ -4,8 +4,16 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/release"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
@@ -125,3 +133,148 @@ var _ = Describe("Validate PluginDefinition against Plugin ", func() {
 		})
 	})
 })
+
+var _ = Describe("Validate Plugins and PluginPresets", func() {
+	const manifests = `apiVersion: greenhouse.sap/v1alpha1
+kind: PluginDefinition
+metadata:
+  name: test-plugin
+spec:
+  version: 1.0.0
+  helmChart:
+    name: test-chart
+    repository: oci://registry/charts
+    version: 1.0.0
+  options:
+  - name: region
+    type: string
+    required: true
+  - name: replicas
+    type: int
+    default: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+---
+apiVersion: greenhouse.sap/v1alpha1
+kind: Plugin
+metadata:
+  name: test-plugin
+  namespace: test-org
+spec:
+  pluginDefinition: test-plugin
+  optionValues:
+  - name: region
+    value: eu-de-1
+---
+apiVersion: greenhouse.sap/v1alpha1
+kind: PluginPreset
+metadata:
+  name: test-preset
+  namespace: test-org
+spec:
+  clusterSelector: {}
+  plugin:
+    pluginDefinition: test-plugin
+    optionValues:
+    - name: replicas
+      value: 2
+  clusterOptionOverrides:
+  - clusterName: cluster-a
+    overrides:
+    - name: region
+      value: eu-de-1
+    - name: replicas
+      value: 3
+  - clusterName: cluster-b
+    overrides:
+    - name: replicas
+      value: "three"
+`
+
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "nested"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "nested", "plugins.yaml"), []byte(manifests), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o600)).To(Succeed())
+	})
+
+	It("should load the Greenhouse resources from all YAML documents in a directory", func() {
+		objects, err := loadGreenhouseObjects([]string{dir})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects.pluginDefinitions).To(HaveLen(1))
+		Expect(objects.plugins).To(HaveLen(1))
+		Expect(objects.pluginPresets).To(HaveLen(1))
+		Expect(objects.plugins[0].file).To(Equal(filepath.Join(dir, "nested", "plugins.yaml")))
+		Expect(objects.pluginPresets[0].object.Spec.ClusterOptionOverrides).To(HaveLen(2))
+	})
+
+	It("should merge the cluster overrides into the Plugin of a PluginPreset", func() {
+		objects, err := loadGreenhouseObjects([]string{dir})
+		Expect(err).NotTo(HaveOccurred())
+		plugin := pluginForPreset(objects.pluginPresets[0].object, "cluster-a")
+		Expect(plugin.Name).To(Equal("test-preset-cluster-a"))
+		Expect(plugin.Spec.ClusterName).To(Equal("cluster-a"))
+		Expect(plugin.Spec.OptionValues).To(ConsistOf(
+			greenhousev1alpha1.PluginOptionValue{Name: "replicas", Value: test.MustReturnJSONFor(3)},
+			greenhousev1alpha1.PluginOptionValue{Name: "region", Value: test.MustReturnJSONFor("eu-de-1")},
+		))
+		Expect(objects.pluginPresets[0].object.Spec.Plugin.OptionValues).To(HaveLen(1), "the PluginPreset must not be modified")
+	})
+
+	It("should report the results of all checks", func() {
+		objects, err := loadGreenhouseObjects([]string{dir})
+		Expect(err).NotTo(HaveOccurred())
+		var rendered []string
+		template := func(_ context.Context, _ *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) (*release.Release, error) {
+			rendered = append(rendered, plugin.Spec.ReleaseNamespace+"/"+plugin.Name)
+			return &release.Release{}, nil
+		}
+		results := validateGreenhouseObjects(context.Background(), objects, newPluginDefinitionSource(objects.pluginDefinitions), template)
+		Expect(rendered).To(ConsistOf("test-org/test-plugin", "test-org/test-preset", "test-org/test-preset-cluster-a", "test-org/test-preset-cluster-b"))
+
+		failed := make([]string, 0)
+		for _, result := range results {
+			if result.failed() {
+				failed = append(failed, result.Check)
+			}
+		}
+		// The PluginPreset sets no region, only cluster-a overrides it. The override for cluster-b has the wrong type.
+		Expect(failed).To(ConsistOf("options", "required options", "required options (cluster cluster-b)"))
+		Expect(countFailedResults(results)).To(Equal(3))
+	})
+
+	It("should report missing PluginDefinitions", func() {
+		objects, err := loadGreenhouseObjects([]string{dir})
+		Expect(err).NotTo(HaveOccurred())
+		results := validateGreenhouseObjects(context.Background(), objects, newPluginDefinitionSource(nil), nil)
+		Expect(results).To(HaveLen(3))
+		Expect(results[1].Check).To(Equal("pluginDefinition"))
+		Expect(results[1].Errors).To(ConsistOf(ContainSubstring("plugin definition test-plugin not found")))
+	})
+
+	It("should write the results as JSON and JUnit", func() {
+		results := []validationResult{
+			{File: "plugins.yaml", Kind: "Plugin", Name: "test-org/test-plugin", Check: "options"},
+			{File: "plugins.yaml", Kind: "Plugin", Name: "test-org/test-plugin", Check: "template", Errors: []string{"chart not found"}},
+		}
+		buf := new(bytes.Buffer)
+		Expect(writeValidationResults(buf, validateOutputJSON, results)).To(Succeed())
+		var decoded []validationResult
+		Expect(json.Unmarshal(buf.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded).To(Equal(results))
+
+		buf.Reset()
+		Expect(writeValidationResults(buf, validateOutputJUnit, results)).To(Succeed())
+		var report junitTestSuites
+		Expect(xml.Unmarshal(buf.Bytes(), &report)).To(Succeed())
+		Expect(report.Tests).To(Equal(2))
+		Expect(report.Failures).To(Equal(1))
+		Expect(report.Suites).To(HaveLen(1))
+		Expect(report.Suites[0].TestCases[1].Name).To(Equal("Plugin test-org/test-plugin: template"))
+		Expect(report.Suites[0].TestCases[1].Failure.Message).To(Equal("chart not found"))
+	})
+})
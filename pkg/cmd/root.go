@@ -22,6 +22,7 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	registerCompletions(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
@@ -0,0 +1,631 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/yaml"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+)
+
+type supportBundleOptions struct {
+	kubecontext string
+	orgName     string
+	pluginName  string
+	clusterName string
+	output      string
+	tailLines   int64
+}
+
+func init() {
+	rootCmd.AddCommand(newSupportBundleCmd())
+}
+
+func newSupportBundleCmd() *cobra.Command {
+	o := &supportBundleOptions{}
+	supportBundleCmd := &cobra.Command{
+		Use:   "support-bundle",
+		Short: "Collect the state of a Plugin or Cluster into a tarball",
+		Long: `Collects the data needed to troubleshoot a Plugin or a Cluster into a tarball to attach to tickets.
+The bundle contains the Greenhouse resources, the history, values and manifest of the Helm release, the state of the deployed resources,
+the events in the release namespace and the logs of failing pods.
+The data of Secrets and the values of secret options are masked.
+With --cluster all Plugins deployed to the cluster are collected. Together with --plugin, the Plugin or the Plugin of the PluginPreset on the cluster is collected.
+Access to the kubeconfig Secret of the cluster in the organization is required to collect the data from the cluster.`,
+		Example: `  # Collect the data of a Plugin
+  greenhousectl support-bundle --org my-org --plugin my-plugin
+
+  # Collect the data of all Plugins on a cluster
+  greenhousectl support-bundle --org my-org --cluster my-cluster -o bundle.tar.gz`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			bundleName := o.bundleName(now)
+			if o.output == "" {
+				o.output = bundleName + ".tar.gz"
+			}
+			g := &supportBundleGatherer{
+				k8sClient: k8sClient,
+				orgName:   o.orgName,
+				tailLines: o.tailLines,
+				remoteForPlugin: func(ctx context.Context, plugin *greenhousev1alpha1.Plugin) (*supportBundleRemote, error) {
+					restClientGetter, err := restClientGetterForPlugin(ctx, k8sClient, restConfig, plugin)
+					if err != nil {
+						return nil, err
+					}
+					return newSupportBundleRemote(restClientGetter)
+				},
+			}
+			f, err := os.Create(o.output)
+			if err != nil {
+				return err
+			}
+			err = g.writeBundle(cmd.Context(), f, bundleName, o.pluginName, o.clusterName, now)
+			err = errors.Join(err, f.Close())
+			if err != nil {
+				return errors.Join(err, os.Remove(o.output))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Support bundle written to %s\n", o.output)
+			return nil
+		},
+	}
+
+	supportBundleCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	supportBundleCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	supportBundleCmd.Flags().StringVar(&o.pluginName, "plugin", "", "The name of the Plugin, or of the PluginPreset together with --cluster")
+	supportBundleCmd.Flags().StringVar(&o.clusterName, "cluster", "", "The name of the Cluster")
+	supportBundleCmd.Flags().StringVarP(&o.output, "output", "o", "", "The path of the tarball (defaults to support-bundle-<name>-<timestamp>.tar.gz)")
+	supportBundleCmd.Flags().Int64Var(&o.tailLines, "tail-lines", 1000, "The number of lines of the logs to collect from each container of failing pods")
+	supportBundleCmd.MarkFlagsOneRequired("plugin", "cluster")
+	if err := supportBundleCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return supportBundleCmd
+}
+
+func (o *supportBundleOptions) bundleName(now time.Time) string {
+	names := []string{"support-bundle"}
+	if o.clusterName != "" {
+		names = append(names, o.clusterName)
+	}
+	if o.pluginName != "" {
+		names = append(names, o.pluginName)
+	}
+	return strings.Join(append(names, now.Format("20060102-150405")), "-")
+}
+
+// supportBundleRemote holds the clients for the cluster a Plugin is deployed to.
+type supportBundleRemote struct {
+	restClientGetter genericclioptions.RESTClientGetter
+	client           client.Client
+	clientset        kubernetes.Interface
+}
+
+func newSupportBundleRemote(restClientGetter genericclioptions.RESTClientGetter) (*supportBundleRemote, error) {
+	restConfig, err := restClientGetter.ToRESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	remoteClient, err := clientutil.NewK8sClient(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &supportBundleRemote{restClientGetter: restClientGetter, client: remoteClient, clientset: clientset}, nil
+}
+
+// supportBundle writes files to a gzipped tarball below a directory named after the bundle.
+// Errors collecting data do not abort the bundle, they are written to errors.txt instead.
+type supportBundle struct {
+	gz      *gzip.Writer
+	tw      *tar.Writer
+	dir     string
+	modTime time.Time
+	errs    []string
+	// err is the first error writing the tarball.
+	err error
+}
+
+func newSupportBundle(out io.Writer, dir string, modTime time.Time) *supportBundle {
+	gz := gzip.NewWriter(out)
+	return &supportBundle{gz: gz, tw: tar.NewWriter(gz), dir: dir, modTime: modTime}
+}
+
+func (b *supportBundle) addFile(name string, data []byte) {
+	if b.err != nil {
+		return
+	}
+	header := &tar.Header{
+		Name:     path.Join(b.dir, name),
+		Mode:     0o644,
+		Size:     int64(len(data)),
+		ModTime:  b.modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := b.tw.WriteHeader(header); err != nil {
+		b.err = err
+		return
+	}
+	_, b.err = b.tw.Write(data)
+}
+
+// addObjects writes the objects as YAML without their managed fields and with the data of Secrets masked.
+func (b *supportBundle) addObjects(name string, objects ...client.Object) {
+	masked := make([]client.Object, 0, len(objects))
+	for _, obj := range objects {
+		obj, ok := obj.DeepCopyObject().(client.Object)
+		if !ok {
+			continue
+		}
+		if gvk, err := apiutil.GVKForObject(obj, clientutil.Scheme); err == nil {
+			obj.GetObjectKind().SetGroupVersionKind(gvk)
+		}
+		obj.SetManagedFields(nil)
+		maskedObj, err := helm.MaskSecret(obj)
+		if err != nil {
+			b.addError("failed to mask %s %s: %s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+			continue
+		}
+		if maskedObj, ok := maskedObj.(client.Object); ok {
+			masked = append(masked, maskedObj)
+		}
+	}
+	var buf bytes.Buffer
+	if err := writeObjectsAsYAML(&buf, masked); err != nil {
+		b.addError("failed to write %s: %s", name, err)
+		return
+	}
+	b.addFile(name, buf.Bytes())
+}
+
+func (b *supportBundle) addError(format string, args ...any) {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
+
+func (b *supportBundle) close() error {
+	if len(b.errs) > 0 {
+		b.addFile("errors.txt", []byte(strings.Join(b.errs, "\n")+"\n"))
+	}
+	return errors.Join(b.err, b.tw.Close(), b.gz.Close())
+}
+
+// supportBundleGatherer collects the data of Plugins from Greenhouse and the clusters they are deployed to.
+type supportBundleGatherer struct {
+	k8sClient       client.Client
+	orgName         string
+	tailLines       int64
+	remoteForPlugin func(ctx context.Context, plugin *greenhousev1alpha1.Plugin) (*supportBundleRemote, error)
+}
+
+// writeBundle writes the support bundle for the Plugin, the Cluster or the Plugin on the Cluster to out.
+func (g *supportBundleGatherer) writeBundle(ctx context.Context, out io.Writer, bundleName, pluginName, clusterName string, now time.Time) error {
+	var objects []client.Object
+	if clusterName != "" {
+		var cluster = new(greenhousev1alpha1.Cluster)
+		if err := g.k8sClient.Get(ctx, client.ObjectKey{Namespace: g.orgName, Name: clusterName}, cluster); err != nil {
+			return fmt.Errorf("failed to get cluster %s/%s: %w", g.orgName, clusterName, err)
+		}
+		objects = append(objects, cluster)
+	}
+	plugins, err := g.selectPlugins(ctx, pluginName, clusterName)
+	if err != nil {
+		return err
+	}
+
+	b := newSupportBundle(out, bundleName, now)
+	for _, plugin := range plugins {
+		objects = append(objects, g.relatedObjects(ctx, b, plugin)...)
+	}
+	b.addObjects("greenhouse.yaml", uniqueObjects(objects)...)
+	for _, plugin := range plugins {
+		g.gatherPlugin(ctx, b, plugin)
+	}
+	return b.close()
+}
+
+// selectPlugins returns the Plugin with the given name or the Plugins on the given cluster.
+// If both are given, the Plugin created on the cluster by a PluginPreset with the name is selected as well.
+func (g *supportBundleGatherer) selectPlugins(ctx context.Context, pluginName, clusterName string) ([]*greenhousev1alpha1.Plugin, error) {
+	if clusterName == "" {
+		var plugin = new(greenhousev1alpha1.Plugin)
+		if err := g.k8sClient.Get(ctx, client.ObjectKey{Namespace: g.orgName, Name: pluginName}, plugin); err != nil {
+			return nil, fmt.Errorf("failed to get plugin %s/%s: %w", g.orgName, pluginName, err)
+		}
+		return []*greenhousev1alpha1.Plugin{plugin}, nil
+	}
+
+	var pluginList = new(greenhousev1alpha1.PluginList)
+	if err := g.k8sClient.List(ctx, pluginList, client.InNamespace(g.orgName)); err != nil {
+		return nil, fmt.Errorf("failed to list plugins of organization %s: %w", g.orgName, err)
+	}
+	var plugins []*greenhousev1alpha1.Plugin
+	for i := range pluginList.Items {
+		plugin := &pluginList.Items[i]
+		if plugin.Spec.ClusterName != clusterName {
+			continue
+		}
+		if pluginName != "" && plugin.GetName() != pluginName && plugin.GetLabels()[greenhouseapis.LabelKeyPluginPreset] != pluginName {
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+	if pluginName != "" && len(plugins) == 0 {
+		return nil, fmt.Errorf("no plugin %s found on cluster %s", pluginName, clusterName)
+	}
+	slices.SortFunc(plugins, func(a, b *greenhousev1alpha1.Plugin) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return plugins, nil
+}
+
+// relatedObjects returns the Plugin together with its PluginDefinition, PluginPreset and Cluster.
+func (g *supportBundleGatherer) relatedObjects(ctx context.Context, b *supportBundle, plugin *greenhousev1alpha1.Plugin) []client.Object {
+	objects := []client.Object{plugin}
+	var pluginDefinition = new(greenhousev1alpha1.PluginDefinition)
+	if err := g.k8sClient.Get(ctx, client.ObjectKey{Name: plugin.Spec.PluginDefinition}, pluginDefinition); err != nil {
+		b.addError("failed to get pluginDefinition %s: %s", plugin.Spec.PluginDefinition, err)
+	} else {
+		objects = append(objects, pluginDefinition)
+	}
+	if presetName, ok := plugin.GetLabels()[greenhouseapis.LabelKeyPluginPreset]; ok {
+		var pluginPreset = new(greenhousev1alpha1.PluginPreset)
+		if err := g.k8sClient.Get(ctx, client.ObjectKey{Namespace: plugin.GetNamespace(), Name: presetName}, pluginPreset); err != nil {
+			b.addError("failed to get pluginPreset %s/%s: %s", plugin.GetNamespace(), presetName, err)
+		} else {
+			objects = append(objects, pluginPreset)
+		}
+	}
+	if plugin.Spec.ClusterName != "" {
+		var cluster = new(greenhousev1alpha1.Cluster)
+		if err := g.k8sClient.Get(ctx, client.ObjectKey{Namespace: plugin.GetNamespace(), Name: plugin.Spec.ClusterName}, cluster); err != nil {
+			b.addError("failed to get cluster %s/%s: %s", plugin.GetNamespace(), plugin.Spec.ClusterName, err)
+		} else {
+			objects = append(objects, cluster)
+		}
+	}
+	return objects
+}
+
+// gatherPlugin collects the Helm release, the deployed resources, the events and the logs of failing pods of the Plugin.
+func (g *supportBundleGatherer) gatherPlugin(ctx context.Context, b *supportBundle, plugin *greenhousev1alpha1.Plugin) {
+	dir := path.Join("plugins", plugin.GetName())
+	remote, err := g.remoteForPlugin(ctx, plugin)
+	if err != nil {
+		b.addError("plugin %s: failed to access cluster %s: %s", plugin.GetName(), valueOrDash(plugin.Spec.ClusterName), err)
+		return
+	}
+
+	latest := g.gatherHelmRelease(b, dir, remote, plugin)
+	if latest != nil {
+		objects, err := helm.ObjectMapFromRelease(remote.restClientGetter, latest, nil)
+		if err != nil {
+			b.addError("plugin %s: failed to load the manifest of the Helm release: %s", plugin.GetName(), err)
+		}
+		keys := make([]helm.ObjectKey, 0, len(objects))
+		for key := range objects {
+			keys = append(keys, key)
+		}
+		liveObjects := g.gatherReleaseResources(ctx, b, dir, remote, keys)
+		g.gatherPodLogs(ctx, b, dir, remote, g.podsOfRelease(ctx, b, remote, liveObjects))
+	}
+	g.gatherEvents(ctx, b, dir, remote, plugin.Spec.ReleaseNamespace)
+}
+
+// gatherHelmRelease writes the history of the Helm release and the values and manifest of its latest revision.
+// It returns the latest revision or nil if there is no release.
+func (g *supportBundleGatherer) gatherHelmRelease(b *supportBundle, dir string, remote *supportBundleRemote, plugin *greenhousev1alpha1.Plugin) *release.Release {
+	history, err := helm.GetReleaseHistoryForPlugin(remote.restClientGetter, plugin)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			b.addError("plugin %s: no Helm release found in namespace %s", plugin.GetName(), plugin.Spec.ReleaseNamespace)
+		} else {
+			b.addError("plugin %s: failed to get the history of the Helm release: %s", plugin.GetName(), err)
+		}
+		return nil
+	}
+	if len(history) == 0 {
+		return nil
+	}
+	slices.SortFunc(history, func(a, b *release.Release) int {
+		return a.Version - b.Version
+	})
+
+	var buf bytes.Buffer
+	writeReleaseHistory(&buf, history)
+	b.addFile(path.Join(dir, "helm", "history.txt"), buf.Bytes())
+
+	latest := history[len(history)-1]
+	manifest, err := helm.MaskManifestSecrets(latest.Manifest)
+	if err != nil {
+		b.addError("plugin %s: failed to mask the manifest of the Helm release: %s", plugin.GetName(), err)
+	} else {
+		b.addFile(path.Join(dir, "helm", "manifest.yaml"), []byte(manifest))
+	}
+	values, err := yaml.Marshal(helm.MaskSecretOptionValues(latest.Config, plugin))
+	if err != nil {
+		b.addError("plugin %s: failed to marshal the values of the Helm release: %s", plugin.GetName(), err)
+	} else {
+		b.addFile(path.Join(dir, "helm", "values.yaml"), values)
+	}
+	return latest
+}
+
+func writeReleaseHistory(out io.Writer, history []*release.Release) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tUPDATED\tSTATUS\tCHART\tAPP VERSION\tDESCRIPTION")
+	for _, rel := range history {
+		var updated, status, description, chartName, appVersion = "-", "-", "-", "-", "-"
+		if rel.Info != nil {
+			updated = rel.Info.LastDeployed.UTC().Format(time.RFC3339)
+			status = rel.Info.Status.String()
+			description = valueOrDash(rel.Info.Description)
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			chartName = rel.Chart.Metadata.Name + "-" + rel.Chart.Metadata.Version
+			appVersion = valueOrDash(rel.Chart.Metadata.AppVersion)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", rel.Version, updated, status, chartName, appVersion, description)
+	}
+	_ = w.Flush()
+}
+
+// gatherReleaseResources writes the live state of the resources of the Helm release and an overview of their status.
+// It returns the resources found on the cluster.
+func (g *supportBundleGatherer) gatherReleaseResources(ctx context.Context, b *supportBundle, dir string, remote *supportBundleRemote, keys []helm.ObjectKey) []*unstructured.Unstructured {
+	slices.SortFunc(keys, func(a, b helm.ObjectKey) int {
+		return strings.Compare(a.GVK.Kind+"/"+a.Namespace+"/"+a.Name, b.GVK.Kind+"/"+b.Namespace+"/"+b.Name)
+	})
+	var liveObjects []*unstructured.Unstructured
+	var status bytes.Buffer
+	w := tabwriter.NewWriter(&status, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAMESPACE\tNAME\tSTATUS")
+	for _, key := range keys {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(key.GVK)
+		err := remote.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: key.Name}, obj)
+		switch {
+		case apierrors.IsNotFound(err):
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key.GVK.Kind, valueOrDash(key.Namespace), key.Name, "not found")
+			continue
+		case err != nil:
+			b.addError("failed to get %s %s/%s: %s", key.GVK.Kind, key.Namespace, key.Name, err)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", key.GVK.Kind, valueOrDash(key.Namespace), key.Name, resourceStatus(obj))
+		liveObjects = append(liveObjects, obj)
+	}
+	_ = w.Flush()
+	b.addFile(path.Join(dir, "remote", "status.txt"), status.Bytes())
+
+	objects := make([]client.Object, len(liveObjects))
+	for i, obj := range liveObjects {
+		objects[i] = obj
+	}
+	b.addObjects(path.Join(dir, "remote", "resources.yaml"), objects...)
+	return liveObjects
+}
+
+// resourceStatus summarizes the status of workloads and the Ready condition of other resources.
+func resourceStatus(obj *unstructured.Unstructured) string {
+	nestedInt := func(fields ...string) int64 {
+		value, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+		return value
+	}
+	switch obj.GetKind() {
+	case "Deployment", "StatefulSet", "ReplicaSet":
+		replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		return fmt.Sprintf("%d/%d ready", nestedInt("status", "readyReplicas"), replicas)
+	case "DaemonSet":
+		return fmt.Sprintf("%d/%d ready", nestedInt("status", "numberReady"), nestedInt("status", "desiredNumberScheduled"))
+	case "Job":
+		return fmt.Sprintf("%d succeeded, %d failed", nestedInt("status", "succeeded"), nestedInt("status", "failed"))
+	case "Pod":
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		return valueOrDash(phase)
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if ok && condition["type"] == "Ready" {
+			return fmt.Sprintf("Ready=%v", condition["status"])
+		}
+	}
+	return "-"
+}
+
+// podsOfRelease returns the pods of the resources of the Helm release, either deployed directly or selected by workloads.
+func (g *supportBundleGatherer) podsOfRelease(ctx context.Context, b *supportBundle, remote *supportBundleRemote, liveObjects []*unstructured.Unstructured) []corev1.Pod {
+	var pods []corev1.Pod
+	seen := make(map[client.ObjectKey]bool)
+	addPod := func(pod corev1.Pod) {
+		if key := client.ObjectKeyFromObject(&pod); !seen[key] {
+			seen[key] = true
+			pods = append(pods, pod)
+		}
+	}
+	for _, obj := range liveObjects {
+		switch obj.GetKind() {
+		case "Pod":
+			var pod = new(corev1.Pod)
+			if err := remote.client.Get(ctx, client.ObjectKeyFromObject(obj), pod); err != nil {
+				b.addError("failed to get pod %s/%s: %s", obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			addPod(*pod)
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+			selectorMap, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+			if err != nil || !found {
+				continue
+			}
+			var labelSelector = new(metav1.LabelSelector)
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, labelSelector); err != nil {
+				b.addError("failed to read the selector of %s %s/%s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+			if err != nil {
+				b.addError("failed to read the selector of %s %s/%s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			var podList = new(corev1.PodList)
+			if err := remote.client.List(ctx, podList, client.InNamespace(obj.GetNamespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+				b.addError("failed to list pods of %s %s/%s: %s", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			for _, pod := range podList.Items {
+				addPod(pod)
+			}
+		}
+	}
+	return pods
+}
+
+// gatherPodLogs writes the logs of all containers of failing pods.
+// The logs of the previous instance are collected as well for restarted containers.
+func (g *supportBundleGatherer) gatherPodLogs(ctx context.Context, b *supportBundle, dir string, remote *supportBundleRemote, pods []corev1.Pod) {
+	for _, pod := range pods {
+		if !isPodFailing(&pod) {
+			continue
+		}
+		for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			logFile := path.Join(dir, "remote", "logs", pod.GetNamespace(), pod.GetName(), status.Name)
+			g.gatherContainerLogs(ctx, b, logFile+".log", remote, &pod, status.Name, false)
+			if status.RestartCount > 0 {
+				g.gatherContainerLogs(ctx, b, logFile+".previous.log", remote, &pod, status.Name, true)
+			}
+		}
+	}
+}
+
+func (g *supportBundleGatherer) gatherContainerLogs(ctx context.Context, b *supportBundle, name string, remote *supportBundleRemote, pod *corev1.Pod, container string, previous bool) {
+	logOptions := &corev1.PodLogOptions{Container: container, Previous: previous}
+	if g.tailLines > 0 {
+		logOptions.TailLines = &g.tailLines
+	}
+	logs, err := remote.clientset.CoreV1().Pods(pod.GetNamespace()).GetLogs(pod.GetName(), logOptions).DoRaw(ctx)
+	if err != nil {
+		b.addError("failed to get logs of container %s of pod %s/%s: %s", container, pod.GetNamespace(), pod.GetName(), err)
+		return
+	}
+	b.addFile(name, logs)
+}
+
+// isPodFailing returns true if the pod failed, or it is running but not ready or has restarted containers.
+func isPodFailing(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return false
+	case corev1.PodFailed:
+		return true
+	}
+	for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+		if status.RestartCount > 0 {
+			return true
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status != corev1.ConditionTrue
+		}
+	}
+	return true
+}
+
+// gatherEvents writes the events in the release namespace ordered by the time they were last seen.
+func (g *supportBundleGatherer) gatherEvents(ctx context.Context, b *supportBundle, dir string, remote *supportBundleRemote, namespace string) {
+	var eventList = new(corev1.EventList)
+	if err := remote.client.List(ctx, eventList, client.InNamespace(namespace)); err != nil {
+		b.addError("failed to list events in namespace %s: %s", namespace, err)
+		return
+	}
+	events := eventList.Items
+	slices.SortStableFunc(events, func(a, b corev1.Event) int {
+		return eventTime(&a).Compare(eventTime(&b))
+	})
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, event := range events {
+		object := strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", eventTime(&event).UTC().Format(time.RFC3339), event.Type, event.Reason, object, event.Count, strings.TrimSpace(event.Message))
+	}
+	_ = w.Flush()
+	b.addFile(path.Join(dir, "remote", "events.txt"), buf.Bytes())
+}
+
+// eventTime returns the time an event was last seen, falling back to the time it was created.
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// uniqueObjects removes duplicates of objects, e.g. the Cluster shared by Plugins.
+func uniqueObjects(objects []client.Object) []client.Object {
+	type objectKey struct {
+		kind string
+		key  client.ObjectKey
+	}
+	seen := make(map[objectKey]bool, len(objects))
+	unique := make([]client.Object, 0, len(objects))
+	for _, obj := range objects {
+		key := objectKey{fmt.Sprintf("%T", obj), client.ObjectKeyFromObject(obj)}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, obj)
+	}
+	return unique
+}
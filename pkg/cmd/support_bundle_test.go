@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+)
+
+// readSupportBundle returns the content of the files in the tarball by their path.
+func readSupportBundle(data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	Expect(err).NotTo(HaveOccurred(), "the bundle should be gzipped")
+	tr := tar.NewReader(gz)
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		Expect(err).NotTo(HaveOccurred(), "the bundle should be a tarball")
+		content, err := io.ReadAll(tr)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error reading %s", header.Name)
+		files[header.Name] = string(content)
+	}
+}
+
+var _ = Describe("Collect support bundles", func() {
+	var (
+		now       = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		k8sClient client.Client
+		g         *supportBundleGatherer
+	)
+
+	BeforeEach(func() {
+		cluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "cluster-a"}}
+		pluginDefinition := &greenhousev1alpha1.PluginDefinition{ObjectMeta: metav1.ObjectMeta{Name: "test-definition"}}
+		pluginPreset := &greenhousev1alpha1.PluginPreset{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-preset"}}
+		presetPlugin := &greenhousev1alpha1.Plugin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-preset-cluster-a", Labels: map[string]string{greenhouseapis.LabelKeyPluginPreset: "test-preset"}},
+			Spec:       greenhousev1alpha1.PluginSpec{PluginDefinition: "test-definition", ClusterName: "cluster-a", ReleaseNamespace: "test-org"},
+		}
+		plugin := &greenhousev1alpha1.Plugin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-plugin"},
+			Spec:       greenhousev1alpha1.PluginSpec{PluginDefinition: "test-definition", ClusterName: "cluster-a", ReleaseNamespace: "test-org"},
+		}
+		otherPlugin := &greenhousev1alpha1.Plugin{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "other-plugin"},
+			Spec:       greenhousev1alpha1.PluginSpec{PluginDefinition: "test-definition", ClusterName: "cluster-b", ReleaseNamespace: "test-org"},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(cluster, pluginDefinition, pluginPreset, presetPlugin, plugin, otherPlugin).Build()
+		g = &supportBundleGatherer{
+			k8sClient: k8sClient,
+			orgName:   "test-org",
+			remoteForPlugin: func(_ context.Context, plugin *greenhousev1alpha1.Plugin) (*supportBundleRemote, error) {
+				return nil, errors.New("kubeconfig secret not found")
+			},
+		}
+	})
+
+	It("should select the Plugin by name or by its PluginPreset on the cluster", func() {
+		plugins, err := g.selectPlugins(context.Background(), "test-plugin", "")
+		Expect(err).NotTo(HaveOccurred(), "there should be no error selecting the plugin")
+		Expect(plugins).To(HaveLen(1))
+		Expect(plugins[0].GetName()).To(Equal("test-plugin"))
+
+		plugins, err = g.selectPlugins(context.Background(), "test-preset", "cluster-a")
+		Expect(err).NotTo(HaveOccurred(), "there should be no error selecting the plugin of the preset")
+		Expect(plugins).To(HaveLen(1))
+		Expect(plugins[0].GetName()).To(Equal("test-preset-cluster-a"))
+
+		plugins, err = g.selectPlugins(context.Background(), "", "cluster-a")
+		Expect(err).NotTo(HaveOccurred(), "there should be no error selecting the plugins of the cluster")
+		Expect(plugins).To(HaveLen(2))
+
+		_, err = g.selectPlugins(context.Background(), "test-preset", "cluster-b")
+		Expect(err).To(MatchError(ContainSubstring("no plugin test-preset found on cluster cluster-b")))
+	})
+
+	It("should write the Greenhouse resources and the errors collecting data from the cluster", func() {
+		var out bytes.Buffer
+		Expect(g.writeBundle(context.Background(), &out, "bundle", "", "cluster-a", now)).To(Succeed())
+		files := readSupportBundle(out.Bytes())
+		Expect(files).To(HaveKey("bundle/greenhouse.yaml"))
+		Expect(files["bundle/greenhouse.yaml"]).To(ContainSubstring("kind: PluginPreset"))
+		Expect(files["bundle/greenhouse.yaml"]).To(ContainSubstring("kind: PluginDefinition"))
+		Expect(files["bundle/greenhouse.yaml"]).To(ContainSubstring("name: test-plugin\n"))
+		Expect(files["bundle/greenhouse.yaml"]).NotTo(ContainSubstring("other-plugin"))
+		Expect(bytes.Count([]byte(files["bundle/greenhouse.yaml"]), []byte("kind: Cluster\n"))).To(Equal(1), "the cluster should be written once")
+		Expect(files["bundle/errors.txt"]).To(ContainSubstring("plugin test-plugin: failed to access cluster cluster-a: kubeconfig secret not found"))
+	})
+
+	It("should fail without writing a bundle if the Plugin does not exist", func() {
+		var out bytes.Buffer
+		Expect(g.writeBundle(context.Background(), &out, "bundle", "missing", "", now)).To(MatchError(ContainSubstring("failed to get plugin test-org/missing")))
+		Expect(out.Len()).To(BeZero())
+	})
+
+	It("should collect the resources, events and logs of failing pods from the cluster", func() {
+		labels := map[string]string{"app": "test"}
+		deployment := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-deployment"},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](2), Selector: &metav1.LabelSelector{MatchLabels: labels}},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-secret"},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		}
+		readyPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-ready", Labels: labels},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", Ready: true}},
+			},
+		}
+		crashingPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-crashing", Labels: labels},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: 3}},
+			},
+		}
+		event := &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "test-org", Name: "test-crashing.1"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "test-crashing"},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container app",
+			Count:          5,
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+		}
+		remote := &supportBundleRemote{
+			client:    fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(deployment, secret, readyPod, crashingPod, event).Build(),
+			clientset: kubefake.NewClientset(),
+		}
+		keys := []helm.ObjectKey{
+			{GVK: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, Namespace: "test-org", Name: "test-deployment"},
+			{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, Namespace: "test-org", Name: "test-secret"},
+			{GVK: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, Namespace: "test-org", Name: "missing"},
+		}
+
+		var out bytes.Buffer
+		b := newSupportBundle(&out, "bundle", now)
+		liveObjects := g.gatherReleaseResources(context.Background(), b, "plugins/test-plugin", remote, keys)
+		Expect(liveObjects).To(HaveLen(2), "the missing ConfigMap should not be returned")
+		g.gatherPodLogs(context.Background(), b, "plugins/test-plugin", remote, g.podsOfRelease(context.Background(), b, remote, liveObjects))
+		g.gatherEvents(context.Background(), b, "plugins/test-plugin", remote, "test-org")
+		Expect(b.close()).To(Succeed())
+
+		files := readSupportBundle(out.Bytes())
+		Expect(files).NotTo(HaveKey("bundle/errors.txt"))
+		Expect(files["bundle/plugins/test-plugin/remote/status.txt"]).To(MatchRegexp(`Deployment\s+test-org\s+test-deployment\s+1/2 ready`))
+		Expect(files["bundle/plugins/test-plugin/remote/status.txt"]).To(MatchRegexp(`ConfigMap\s+test-org\s+missing\s+not found`))
+		Expect(files["bundle/plugins/test-plugin/remote/resources.yaml"]).To(ContainSubstring("password: '*****'"))
+		Expect(files["bundle/plugins/test-plugin/remote/resources.yaml"]).NotTo(ContainSubstring("czNjcjN0"), "the secret data should be masked")
+		Expect(files).To(HaveKeyWithValue("bundle/plugins/test-plugin/remote/logs/test-org/test-crashing/app.log", "fake logs"))
+		Expect(files).To(HaveKey("bundle/plugins/test-plugin/remote/logs/test-org/test-crashing/app.previous.log"))
+		Expect(files).NotTo(HaveKey("bundle/plugins/test-plugin/remote/logs/test-org/test-ready/app.log"))
+		Expect(files["bundle/plugins/test-plugin/remote/events.txt"]).To(MatchRegexp(`2025-01-01T11:59:00Z\s+Warning\s+BackOff\s+pod/test-crashing\s+5\s+Back-off restarting failed container app`))
+	})
+})
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type teamListOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	teamCmd.AddCommand(newTeamListCmd())
+}
+
+func newTeamListCmd() *cobra.Command {
+	o := &teamListOptions{}
+	listCmd := &cobra.Command{
+		Use:          "list",
+		Short:        "List the teams of an organization",
+		Long:         "Lists the teams of an organization with their IdP group, the number of members and when the members were last synced from the SCIM API.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			return listTeams(cmd.Context(), k8sClient, o.orgName, cmd.OutOrStdout(), time.Now())
+		},
+	}
+
+	listCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	listCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := listCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return listCmd
+}
+
+// listTeams prints the teams of the organization together with the state of their TeamMembership.
+func listTeams(ctx context.Context, k8sClient client.Client, orgName string, out io.Writer, now time.Time) error {
+	var teams = new(greenhousev1alpha1.TeamList)
+	if err := k8sClient.List(ctx, teams, client.InNamespace(orgName)); err != nil {
+		return fmt.Errorf("failed to list teams in organization %s: %w", orgName, err)
+	}
+	var teamMemberships = new(greenhousev1alpha1.TeamMembershipList)
+	if err := k8sClient.List(ctx, teamMemberships, client.InNamespace(orgName)); err != nil {
+		return fmt.Errorf("failed to list teamMemberships in organization %s: %w", orgName, err)
+	}
+	// The TeamMembership of a team has the name of the team.
+	membershipByTeam := make(map[string]*greenhousev1alpha1.TeamMembership, len(teamMemberships.Items))
+	for idx := range teamMemberships.Items {
+		membershipByTeam[teamMemberships.Items[idx].GetName()] = &teamMemberships.Items[idx]
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tIDP GROUP\tMEMBERS\tLAST SYNCED\tSCIM ACCESS")
+	for _, team := range teams.Items {
+		members, lastSynced, scimAccess := "-", "-", "-"
+		if teamMembership, ok := membershipByTeam[team.GetName()]; ok {
+			members = strconv.Itoa(len(teamMembership.Spec.Members))
+			lastSynced = timeSince(teamMembership.Status.LastSyncedTime, now)
+			if condition := teamMembership.Status.GetConditionByType(greenhousev1alpha1.SCIMAccessReadyCondition); condition != nil {
+				scimAccess = string(condition.Status)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", team.GetName(), valueOrDash(team.Spec.MappedIDPGroup), members, lastSynced, scimAccess)
+	}
+	return w.Flush()
+}
+
+// timeSince returns the time passed since t in a human readable form.
+func timeSince(t *metav1.Time, now time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return duration.HumanDuration(now.Sub(t.Time)) + " ago"
+}
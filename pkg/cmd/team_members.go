@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+type teamMembersOptions struct {
+	kubecontext string
+	orgName     string
+}
+
+func init() {
+	teamCmd.AddCommand(newTeamMembersCmd())
+}
+
+func newTeamMembersCmd() *cobra.Command {
+	o := &teamMembersOptions{}
+	membersCmd := &cobra.Command{
+		Use:          "members <team-name>",
+		Short:        "Show the members of a team",
+		Long:         "Shows the members of a team as synced from the SCIM API into its TeamMembership, together with the state of the synchronization.",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			restConfig, err := config.GetConfigWithContext(o.kubecontext)
+			if err != nil {
+				return err
+			}
+			k8sClient, err := clientutil.NewK8sClient(restConfig)
+			if err != nil {
+				return err
+			}
+			return printTeamMembers(cmd.Context(), k8sClient, o.orgName, args[0], cmd.OutOrStdout(), time.Now())
+		},
+	}
+
+	membersCmd.Flags().StringVar(&o.kubecontext, "kubecontext", "", "The context to use from the kubeconfig for the Greenhouse cluster (defaults to current-context)")
+	membersCmd.Flags().StringVar(&o.orgName, "org", clientutil.GetEnvOrDefault("GREENHOUSE_ORG", ""), "The organization name to use. Can be set via GREENHOUSE_ORG env var")
+	if err := membersCmd.MarkFlagRequired("org"); err != nil {
+		setupLog.Error(err, "Flag could not set as required", "org")
+	}
+	return membersCmd
+}
+
+func printTeamMembers(ctx context.Context, k8sClient client.Client, orgName, teamName string, out io.Writer, now time.Time) error {
+	var team = new(greenhousev1alpha1.Team)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: orgName, Name: teamName}, team); err != nil {
+		return fmt.Errorf("failed to get team %s/%s: %w", orgName, teamName, err)
+	}
+	var teamMembership = new(greenhousev1alpha1.TeamMembership)
+	err := k8sClient.Get(ctx, client.ObjectKey{Namespace: orgName, Name: teamName}, teamMembership)
+	switch {
+	case apierrors.IsNotFound(err):
+		fmt.Fprintf(out, "team %s/%s has no members, they are synced from the IdP group of the team if the organization has a SCIM config\n", orgName, teamName)
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get teamMembership %s/%s: %w", orgName, teamName, err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Team:\t%s\n", team.GetName())
+	fmt.Fprintf(w, "IdP group:\t%s\n", valueOrDash(team.Spec.MappedIDPGroup))
+	fmt.Fprintf(w, "Last synced:\t%s\n", timeSince(teamMembership.Status.LastSyncedTime, now))
+	fmt.Fprintf(w, "Last changed:\t%s\n", timeSince(teamMembership.Status.LastChangedTime, now))
+	printConditions(w, teamMembership.Status.Conditions)
+
+	fmt.Fprintf(w, "\nMEMBER (%d)\tEMAIL\tID\n", len(teamMembership.Spec.Members))
+	for _, user := range teamMembership.Spec.Members {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.TrimSpace(user.FirstName+" "+user.LastName), user.Email, user.ID)
+	}
+	return w.Flush()
+}
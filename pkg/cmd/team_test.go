@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+var _ = Describe("List teams and their members", func() {
+	var (
+		now       = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		k8sClient client.Client
+	)
+
+	BeforeEach(func() {
+		synced := metav1.NewTime(now.Add(-5 * time.Minute))
+		teamMembership := &greenhousev1alpha1.TeamMembership{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "team-a"},
+			Spec: greenhousev1alpha1.TeamMembershipSpec{Members: []greenhousev1alpha1.User{
+				{ID: "I123", FirstName: "Jane", LastName: "Doe", Email: "jane.doe@example.com"},
+				{ID: "I456", FirstName: "John", LastName: "Doe", Email: "john.doe@example.com"},
+			}},
+			Status: greenhousev1alpha1.TeamMembershipStatus{
+				LastSyncedTime:   &synced,
+				StatusConditions: greenhousev1alpha1.StatusConditions{Conditions: []greenhousev1alpha1.Condition{greenhousev1alpha1.TrueCondition(greenhousev1alpha1.SCIMAccessReadyCondition, "", "")}},
+			},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			&greenhousev1alpha1.Team{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "team-a"}, Spec: greenhousev1alpha1.TeamSpec{MappedIDPGroup: "group-a"}},
+			&greenhousev1alpha1.Team{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "team-b"}},
+			teamMembership,
+		).Build()
+	})
+
+	It("should list the teams with the state of their memberships", func() {
+		var out bytes.Buffer
+		Expect(listTeams(context.Background(), k8sClient, "test-org", &out, now)).To(Succeed())
+		Expect(out.String()).To(MatchRegexp(`team-a\s+group-a\s+2\s+5m ago\s+True`))
+		Expect(out.String()).To(MatchRegexp(`team-b\s+-\s+-\s+-\s+-`))
+	})
+
+	It("should show the members of a team", func() {
+		var out bytes.Buffer
+		Expect(printTeamMembers(context.Background(), k8sClient, "test-org", "team-a", &out, now)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("MEMBER (2)"))
+		Expect(out.String()).To(MatchRegexp(`Jane Doe\s+jane.doe@example.com\s+I123`))
+		Expect(out.String()).To(MatchRegexp(`SCIMAccessReady\s+True`))
+	})
+
+	It("should report a team without members", func() {
+		var out bytes.Buffer
+		Expect(printTeamMembers(context.Background(), k8sClient, "test-org", "team-b", &out, now)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("team test-org/team-b has no members"))
+	})
+})
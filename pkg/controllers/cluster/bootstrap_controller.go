@@ -162,6 +162,9 @@ func (r *BootstrapReconciler) createOrPatchCluster(
 		return nil
 	}
 	accessMode := greenhousev1alpha1.ClusterAccessModeDirect
+	if kubeConfigSecret.GetAnnotations()[greenhouseapis.SecretClusterAccessModeAnnotation] == string(greenhousev1alpha1.ClusterAccessModeReverseTunnel) {
+		accessMode = greenhousev1alpha1.ClusterAccessModeReverseTunnel
+	}
 
 	cluster.SetName(kubeConfigSecret.Name)
 	cluster.SetNamespace(kubeConfigSecret.Namespace)
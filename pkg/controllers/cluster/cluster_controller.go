@@ -24,25 +24,39 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
 	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/clientutil"
 )
 
-// RemoteClusterReconciler reconciles a Cluster object with accessMode=direct set.
+// RemoteClusterReconciler reconciles a Cluster object with accessMode=direct or accessMode=reverse-tunnel set.
 type RemoteClusterReconciler struct {
 	client.Client
 	recorder                           record.EventRecorder
 	RemoteClusterBearerTokenValidity   time.Duration
 	RenewRemoteClusterBearerTokenAfter time.Duration
+	// TunnelProxyURL is the URL of the tunnel server proxy used to access clusters with the reverse-tunnel access mode.
+	TunnelProxyURL string
+	// TunnelProxyCA is the PEM encoded CA certificate clients verify the tunnel server proxy with.
+	TunnelProxyCA []byte
+	// InventoryInterval is the interval in which the inventory of a cluster is collected. Collecting the inventory is disabled if zero.
+	InventoryInterval time.Duration
+	// DeprecatedAPIScanInterval is the interval in which a cluster is scanned for removed API versions. Scanning is disabled if zero.
+	DeprecatedAPIScanInterval time.Duration
+
+	tokenRenewalFailures tokenRenewalFailures
 }
 
 //+kubebuilder:rbac:groups=greenhouse.sap,resources=clusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=greenhouse.sap,resources=clusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=greenhouse.sap,resources=clusters/finalizers,verbs=update
+//+kubebuilder:rbac:groups=greenhouse.sap,resources=organizations,verbs=get;list;watch
+//+kubebuilder:rbac:groups=greenhouse.sap,resources=plugins;teamrolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=greenhouse.sap,resources=teams;teamroles,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update;patch;create
-//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;update;patch;create
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;update;patch;create;delete
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch;create;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups="rbac",resources=clusterrolebindings,verbs=get;list;watch;update;patch;create
@@ -54,11 +68,15 @@ func (r *RemoteClusterReconciler) SetupWithManager(name string, mgr ctrl.Manager
 
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(name).
-		For(&greenhousev1alpha1.Cluster{}, builder.WithPredicates(
+		For(&greenhousev1alpha1.Cluster{}, builder.WithPredicates(predicate.Or(
 			clientutil.PredicateClusterByAccessMode(greenhousev1alpha1.ClusterAccessModeDirect),
-		)).
+			clientutil.PredicateClusterByAccessMode(greenhousev1alpha1.ClusterAccessModeReverseTunnel),
+		))).
 		// Watch the secret owned by this cluster.
 		Watches(&corev1.Secret{}, handler.EnqueueRequestForOwner(mgr.GetScheme(), mgr.GetRESTMapper(), &greenhousev1alpha1.Cluster{})).
+		// Watch the Organization to apply changed cluster label rules.
+		Watches(&greenhousev1alpha1.Organization{}, handler.EnqueueRequestsFromMapFunc(r.enqueueAllClustersForOrganization),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Complete(r)
 }
 
@@ -72,7 +90,7 @@ func (r *RemoteClusterReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 func (r *RemoteClusterReconciler) EnsureCreated(ctx context.Context, resource lifecycle.RuntimeObject) (ctrl.Result, lifecycle.ReconcileResult, error) {
 	cluster := resource.(*greenhousev1alpha1.Cluster) //nolint:errcheck
-	if cluster.Spec.AccessMode != greenhousev1alpha1.ClusterAccessModeDirect {
+	if cluster.Spec.AccessMode != greenhousev1alpha1.ClusterAccessModeDirect && cluster.Spec.AccessMode != greenhousev1alpha1.ClusterAccessModeReverseTunnel {
 		return ctrl.Result{}, lifecycle.Failed, nil
 	}
 	// Deletion Schedule mechanism
@@ -95,6 +113,29 @@ func (r *RemoteClusterReconciler) EnsureCreated(ctx context.Context, resource li
 		return ctrl.Result{}, lifecycle.Failed, err
 	}
 
+	if cluster.Spec.AccessMode == greenhousev1alpha1.ClusterAccessModeReverseTunnel {
+		if err := r.reconcileTunnel(ctx, cluster, clusterSecret); err != nil {
+			return ctrl.Result{}, lifecycle.Failed, err
+		}
+	}
+
+	// Every failure to access the cluster prevents the renewal of its token, so all of them are counted as failed renewals.
+	result, reconcileResult, err := r.reconcileRemoteCluster(ctx, cluster, clusterSecret)
+	if err != nil {
+		r.recordTokenRenewalFailure(cluster, err)
+		return result, reconcileResult, err
+	}
+	r.tokenRenewalFailures.reset(client.ObjectKeyFromObject(cluster))
+	return result, reconcileResult, nil
+}
+
+// reconcileRemoteCluster reconciles the resources of Greenhouse in the remote cluster and renews the token used to access it.
+func (r *RemoteClusterReconciler) reconcileRemoteCluster(
+	ctx context.Context,
+	cluster *greenhousev1alpha1.Cluster,
+	clusterSecret *corev1.Secret,
+) (ctrl.Result, lifecycle.ReconcileResult, error) {
+
 	restClientGetter, err := clientutil.NewRestClientGetterFromSecret(clusterSecret, cluster.Namespace)
 	if err != nil {
 		return ctrl.Result{}, lifecycle.Failed, err
@@ -105,6 +146,26 @@ func (r *RemoteClusterReconciler) EnsureCreated(ctx context.Context, resource li
 		return ctrl.Result{}, lifecycle.Failed, err
 	}
 
+	// A cluster being migrated to another organization is no longer reconciled in this organization.
+	if isMigrationRequested(cluster) {
+		return r.reconcileMigration(ctx, remoteClient, cluster, clusterSecret)
+	}
+
+	// An interrupted rotation is resumed first, as the current token might already be revoked.
+	if isCredentialRotationInProgress(cluster, clusterSecret) {
+		return r.reconcileCredentialRotation(ctx, restClientGetter, remoteClient, nil, cluster, clusterSecret)
+	}
+
+	// Derived labels are reconciled first, as patching the labels refreshes the cluster and the inventory is set on the status.
+	if err := r.reconcileDerivedLabels(ctx, remoteClient, cluster); err != nil {
+		return ctrl.Result{}, lifecycle.Failed, err
+	}
+
+	if err := r.reconcileInventory(ctx, remoteClient, cluster); err != nil {
+		return ctrl.Result{}, lifecycle.Failed, err
+	}
+	r.reconcileDeprecatedAPIs(ctx, restClientGetter, remoteClient, cluster)
+
 	var crb *rbacv1.ClusterRoleBinding
 	if clusterSecret.Type != greenhouseapis.SecretTypeOIDCConfig {
 		// Create ClusterRoleBinding first so it can be added as an owner in ServiceAccount
@@ -126,7 +187,27 @@ func (r *RemoteClusterReconciler) EnsureCreated(ctx context.Context, resource li
 			return ctrl.Result{}, lifecycle.Failed, err
 		}
 	}
-	if err := r.reconcileServiceAccountToken(ctx, restClientGetter, remoteClient, cluster, clusterSecret.Type); err != nil {
+	if isCredentialRotationRequested(cluster) {
+		return r.reconcileCredentialRotation(ctx, restClientGetter, remoteClient, crb, cluster, clusterSecret)
+	}
+	if err := r.reconcileServiceAccountToken(ctx, restClientGetter, remoteClient, cluster, clusterSecret.Type, false); err != nil {
+		return ctrl.Result{}, lifecycle.Failed, err
+	}
+	return ctrl.Result{RequeueAfter: utils.DefaultRequeueInterval}, lifecycle.Success, nil
+}
+
+// reconcileCredentialRotation rotates the credentials of the cluster and emits an event if the rotation fails.
+func (r *RemoteClusterReconciler) reconcileCredentialRotation(
+	ctx context.Context,
+	restClientGetter *clientutil.RestClientGetter,
+	remoteClient client.Client,
+	crb *rbacv1.ClusterRoleBinding,
+	cluster *greenhousev1alpha1.Cluster,
+	clusterSecret *corev1.Secret,
+) (ctrl.Result, lifecycle.ReconcileResult, error) {
+
+	if err := r.rotateCredentials(ctx, restClientGetter, remoteClient, crb, cluster, clusterSecret); err != nil {
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, greenhousev1alpha1.FailedEvent, "Failed to rotate the credentials of the cluster: %s", err)
 		return ctrl.Result{}, lifecycle.Failed, err
 	}
 	return ctrl.Result{RequeueAfter: utils.DefaultRequeueInterval}, lifecycle.Success, nil
@@ -219,6 +300,7 @@ func (r *RemoteClusterReconciler) reconcileServiceAccountToken(
 	remoteClient client.Client,
 	cluster *greenhousev1alpha1.Cluster,
 	secretType corev1.SecretType,
+	forceRenewal bool,
 ) error {
 
 	cluster.SetDefaultTokenValidityIfNeeded()
@@ -228,6 +310,7 @@ func (r *RemoteClusterReconciler) reconcileServiceAccountToken(
 		RemoteClusterBearerTokenValidity:   time.Duration(cluster.Spec.KubeConfig.MaxTokenValidity) * time.Hour,
 		RenewRemoteClusterBearerTokenAfter: r.RenewRemoteClusterBearerTokenAfter,
 		SecretType:                         secretType,
+		ForceRenewal:                       forceRenewal,
 	}
 	tokenRequest, err := t.GenerateTokenRequest(ctx, restClientGetter, cluster)
 	if err != nil {
@@ -240,7 +323,7 @@ func (r *RemoteClusterReconciler) reconcileServiceAccountToken(
 	}
 	var generatedKubeConfig []byte
 	switch cluster.Spec.AccessMode {
-	case greenhousev1alpha1.ClusterAccessModeDirect:
+	case greenhousev1alpha1.ClusterAccessModeDirect, greenhousev1alpha1.ClusterAccessModeReverseTunnel:
 		generatedKubeConfig, err = utils.GenerateNewClientKubeConfig(restClientGetter, tokenRequest.Status.Token, cluster)
 		if err != nil {
 			return err
@@ -279,11 +362,13 @@ func (r *RemoteClusterReconciler) reconcileServiceAccountToken(
 // EnsureDeleted - handles the deletion / cleanup of cluster resource
 func (r *RemoteClusterReconciler) EnsureDeleted(ctx context.Context, resource lifecycle.RuntimeObject) (ctrl.Result, lifecycle.ReconcileResult, error) {
 	cluster := resource.(*greenhousev1alpha1.Cluster) //nolint:errcheck
+	r.tokenRenewalFailures.reset(client.ObjectKeyFromObject(cluster))
+	deleteHealthMetrics(cluster)
 	c := cluster.Status.StatusConditions.GetConditionByType(greenhousev1alpha1.KubeConfigValid)
 	if c != nil && c.IsFalse() {
 		return ctrl.Result{}, lifecycle.Success, nil
 	}
-	// delete all plugins that are bound to this cluster
+	// delete all plugins that are bound to this cluster, detached plugins keep their helm release
 	deletionCount, err := deletePlugins(ctx, r.Client, cluster)
 	if err != nil {
 		return ctrl.Result{}, lifecycle.Failed, err
@@ -310,8 +395,16 @@ func (r *RemoteClusterReconciler) EnsureDeleted(ctx context.Context, resource li
 		return ctrl.Result{}, lifecycle.Failed, err
 	}
 
-	// deleting the cluster role binding in the remote cluster will delete
-	// greenhouse service account and namespace due to owner reference
+	// the cluster role binding is shared with the organization the cluster was migrated to
+	if isMigrated(cluster) {
+		if err := releaseClusterRoleBinding(ctx, remoteClient, cluster.GetNamespace()); err != nil {
+			return ctrl.Result{}, lifecycle.Failed, err
+		}
+		return ctrl.Result{}, lifecycle.Success, nil
+	}
+
+	// deleting the cluster role binding in the remote cluster deletes the greenhouse service account due to its owner reference.
+	// The namespace is not owned by it and kept, so the Helm releases of detached Plugins in it keep running.
 	if err := r.deleteClusterRoleBindingInRemoteCluster(ctx, remoteClient); err != nil {
 		return ctrl.Result{}, lifecycle.Failed, err
 	}
@@ -352,6 +445,11 @@ func deletePlugins(ctx context.Context, c client.Client, cluster *greenhousev1al
 		return
 	}
 	for _, plugin := range pluginList.Items {
+		if cluster.Spec.DeletionPolicy == greenhousev1alpha1.ClusterDeletionPolicyDetach {
+			if err = detachPlugin(ctx, c, &plugin); client.IgnoreNotFound(err) != nil {
+				return
+			}
+		}
 		if err = c.Delete(ctx, &plugin); client.IgnoreNotFound(err) != nil {
 			return
 		}
@@ -359,3 +457,17 @@ func deletePlugins(ctx context.Context, c client.Client, cluster *greenhousev1al
 	}
 	return
 }
+
+// detachPlugin annotates the Plugin to keep its Helm release running in the cluster once the Plugin is deleted.
+func detachPlugin(ctx context.Context, c client.Client, plugin *greenhousev1alpha1.Plugin) error {
+	_, err := clientutil.Patch(ctx, c, plugin, func() error {
+		annotations := plugin.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[greenhouseapis.PluginDetachAnnotation] = "true"
+		plugin.SetAnnotations(annotations)
+		return nil
+	})
+	return err
+}
@@ -8,10 +8,13 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 
@@ -119,5 +122,123 @@ var _ = Describe("KubeConfig controller", func() {
 				Expect(kubeVersion).
 					ToNot(BeNil(), "the kubernetes version should not be nil")
 			})
+
+		It("Should rotate the credentials of the cluster on demand", func() {
+			By("Creating a secret with a valid kubeconfig for a remote cluster")
+			secret := setup.CreateSecret(test.Ctx, directAccessTestCase,
+				test.WithSecretType(greenhouseapis.SecretTypeKubeConfig),
+				test.WithSecretData(map[string][]byte{greenhouseapis.KubeConfigKey: remoteKubeConfig}))
+
+			By("Waiting for the greenhouse kubeconfig to be generated")
+			greenhouseKubeConfigSecret := corev1.Secret{}
+			Eventually(func(g Gomega) {
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &greenhouseKubeConfigSecret)).To(Succeed())
+				g.Expect(greenhouseKubeConfigSecret.Data).To(HaveKey(greenhouseapis.GreenHouseKubeConfigKey))
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &cluster)).To(Succeed())
+			}).Should(Succeed(), "eventually the greenhouse kubeconfig should be generated")
+			oldKubeConfig := greenhouseKubeConfigSecret.Data[greenhouseapis.GreenHouseKubeConfigKey]
+
+			remoteClient, err := clientutil.NewK8sClientFromCluster(test.Ctx, test.K8sClient, &cluster)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error creating a new k8s client from the cluster")
+			var serviceAccount = new(corev1.ServiceAccount)
+			Eventually(func() error {
+				return remoteClient.Get(test.Ctx, types.NamespacedName{Namespace: setup.Namespace(), Name: clusterutils.ServiceAccountName}, serviceAccount)
+			}).Should(Succeed(), "eventually the service account should exist")
+			oldUID := serviceAccount.UID
+
+			By("Requesting the rotation of the credentials")
+			_, err = clientutil.Patch(test.Ctx, test.K8sClient, &cluster, func() error {
+				annotations := cluster.GetAnnotations()
+				if annotations == nil {
+					annotations = make(map[string]string)
+				}
+				annotations[greenhouseapis.ClusterRotateCredentialsAnnotation] = "now"
+				cluster.SetAnnotations(annotations)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred(), "there should be no error annotating the cluster")
+
+			By("Checking the rotation is recorded and the annotation is removed")
+			Eventually(func(g Gomega) {
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&cluster), &cluster)).To(Succeed())
+				g.Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.ClusterRotateCredentialsAnnotation))
+				g.Expect(cluster.Status.LastCredentialRotationTimestamp.IsZero()).To(BeFalse())
+			}).Should(Succeed(), "eventually the rotation should be recorded")
+
+			By("Checking the service account was recreated and a new kubeconfig was written")
+			Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &greenhouseKubeConfigSecret)).To(Succeed())
+			Expect(greenhouseKubeConfigSecret.Data[greenhouseapis.GreenHouseKubeConfigKey]).ToNot(Equal(oldKubeConfig), "the kubeconfig should have been replaced")
+			remoteClient, err = clientutil.NewK8sClientFromCluster(test.Ctx, test.K8sClient, &cluster)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error creating a new k8s client from the cluster")
+			Expect(remoteClient.Get(test.Ctx, types.NamespacedName{Namespace: setup.Namespace(), Name: clusterutils.ServiceAccountName}, serviceAccount)).
+				To(Succeed(), "the new kubeconfig should be valid")
+			Expect(serviceAccount.UID).ToNot(Equal(oldUID), "the service account should have been recreated")
+			Expect(remoteClient.Get(test.Ctx, types.NamespacedName{Name: "greenhouse-rotation"}, &rbacv1.ClusterRoleBinding{})).
+				To(Satisfy(apierrors.IsNotFound), "the temporary clusterRoleBinding should have been deleted")
+			Expect(greenhouseKubeConfigSecret.Data).ToNot(HaveKey(greenhouseapis.RotationTokenKey), "the token of the temporary service account should have been removed")
+		})
+
+		It("Should resume an interrupted rotation of the credentials", func() {
+			By("Creating a secret with a valid kubeconfig for a remote cluster")
+			secret := setup.CreateSecret(test.Ctx, directAccessTestCase,
+				test.WithSecretType(greenhouseapis.SecretTypeKubeConfig),
+				test.WithSecretData(map[string][]byte{greenhouseapis.KubeConfigKey: remoteKubeConfig}))
+
+			By("Waiting for the greenhouse kubeconfig to be generated")
+			greenhouseKubeConfigSecret := corev1.Secret{}
+			Eventually(func(g Gomega) {
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &greenhouseKubeConfigSecret)).To(Succeed())
+				g.Expect(greenhouseKubeConfigSecret.Data).To(HaveKey(greenhouseapis.GreenHouseKubeConfigKey))
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &cluster)).To(Succeed())
+			}).Should(Succeed(), "eventually the greenhouse kubeconfig should be generated")
+			remoteClient, err := clientutil.NewK8sClientFromCluster(test.Ctx, test.K8sClient, &cluster)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error creating a new k8s client from the cluster")
+
+			By("Interrupting a rotation after the greenhouse service account was deleted")
+			Expect(remoteClient.Create(test.Ctx, &rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "greenhouse-rotation"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "greenhouse-rotation", Namespace: setup.Namespace()}},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin", APIGroup: rbacv1.GroupName},
+			})).To(Succeed(), "there should be no error creating the temporary clusterRoleBinding")
+			rotationServiceAccount := clusterutils.NewServiceAccount("greenhouse-rotation", setup.Namespace())
+			Expect(remoteClient.Create(test.Ctx, rotationServiceAccount)).To(Succeed(), "there should be no error creating the temporary service account")
+			tokenRequest := &authenticationv1.TokenRequest{Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: ptr.To[int64](3600)}}
+			Expect(remoteClient.SubResource("token").Create(test.Ctx, rotationServiceAccount, tokenRequest)).
+				To(Succeed(), "there should be no error requesting a token of the temporary service account")
+			token := tokenRequest.Status.Token
+			_, err = clientutil.Patch(test.Ctx, test.K8sClient, &greenhouseKubeConfigSecret, func() error {
+				greenhouseKubeConfigSecret.Data[greenhouseapis.RotationTokenKey] = []byte(token)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred(), "there should be no error storing the token of the temporary service account")
+			Expect(remoteClient.Delete(test.Ctx, clusterutils.NewServiceAccount(clusterutils.ServiceAccountName, setup.Namespace()))).
+				To(Succeed(), "there should be no error deleting the greenhouse service account")
+
+			By("Requesting the rotation of the credentials")
+			_, err = clientutil.Patch(test.Ctx, test.K8sClient, &cluster, func() error {
+				annotations := cluster.GetAnnotations()
+				if annotations == nil {
+					annotations = make(map[string]string)
+				}
+				annotations[greenhouseapis.ClusterRotateCredentialsAnnotation] = "now"
+				cluster.SetAnnotations(annotations)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred(), "there should be no error annotating the cluster")
+
+			By("Checking the rotation is completed with the temporary service account")
+			Eventually(func(g Gomega) {
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&cluster), &cluster)).To(Succeed())
+				g.Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.ClusterRotateCredentialsAnnotation))
+				g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), &greenhouseKubeConfigSecret)).To(Succeed())
+				g.Expect(greenhouseKubeConfigSecret.Data).ToNot(HaveKey(greenhouseapis.RotationTokenKey))
+			}).Should(Succeed(), "eventually the rotation should be completed")
+			remoteClient, err = clientutil.NewK8sClientFromCluster(test.Ctx, test.K8sClient, &cluster)
+			Expect(err).ToNot(HaveOccurred(), "there should be no error creating a new k8s client from the cluster")
+			Expect(remoteClient.Get(test.Ctx, types.NamespacedName{Namespace: setup.Namespace(), Name: clusterutils.ServiceAccountName}, &corev1.ServiceAccount{})).
+				To(Succeed(), "the new kubeconfig should be valid")
+			Expect(remoteClient.Get(test.Ctx, types.NamespacedName{Name: "greenhouse-rotation"}, &rbacv1.ClusterRoleBinding{})).
+				To(Satisfy(apierrors.IsNotFound), "the temporary clusterRoleBinding should have been deleted")
+		})
 	})
 })
@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+// derivedLabelConflictEvent is the reason of the event emitted if a derived label is skipped because it is set by users.
+const derivedLabelConflictEvent = "DerivedLabelConflict"
+
+// reconcileDerivedLabels keeps the labels derived from the ClusterLabelRules of the Organization in sync.
+// Labels that were derived before but are no longer derived are removed. Labels set by users are never overwritten.
+// Failing to derive the labels from the remote cluster is not fatal, the previously derived labels are kept.
+func (r *RemoteClusterReconciler) reconcileDerivedLabels(ctx context.Context, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster) error {
+	var organization = new(greenhousev1alpha1.Organization)
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster.GetNamespace()}, organization); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	derived, err := deriveLabels(ctx, remoteClient, organization.Spec.ClusterLabelRules, cluster.Status.KubernetesVersion)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to derive cluster labels", "cluster", cluster.Name)
+		return nil
+	}
+
+	var conflicts, previousConflicts string
+	_, err = clientutil.Patch(ctx, r.Client, cluster, func() error {
+		labels, keys, conflictKeys := applyDerivedLabels(cluster.GetLabels(), cluster.GetAnnotations()[greenhouseapis.ClusterDerivedLabelsAnnotation], derived)
+		cluster.SetLabels(labels)
+
+		annotations := cluster.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		setOrDeleteAnnotation(annotations, greenhouseapis.ClusterDerivedLabelsAnnotation, strings.Join(keys, ","))
+		previousConflicts = annotations[greenhouseapis.ClusterDerivedLabelConflictsAnnotation]
+		conflicts = strings.Join(conflictKeys, ",")
+		setOrDeleteAnnotation(annotations, greenhouseapis.ClusterDerivedLabelConflictsAnnotation, conflicts)
+		cluster.SetAnnotations(annotations)
+		return nil
+	})
+	// The conflicts are only reported if they changed, as the labels are derived on every reconciliation.
+	if err == nil && conflicts != "" && conflicts != previousConflicts {
+		log.FromContext(ctx).Info("skipping derived cluster labels set by users", "cluster", cluster.Name, "labels", conflicts)
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, derivedLabelConflictEvent,
+			"Skipped derived labels already set on the cluster: %s", strings.ReplaceAll(conflicts, ",", ", "))
+	}
+	return err
+}
+
+// setOrDeleteAnnotation sets the annotation to the value or deletes it if the value is empty.
+func setOrDeleteAnnotation(annotations map[string]string, key, value string) {
+	if value == "" {
+		delete(annotations, key)
+		return
+	}
+	annotations[key] = value
+}
+
+// applyDerivedLabels returns the labels with the derived labels applied and the sorted keys of the derived labels now owned by Greenhouse.
+// Previously derived labels that are no longer derived are removed. Derived labels whose key is already set but was not derived before
+// are skipped and returned as conflicts, so labels set by users are not overwritten.
+func applyDerivedLabels(labels map[string]string, previouslyDerived string, derived map[string]string) (result map[string]string, keys, conflicts []string) {
+	result = make(map[string]string, len(labels)+len(derived))
+	for key, value := range labels {
+		result[key] = value
+	}
+	owned := make(map[string]bool)
+	for _, key := range strings.Split(previouslyDerived, ",") {
+		if key == "" {
+			continue
+		}
+		owned[key] = true
+		if _, ok := derived[key]; !ok {
+			delete(result, key)
+		}
+	}
+	for key, value := range derived {
+		if _, ok := result[key]; ok && !owned[key] {
+			conflicts = append(conflicts, key)
+			continue
+		}
+		result[key] = value
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	slices.Sort(conflicts)
+	return result, keys, conflicts
+}
+
+// deriveLabels evaluates the rules against the remote cluster. Rules resulting in an empty or invalid label value are omitted.
+func deriveLabels(ctx context.Context, remoteClient client.Client, rules []greenhousev1alpha1.ClusterLabelRule, kubernetesVersion string) (map[string]string, error) {
+	var (
+		derived = make(map[string]string, len(rules))
+		nodes   *corev1.NodeList
+	)
+	for _, rule := range rules {
+		var value string
+		switch {
+		case rule.NodeLabel != "":
+			if nodes == nil {
+				nodes = new(corev1.NodeList)
+				if err := remoteClient.List(ctx, nodes); err != nil {
+					return nil, err
+				}
+			}
+			values := make(map[string]int)
+			for _, node := range nodes.Items {
+				if v, ok := node.Labels[rule.NodeLabel]; ok {
+					values[v]++
+				}
+			}
+			value = mostCommon(values)
+		case rule.KubernetesVersion:
+			if v, err := version.ParseGeneric(kubernetesVersion); err == nil {
+				value = fmt.Sprintf("v%d.%d", v.Major(), v.Minor())
+			}
+		case rule.Namespace != "":
+			exists, err := objectExists(ctx, remoteClient, rule.Namespace, new(corev1.Namespace))
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.FormatBool(exists)
+		case rule.CustomResourceDefinition != "":
+			exists, err := objectExists(ctx, remoteClient, rule.CustomResourceDefinition, new(apiextensionsv1.CustomResourceDefinition))
+			if err != nil {
+				return nil, err
+			}
+			value = strconv.FormatBool(exists)
+		}
+		if value == "" || len(validation.IsValidLabelValue(value)) > 0 {
+			ctrl.LoggerFrom(ctx).Info("omitting derived cluster label", "label", rule.Label, "value", value)
+			continue
+		}
+		derived[rule.Label] = value
+	}
+	return derived, nil
+}
+
+// objectExists returns whether the cluster-scoped object with the given name exists.
+func objectExists(ctx context.Context, c client.Client, name string, obj client.Object) (bool, error) {
+	err := c.Get(ctx, types.NamespacedName{Name: name}, obj)
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// enqueueAllClustersForOrganization enqueues all Clusters of the Organization, so that changed ClusterLabelRules are applied.
+func (r *RemoteClusterReconciler) enqueueAllClustersForOrganization(ctx context.Context, o client.Object) []ctrl.Request {
+	var clusterList = new(greenhousev1alpha1.ClusterList)
+	// Cluster's namespace corresponds to Organization's name.
+	if err := r.List(ctx, clusterList, client.InNamespace(o.GetName())); err != nil {
+		return nil
+	}
+	res := make([]ctrl.Request, len(clusterList.Items))
+	for idx, cluster := range clusterList.Items {
+		res[idx] = ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&cluster)}
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Derived cluster labels", func() {
+	It("should derive the labels from the rules", func() {
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"region": "eu-de-1"}}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"region": "eu-de-1"}}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-c", Labels: map[string]string{"region": "eu-de-2"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring"}},
+			&apiextensionsv1.CustomResourceDefinition{ObjectMeta: metav1.ObjectMeta{Name: "prometheuses.monitoring.coreos.com"}},
+		).Build()
+		rules := []greenhousev1alpha1.ClusterLabelRule{
+			{Label: "region", NodeLabel: "region"},
+			{Label: "zone", NodeLabel: "zone"},
+			{Label: "version", KubernetesVersion: true},
+			{Label: "monitoring", Namespace: "monitoring"},
+			{Label: "logging", Namespace: "logging"},
+			{Label: "prometheus-operator", CustomResourceDefinition: "prometheuses.monitoring.coreos.com"},
+		}
+
+		labels, err := deriveLabels(test.Ctx, remoteClient, rules, "v1.32.2")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error deriving the labels")
+		Expect(labels).To(Equal(map[string]string{
+			"region":              "eu-de-1",
+			"version":             "v1.32",
+			"monitoring":          "true",
+			"logging":             "false",
+			"prometheus-operator": "true",
+		}), "labels without a value should be omitted")
+	})
+
+	It("should not overwrite labels set by users", func() {
+		labels := map[string]string{"team": "core", "region": "eu-de-2", "removed": "true", "version": "v1.31"}
+		derived := map[string]string{"region": "eu-de-1", "version": "v1.32"}
+
+		result, keys, conflicts := applyDerivedLabels(labels, "removed,version", derived)
+		Expect(result).To(Equal(map[string]string{"team": "core", "region": "eu-de-2", "version": "v1.32"}),
+			"previously derived labels should be updated or removed and labels set by users kept")
+		Expect(keys).To(Equal([]string{"version"}), "only the labels applied by Greenhouse should be owned")
+		Expect(conflicts).To(Equal([]string{"region"}), "the label set by users should be reported as conflict")
+	})
+
+	It("should only report changed conflicts with labels set by users", func() {
+		organization := &greenhousev1alpha1.Organization{
+			ObjectMeta: metav1.ObjectMeta{Name: "labels"},
+			Spec: greenhousev1alpha1.OrganizationSpec{ClusterLabelRules: []greenhousev1alpha1.ClusterLabelRule{
+				{Label: "region", NodeLabel: "region"},
+				{Label: "version", KubernetesVersion: true},
+			}},
+		}
+		cluster := &greenhousev1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "labels-cluster", Namespace: "labels", Labels: map[string]string{"region": "eu-de-2", "zone": "eu-de-2a"}},
+			Status:     greenhousev1alpha1.ClusterStatus{KubernetesVersion: "v1.32.2"},
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(organization, cluster).Build()
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"region": "eu-de-1", "zone": "eu-de-1a"}}},
+		).Build()
+		recorder := record.NewFakeRecorder(10)
+		r := &RemoteClusterReconciler{Client: k8sClient, recorder: recorder}
+
+		By("reporting the conflict once")
+		for range 2 {
+			Expect(r.reconcileDerivedLabels(test.Ctx, remoteClient, cluster)).To(Succeed(), "there should be no error reconciling the derived labels")
+		}
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.ClusterDerivedLabelConflictsAnnotation, "region"))
+		Expect(recorder.Events).To(HaveLen(1), "the unchanged conflict should only be reported once")
+		Expect(<-recorder.Events).To(ContainSubstring(derivedLabelConflictEvent))
+
+		By("reporting a changed conflict")
+		organization.Spec.ClusterLabelRules = append(organization.Spec.ClusterLabelRules, greenhousev1alpha1.ClusterLabelRule{Label: "zone", NodeLabel: "zone"})
+		Expect(k8sClient.Update(test.Ctx, organization)).To(Succeed(), "there should be no error adding a rule")
+		Expect(r.reconcileDerivedLabels(test.Ctx, remoteClient, cluster)).To(Succeed(), "there should be no error reconciling the derived labels")
+		Expect(cluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.ClusterDerivedLabelConflictsAnnotation, "region,zone"))
+		Expect(recorder.Events).To(HaveLen(1), "the changed conflict should be reported")
+		Expect(<-recorder.Events).To(ContainSubstring("region, zone"))
+
+		By("removing the conflicts once the labels are no longer set by users")
+		delete(cluster.Labels, "region")
+		delete(cluster.Labels, "zone")
+		Expect(k8sClient.Update(test.Ctx, cluster)).To(Succeed(), "there should be no error removing the labels")
+		Expect(r.reconcileDerivedLabels(test.Ctx, remoteClient, cluster)).To(Succeed(), "there should be no error reconciling the derived labels")
+		Expect(cluster.GetAnnotations()).ToNot(HaveKey(greenhouseapis.ClusterDerivedLabelConflictsAnnotation))
+		Expect(cluster.GetLabels()).To(HaveKeyWithValue("region", "eu-de-1"), "the derived label should be applied")
+		Expect(recorder.Events).To(BeEmpty(), "no conflict should be reported")
+	})
+})
@@ -37,22 +37,38 @@ func (r *RemoteClusterReconciler) setConditions() lifecycle.Conditioner {
 
 		allNodesReadyCondition := greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.AllNodesReady, "", "")
 		clusterNodeStatus := make(map[string]greenhousev1alpha1.NodeStatus)
-		// Can only reconcile node status if kubeconfig is valid
+		var health clusterHealth
+		// Can only reconcile node status and health if kubeconfig is valid
 		if restClientGetter == nil || kubeConfigValidCondition.IsFalse() {
 			allNodesReadyCondition.Message = "kubeconfig not valid - cannot know node status"
+			health = unknownClusterHealth("kubeconfig not valid - cannot probe cluster health")
 		} else {
 			allNodesReadyCondition, clusterNodeStatus = r.reconcileNodeStatus(ctx, restClientGetter)
+			health = r.reconcileHealth(ctx, restClientGetter)
 		}
+		updateHealthMetrics(cluster, health)
 
-		// set ready condition if kubeconfig is valid and all nodes are ready
-		readyCondition := r.reconcileReadyStatus(kubeConfigValidCondition, allNodesReadyCondition)
+		// set ready condition if kubeconfig is valid, all nodes are ready, the API server is healthy and the DNS is not failing
+		// the DNS only gates the readiness if it is known to fail, the probe of the metrics-server is informational only
+		readyCondition := r.reconcileReadyStatus(kubeConfigValidCondition, allNodesReadyCondition, health.apiServerReady, health.dnsReady)
 
 		conditions = append(conditions, readyCondition, allNodesReadyCondition, kubeConfigValidCondition)
+		conditions = append(conditions, health.conditions()...)
+		// A token is renewed once it expires within RenewRemoteClusterBearerTokenAfter, it is about to expire if renewing it failed for half of that time.
+		conditions = append(conditions, tokenExpiringSoonCondition(cluster, r.RenewRemoteClusterBearerTokenAfter/2))
+		if r.DeprecatedAPIScanInterval > 0 {
+			conditions = append(conditions, deprecatedAPIsCondition(cluster))
+		}
 
 		deletionCondition := r.checkDeletionSchedule(logger, cluster)
 		if !deletionCondition.IsUnknown() {
 			conditions = append(conditions, deletionCondition)
 		}
+		deletionImpact, err := r.reconcileDeletionImpact(ctx, cluster)
+		if err != nil {
+			logger.Error(err, "failed to compute deletion impact")
+		}
+		cluster.Status.DeletionImpact = deletionImpact
 		cluster.Status.KubernetesVersion = k8sVersion
 		cluster.Status.SetConditions(conditions...)
 		cluster.Status.Nodes = clusterNodeStatus
@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/controllers/cluster/utils"
+)
+
+const (
+	// rotationServiceAccountName is the name of the temporary service account used to access the cluster while the greenhouse service account is recreated.
+	rotationServiceAccountName = "greenhouse-rotation"
+	// rotationTokenValidity is the validity of the token of the temporary service account.
+	// It is long enough to resume a rotation that was interrupted after the greenhouse service account was deleted.
+	rotationTokenValidity int64 = 3600
+)
+
+// isCredentialRotationRequested returns whether the rotation of the credentials of the cluster was requested.
+func isCredentialRotationRequested(cluster *greenhousev1alpha1.Cluster) bool {
+	_, ok := cluster.GetAnnotations()[greenhouseapis.ClusterRotateCredentialsAnnotation]
+	return ok
+}
+
+// isCredentialRotationInProgress returns whether a rotation was started but not completed.
+// The greenhouse service account might already be deleted, so the current token can no longer be used to access the cluster.
+func isCredentialRotationInProgress(cluster *greenhousev1alpha1.Cluster, clusterSecret *corev1.Secret) bool {
+	return isCredentialRotationRequested(cluster) && clientutil.IsSecretContainsKey(clusterSecret, greenhouseapis.RotationTokenKey)
+}
+
+// rotateCredentials revokes the tokens used to access the cluster by recreating the service account they were issued for.
+// A new token is issued and written to the kubeconfig Secret, the rotation is recorded in the status and the annotation requesting it is removed.
+// The token of the temporary service account is stored in the kubeconfig Secret until the new token is written, so a failed rotation is resumed.
+// The ClusterRoleBinding is reconciled with the temporary service account if it is not given.
+func (r *RemoteClusterReconciler) rotateCredentials(
+	ctx context.Context,
+	restClientGetter *clientutil.RestClientGetter,
+	remoteClient client.Client,
+	crb *rbacv1.ClusterRoleBinding,
+	cluster *greenhousev1alpha1.Cluster,
+	clusterSecret *corev1.Secret,
+) error {
+
+	if clusterSecret.Type == greenhouseapis.SecretTypeOIDCConfig {
+		if err := r.rotateOIDCServiceAccount(ctx, clusterSecret); err != nil {
+			return err
+		}
+		if err := r.reconcileServiceAccountToken(ctx, restClientGetter, remoteClient, cluster, clusterSecret.Type, true); err != nil {
+			return err
+		}
+	} else {
+		restConfig, err := restClientGetter.ToRESTConfig()
+		if err != nil {
+			return err
+		}
+		// The greenhouse service account is recreated using a temporary service account, as deleting it revokes the current token.
+		rotationClient, err := r.rotationClientFor(ctx, restConfig, remoteClient, cluster, clusterSecret)
+		if err != nil {
+			return fmt.Errorf("failed to create temporary service account: %w", err)
+		}
+		if crb == nil {
+			if crb, err = r.reconcileClusterRoleBindingInRemoteCluster(ctx, rotationClient, cluster); err != nil {
+				return err
+			}
+		}
+		if err := client.IgnoreNotFound(rotationClient.Delete(ctx, utils.NewServiceAccount(utils.ServiceAccountName, cluster.GetNamespace()))); err != nil {
+			return fmt.Errorf("failed to delete service account: %w", err)
+		}
+		if err := r.reconcileServiceAccountInRemoteCluster(ctx, rotationClient, crb, cluster); err != nil {
+			return err
+		}
+		if err := r.reconcileServiceAccountToken(ctx, restClientGetter, rotationClient, cluster, clusterSecret.Type, true); err != nil {
+			return err
+		}
+		// The new token is written to the kubeconfig Secret, so the temporary service account is no longer needed.
+		if err := deleteRotationServiceAccount(ctx, rotationClient); err != nil {
+			return fmt.Errorf("failed to delete temporary service account: %w", err)
+		}
+		if err := r.setRotationToken(ctx, clusterSecret, ""); err != nil {
+			return err
+		}
+	}
+
+	// Patching the annotations refreshes the cluster, so a copy is patched to keep the status.
+	clusterCopy := cluster.DeepCopy()
+	if _, err := clientutil.Patch(ctx, r.Client, clusterCopy, func() error {
+		annotations := clusterCopy.GetAnnotations()
+		delete(annotations, greenhouseapis.ClusterRotateCredentialsAnnotation)
+		clusterCopy.SetAnnotations(annotations)
+		return nil
+	}); err != nil {
+		return err
+	}
+	cluster.Status.LastCredentialRotationTimestamp = metav1.Now()
+	r.recorder.Event(cluster, corev1.EventTypeNormal, greenhousev1alpha1.SuccessEvent, "Rotated the credentials of the cluster")
+	log.FromContext(ctx).Info("rotated credentials", "cluster", cluster.Name)
+	return nil
+}
+
+// rotateOIDCServiceAccount recreates the service account the OIDC token of the cluster is issued for.
+func (r *RemoteClusterReconciler) rotateOIDCServiceAccount(ctx context.Context, clusterSecret *corev1.Secret) error {
+	serviceAccount := utils.NewServiceAccount(clusterSecret.GetName(), clusterSecret.GetNamespace())
+	if err := client.IgnoreNotFound(r.Delete(ctx, serviceAccount)); err != nil {
+		return fmt.Errorf("failed to delete service account: %w", err)
+	}
+	serviceAccount = utils.NewServiceAccount(clusterSecret.GetName(), clusterSecret.GetNamespace())
+	_, err := clientutil.CreateOrPatch(ctx, r.Client, serviceAccount, func() error {
+		return controllerutil.SetOwnerReference(clusterSecret, serviceAccount, r.Scheme())
+	})
+	return err
+}
+
+// rotationClientFor returns a client using the token of the temporary service account.
+// The token stored by an interrupted rotation is reused if it is still valid. Otherwise the temporary service account is created
+// with the current credentials and its token is stored in the kubeconfig Secret before the greenhouse service account is deleted.
+func (r *RemoteClusterReconciler) rotationClientFor(
+	ctx context.Context,
+	restConfig *rest.Config,
+	remoteClient client.Client,
+	cluster *greenhousev1alpha1.Cluster,
+	clusterSecret *corev1.Secret,
+) (client.Client, error) {
+
+	if token, ok := clusterSecret.Data[greenhouseapis.RotationTokenKey]; ok {
+		rotationClient, err := newTokenClient(ctx, restConfig, string(token), cluster)
+		if err == nil {
+			log.FromContext(ctx).Info("resuming credential rotation", "cluster", cluster.Name)
+			return rotationClient, nil
+		}
+		log.FromContext(ctx).Error(err, "stored token of the temporary service account is not valid, restarting rotation", "cluster", cluster.Name)
+	}
+	token, err := newRotationServiceAccountToken(ctx, remoteClient, cluster)
+	if err != nil {
+		if err := deleteRotationServiceAccount(ctx, remoteClient); err != nil {
+			log.FromContext(ctx).Error(err, "failed to delete temporary clusterRoleBinding", "name", rotationServiceAccountName)
+		}
+		return nil, err
+	}
+	rotationClient, err := newTokenClient(ctx, restConfig, token, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.setRotationToken(ctx, clusterSecret, token); err != nil {
+		return nil, err
+	}
+	return rotationClient, nil
+}
+
+// setRotationToken stores the token of the temporary service account in the kubeconfig Secret. An empty token removes it.
+func (r *RemoteClusterReconciler) setRotationToken(ctx context.Context, clusterSecret *corev1.Secret, token string) error {
+	secret := new(corev1.Secret)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(clusterSecret), secret); err != nil {
+		return err
+	}
+	_, err := clientutil.Patch(ctx, r.Client, secret, func() error {
+		if token == "" {
+			delete(secret.Data, greenhouseapis.RotationTokenKey)
+			return nil
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[greenhouseapis.RotationTokenKey] = []byte(token)
+		return nil
+	})
+	return err
+}
+
+// newRotationServiceAccountToken creates a temporary service account bound to cluster-admin in the remote cluster and returns a short-lived token of it.
+func newRotationServiceAccountToken(ctx context.Context, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster) (string, error) {
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: rotationServiceAccountName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      rotationServiceAccountName,
+				Namespace: cluster.GetNamespace(),
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			Kind:     utils.CRoleKind,
+			Name:     utils.CRoleRef,
+			APIGroup: rbacv1.GroupName,
+		},
+	}
+	if _, err := clientutil.CreateOrPatch(ctx, remoteClient, clusterRoleBinding, func() error {
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	// The service account is owned by the ClusterRoleBinding, so it is garbage collected once the binding is deleted.
+	serviceAccount := utils.NewServiceAccount(rotationServiceAccountName, cluster.GetNamespace())
+	if _, err := clientutil.CreateOrPatch(ctx, remoteClient, serviceAccount, func() error {
+		return controllerutil.SetOwnerReference(clusterRoleBinding, serviceAccount, remoteClient.Scheme())
+	}); err != nil {
+		return "", err
+	}
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: ptr.To(rotationTokenValidity),
+		},
+	}
+	if err := remoteClient.SubResource("token").Create(ctx, serviceAccount, tokenRequest); err != nil {
+		return "", err
+	}
+	return tokenRequest.Status.Token, nil
+}
+
+// newTokenClient returns a client for the cluster authenticating with the token of the temporary service account.
+// It ensures the token can access the cluster before the greenhouse service account is deleted.
+func newTokenClient(ctx context.Context, restConfig *rest.Config, token string, cluster *greenhousev1alpha1.Cluster) (client.Client, error) {
+	rotationConfig := rest.AnonymousClientConfig(restConfig)
+	rotationConfig.BearerToken = token
+	rotationClient, err := clientutil.NewK8sClient(rotationConfig)
+	if err != nil {
+		return nil, err
+	}
+	serviceAccount := utils.NewServiceAccount(rotationServiceAccountName, cluster.GetNamespace())
+	if err := rotationClient.Get(ctx, client.ObjectKeyFromObject(serviceAccount), serviceAccount); err != nil {
+		return nil, err
+	}
+	return rotationClient, nil
+}
+
+// deleteRotationServiceAccount deletes the ClusterRoleBinding of the temporary service account.
+func deleteRotationServiceAccount(ctx context.Context, k8sClient client.Client) error {
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: rotationServiceAccountName}}
+	return client.IgnoreNotFound(k8sClient.Delete(ctx, clusterRoleBinding))
+}
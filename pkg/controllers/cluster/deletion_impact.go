@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+// reconcileDeletionImpact returns the impact of the scheduled deletion of the cluster, or nil if no deletion is scheduled.
+// An event summarizing the impact is emitted once the deletion was scheduled.
+func (r *RemoteClusterReconciler) reconcileDeletionImpact(ctx context.Context, cluster *greenhousev1alpha1.Cluster) (*greenhousev1alpha1.ClusterDeletionImpact, error) {
+	isScheduled, schedule, err := clientutil.ExtractDeletionSchedule(cluster.GetAnnotations())
+	if err != nil {
+		return nil, err
+	}
+	if !isScheduled || cluster.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	impact, err := computeDeletionImpact(ctx, r.Client, cluster)
+	if err != nil {
+		return cluster.Status.DeletionImpact, err
+	}
+	if cluster.Status.DeletionImpact == nil {
+		helmReleases := fmt.Sprintf("%d Helm releases", len(impact.HelmReleases))
+		if cluster.Spec.DeletionPolicy == greenhousev1alpha1.ClusterDeletionPolicyDetach {
+			helmReleases = "no Helm releases"
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, "DeletionScheduled",
+			"Deletion scheduled at %s removes %d Plugins, %s, %d TeamRoleBindings and %d exposed services. Remove the %s annotation to cancel the deletion",
+			schedule.Format(time.DateTime), len(impact.Plugins), helmReleases, len(impact.TeamRoleBindings), len(impact.ExposedServices), greenhouseapis.MarkClusterDeletionAnnotation)
+	}
+	return impact, nil
+}
+
+// computeDeletionImpact lists the Plugins, Helm releases, TeamRoleBindings and exposed services that are removed together with the cluster.
+// The Helm releases are kept running in the cluster with the Detach deletion policy, so none are listed.
+func computeDeletionImpact(ctx context.Context, c client.Client, cluster *greenhousev1alpha1.Cluster) (*greenhousev1alpha1.ClusterDeletionImpact, error) {
+	var impact = new(greenhousev1alpha1.ClusterDeletionImpact)
+	isDetached := cluster.Spec.DeletionPolicy == greenhousev1alpha1.ClusterDeletionPolicyDetach
+
+	pluginList := &greenhousev1alpha1.PluginList{}
+	if err := c.List(ctx, pluginList, client.InNamespace(cluster.GetNamespace()), client.MatchingLabels{greenhouseapis.LabelKeyCluster: cluster.GetName()}); err != nil {
+		return nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	for _, plugin := range pluginList.Items {
+		impact.Plugins = append(impact.Plugins, plugin.GetName())
+		if plugin.Status.HelmReleaseStatus != nil && !isDetached {
+			impact.HelmReleases = append(impact.HelmReleases, plugin.Spec.ReleaseNamespace+"/"+plugin.GetName())
+		}
+		for url := range plugin.Status.ExposedServices {
+			impact.ExposedServices = append(impact.ExposedServices, url)
+		}
+	}
+
+	teamRoleBindingList := &greenhousev1alpha1.TeamRoleBindingList{}
+	if err := c.List(ctx, teamRoleBindingList, client.InNamespace(cluster.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list teamrolebindings: %w", err)
+	}
+	for _, trb := range teamRoleBindingList.Items {
+		isPropagated := slices.ContainsFunc(trb.Status.PropagationStatus, func(ps greenhousev1alpha1.PropagationStatus) bool {
+			return ps.ClusterName == cluster.GetName()
+		})
+		if isPropagated || trb.Spec.ClusterName == cluster.GetName() {
+			impact.TeamRoleBindings = append(impact.TeamRoleBindings, trb.GetName())
+		}
+	}
+
+	slices.Sort(impact.Plugins)
+	slices.Sort(impact.HelmReleases)
+	slices.Sort(impact.TeamRoleBindings)
+	slices.Sort(impact.ExposedServices)
+	return impact, nil
+}
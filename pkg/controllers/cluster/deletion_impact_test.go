@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Cluster deletion impact", func() {
+	var (
+		cluster  *greenhousev1alpha1.Cluster
+		recorder *record.FakeRecorder
+		r        *RemoteClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+		recorder = record.NewFakeRecorder(10)
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			&greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "ingress", Labels: map[string]string{greenhouseapis.LabelKeyCluster: "test-cluster"}},
+				Spec:       greenhousev1alpha1.PluginSpec{ClusterName: "test-cluster", ReleaseNamespace: "kube-system"},
+				Status: greenhousev1alpha1.PluginStatus{
+					HelmReleaseStatus: &greenhousev1alpha1.HelmReleaseStatus{Status: "deployed"},
+					ExposedServices:   map[string]greenhousev1alpha1.Service{"https://ingress.example.com": {Namespace: "kube-system", Name: "ingress", Port: 80}},
+				},
+			},
+			&greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "dashboard", Labels: map[string]string{greenhouseapis.LabelKeyCluster: "test-cluster"}},
+				Spec:       greenhousev1alpha1.PluginSpec{ClusterName: "test-cluster"},
+			},
+			&greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "other", Labels: map[string]string{greenhouseapis.LabelKeyCluster: "other-cluster"}},
+			},
+			&greenhousev1alpha1.TeamRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "admins"},
+				Status: greenhousev1alpha1.TeamRoleBindingStatus{
+					PropagationStatus: []greenhousev1alpha1.PropagationStatus{{ClusterName: "test-cluster"}},
+				},
+			},
+			&greenhousev1alpha1.TeamRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "viewers"},
+				Spec:       greenhousev1alpha1.TeamRoleBindingSpec{ClusterName: "other-cluster"},
+			},
+		).Build()
+		r = &RemoteClusterReconciler{Client: k8sClient, recorder: recorder}
+	})
+
+	It("should not report an impact if no deletion is scheduled", func() {
+		impact, err := r.reconcileDeletionImpact(test.Ctx, cluster)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error reconciling the deletion impact")
+		Expect(impact).To(BeNil(), "there should be no impact without a deletion schedule")
+		Expect(recorder.Events).To(BeEmpty(), "no event should be emitted")
+	})
+
+	It("should report the impact of a scheduled deletion", func() {
+		cluster.SetAnnotations(map[string]string{
+			greenhouseapis.MarkClusterDeletionAnnotation:     "true",
+			greenhouseapis.ScheduleClusterDeletionAnnotation: time.Now().Add(48 * time.Hour).Format(time.DateTime),
+		})
+		impact, err := r.reconcileDeletionImpact(test.Ctx, cluster)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error reconciling the deletion impact")
+		Expect(impact).To(Equal(&greenhousev1alpha1.ClusterDeletionImpact{
+			Plugins:          []string{"dashboard", "ingress"},
+			HelmReleases:     []string{"kube-system/ingress"},
+			TeamRoleBindings: []string{"admins"},
+			ExposedServices:  []string{"https://ingress.example.com"},
+		}))
+		Expect(recorder.Events).To(Receive(ContainSubstring("removes 2 Plugins, 1 Helm releases, 1 TeamRoleBindings and 1 exposed services")))
+
+		By("not emitting the event again once the impact is reported")
+		cluster.Status.DeletionImpact = impact
+		_, err = r.reconcileDeletionImpact(test.Ctx, cluster)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error reconciling the deletion impact")
+		Expect(recorder.Events).To(BeEmpty(), "the event should only be emitted once")
+	})
+
+	It("should not report Helm releases kept by the Detach deletion policy", func() {
+		cluster.Spec.DeletionPolicy = greenhousev1alpha1.ClusterDeletionPolicyDetach
+		cluster.SetAnnotations(map[string]string{
+			greenhouseapis.MarkClusterDeletionAnnotation:     "true",
+			greenhouseapis.ScheduleClusterDeletionAnnotation: time.Now().Add(48 * time.Hour).Format(time.DateTime),
+		})
+		impact, err := r.reconcileDeletionImpact(test.Ctx, cluster)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error reconciling the deletion impact")
+		Expect(impact.Plugins).To(Equal([]string{"dashboard", "ingress"}), "the Plugins should still be reported")
+		Expect(impact.HelmReleases).To(BeEmpty(), "the detached Helm releases should not be reported")
+		Expect(recorder.Events).To(Receive(ContainSubstring("removes 2 Plugins, no Helm releases, 1 TeamRoleBindings and 1 exposed services")))
+	})
+})
@@ -0,0 +1,345 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+)
+
+const (
+	// DefaultDeprecatedAPIScanInterval is the default interval in which clusters are scanned for removed API versions.
+	DefaultDeprecatedAPIScanInterval = 6 * time.Hour
+	// deprecatedAPIScanMinorVersions is the number of upcoming minor Kubernetes versions removed API versions are reported for.
+	deprecatedAPIScanMinorVersions = 2
+	// deprecatedAPIScanPageSize is the number of objects listed at once when scanning the cluster.
+	deprecatedAPIScanPageSize = 500
+)
+
+// removedAPI is an API version that is removed in a Kubernetes version.
+type removedAPI struct {
+	groupVersion schema.GroupVersion
+	kind         string
+	removedIn    string
+	replacement  string
+}
+
+// removedAPIs are the API versions removed from Kubernetes, see https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var removedAPIs = []removedAPI{
+	{schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1beta1"}, "MutatingWebhookConfiguration", "1.22", "admissionregistration.k8s.io/v1"},
+	{schema.GroupVersion{Group: "admissionregistration.k8s.io", Version: "v1beta1"}, "ValidatingWebhookConfiguration", "1.22", "admissionregistration.k8s.io/v1"},
+	{schema.GroupVersion{Group: "apiextensions.k8s.io", Version: "v1beta1"}, "CustomResourceDefinition", "1.22", "apiextensions.k8s.io/v1"},
+	{schema.GroupVersion{Group: "apiregistration.k8s.io", Version: "v1beta1"}, "APIService", "1.22", "apiregistration.k8s.io/v1"},
+	{schema.GroupVersion{Group: "certificates.k8s.io", Version: "v1beta1"}, "CertificateSigningRequest", "1.22", "certificates.k8s.io/v1"},
+	{schema.GroupVersion{Group: "coordination.k8s.io", Version: "v1beta1"}, "Lease", "1.22", "coordination.k8s.io/v1"},
+	{schema.GroupVersion{Group: "extensions", Version: "v1beta1"}, "Ingress", "1.22", "networking.k8s.io/v1"},
+	{schema.GroupVersion{Group: "networking.k8s.io", Version: "v1beta1"}, "Ingress", "1.22", "networking.k8s.io/v1"},
+	{schema.GroupVersion{Group: "networking.k8s.io", Version: "v1beta1"}, "IngressClass", "1.22", "networking.k8s.io/v1"},
+	{schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1beta1"}, "ClusterRole", "1.22", "rbac.authorization.k8s.io/v1"},
+	{schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1beta1"}, "ClusterRoleBinding", "1.22", "rbac.authorization.k8s.io/v1"},
+	{schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1beta1"}, "Role", "1.22", "rbac.authorization.k8s.io/v1"},
+	{schema.GroupVersion{Group: "rbac.authorization.k8s.io", Version: "v1beta1"}, "RoleBinding", "1.22", "rbac.authorization.k8s.io/v1"},
+	{schema.GroupVersion{Group: "scheduling.k8s.io", Version: "v1beta1"}, "PriorityClass", "1.22", "scheduling.k8s.io/v1"},
+	{schema.GroupVersion{Group: "storage.k8s.io", Version: "v1beta1"}, "CSIDriver", "1.22", "storage.k8s.io/v1"},
+	{schema.GroupVersion{Group: "storage.k8s.io", Version: "v1beta1"}, "CSINode", "1.22", "storage.k8s.io/v1"},
+	{schema.GroupVersion{Group: "storage.k8s.io", Version: "v1beta1"}, "StorageClass", "1.22", "storage.k8s.io/v1"},
+	{schema.GroupVersion{Group: "storage.k8s.io", Version: "v1beta1"}, "VolumeAttachment", "1.22", "storage.k8s.io/v1"},
+	{schema.GroupVersion{Group: "batch", Version: "v1beta1"}, "CronJob", "1.25", "batch/v1"},
+	{schema.GroupVersion{Group: "discovery.k8s.io", Version: "v1beta1"}, "EndpointSlice", "1.25", "discovery.k8s.io/v1"},
+	{schema.GroupVersion{Group: "events.k8s.io", Version: "v1beta1"}, "Event", "1.25", "events.k8s.io/v1"},
+	{schema.GroupVersion{Group: "autoscaling", Version: "v2beta1"}, "HorizontalPodAutoscaler", "1.25", "autoscaling/v2"},
+	{schema.GroupVersion{Group: "policy", Version: "v1beta1"}, "PodDisruptionBudget", "1.25", "policy/v1"},
+	{schema.GroupVersion{Group: "policy", Version: "v1beta1"}, "PodSecurityPolicy", "1.25", ""},
+	{schema.GroupVersion{Group: "node.k8s.io", Version: "v1beta1"}, "RuntimeClass", "1.25", "node.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1"}, "FlowSchema", "1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta1"}, "PriorityLevelConfiguration", "1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{schema.GroupVersion{Group: "autoscaling", Version: "v2beta2"}, "HorizontalPodAutoscaler", "1.26", "autoscaling/v2"},
+	{schema.GroupVersion{Group: "storage.k8s.io", Version: "v1beta1"}, "CSIStorageCapacity", "1.27", "storage.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2"}, "FlowSchema", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta2"}, "PriorityLevelConfiguration", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3"}, "FlowSchema", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+	{schema.GroupVersion{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3"}, "PriorityLevelConfiguration", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// removedAPIsUpTo returns the API versions removed after the current and up to the target Kubernetes version.
+// If current is nil, all API versions removed up to the target version are returned.
+func removedAPIsUpTo(current, target *version.Version) []removedAPI {
+	var apis []removedAPI
+	for _, api := range removedAPIs {
+		removedIn := version.MustParseGeneric(api.removedIn)
+		if (current == nil || current.LessThan(removedIn)) && !target.LessThan(removedIn) {
+			apis = append(apis, api)
+		}
+	}
+	return apis
+}
+
+func (api removedAPI) usage(count int) greenhousev1alpha1.DeprecatedAPIUsage {
+	return greenhousev1alpha1.DeprecatedAPIUsage{
+		APIVersion:       api.groupVersion.String(),
+		Kind:             api.kind,
+		RemovedInVersion: api.removedIn,
+		Replacement:      api.replacement,
+		Count:            count,
+	}
+}
+
+// reconcileDeprecatedAPIs scans the cluster for removed API versions if the last scan is due.
+// Failing to scan the cluster does not fail the reconciliation, the previous report is kept instead.
+// The time of a failed scan is recorded as well, so a failing scan is not repeated on every reconciliation.
+func (r *RemoteClusterReconciler) reconcileDeprecatedAPIs(ctx context.Context, restClientGetter *clientutil.RestClientGetter, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster) {
+	if r.DeprecatedAPIScanInterval <= 0 {
+		return
+	}
+	if report := cluster.Status.DeprecatedAPIs; report != nil && time.Since(report.LastScanTime.Time) < r.DeprecatedAPIScanInterval {
+		return
+	}
+	report, err := scanDeprecatedAPIs(ctx, r.Client, restClientGetter, remoteClient, cluster)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to scan cluster for deprecated APIs", "cluster", cluster.Name)
+		report = new(greenhousev1alpha1.ClusterDeprecatedAPIReport)
+		if cluster.Status.DeprecatedAPIs != nil {
+			report = cluster.Status.DeprecatedAPIs.DeepCopy()
+		}
+		report.LastScanTime = metav1.Now()
+		report.LastScanError = err.Error()
+		cluster.Status.DeprecatedAPIs = report
+		return
+	}
+	cluster.Status.DeprecatedAPIs = report
+	updateDeprecatedAPIMetrics(cluster)
+}
+
+// scanDeprecatedAPIs reports the API versions removed up to the next minor Kubernetes versions that are used by objects in the cluster or the Helm releases of its Plugins.
+// API versions already removed in the current Kubernetes version are reported separately, as they no longer block an upgrade of the cluster.
+func scanDeprecatedAPIs(ctx context.Context, c client.Client, restClientGetter *clientutil.RestClientGetter, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster) (*greenhousev1alpha1.ClusterDeprecatedAPIReport, error) {
+	discoveryClient, err := restClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	serverVersion, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+	currentVersion, err := version.ParseGeneric(serverVersion.GitVersion)
+	if err != nil {
+		return nil, err
+	}
+	currentVersion = version.MajorMinor(currentVersion.Major(), currentVersion.Minor())
+	targetVersion := version.MajorMinor(currentVersion.Major(), currentVersion.Minor()+deprecatedAPIScanMinorVersions)
+	upcomingAPIs := removedAPIsUpTo(currentVersion, targetVersion)
+	alreadyRemovedAPIs := removedAPIsUpTo(nil, currentVersion)
+
+	report := &greenhousev1alpha1.ClusterDeprecatedAPIReport{
+		TargetKubernetesVersion: fmt.Sprintf("%d.%d", targetVersion.Major(), targetVersion.Minor()),
+		LastScanTime:            metav1.Now(),
+	}
+	report.Objects, err = scanObjectsForRemovedAPIs(ctx, discoveryClient, remoteClient, upcomingAPIs)
+	if err != nil {
+		return nil, err
+	}
+	report.RemovedObjects, err = scanObjectsForRemovedAPIs(ctx, discoveryClient, remoteClient, alreadyRemovedAPIs)
+	if err != nil {
+		return nil, err
+	}
+	report.Plugins, report.RemovedPlugins, err = scanPluginsForRemovedAPIs(ctx, c, restClientGetter, cluster, upcomingAPIs, alreadyRemovedAPIs)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// scanObjectsForRemovedAPIs counts the objects last written with a removed API version, as recorded in their managed fields.
+// Objects are listed with any served version of the API group, as the removed version might no longer be served.
+func scanObjectsForRemovedAPIs(ctx context.Context, discoveryClient discovery.DiscoveryInterface, remoteClient client.Client, apis []removedAPI) ([]greenhousev1alpha1.DeprecatedAPIUsage, error) {
+	var usages []greenhousev1alpha1.DeprecatedAPIUsage
+	for _, api := range apis {
+		listGroupVersion, err := servedGroupVersionForKind(discoveryClient, api)
+		if err != nil {
+			return nil, err
+		}
+		if listGroupVersion == nil {
+			continue
+		}
+		count, err := countObjectsManagedWithAPI(ctx, remoteClient, *listGroupVersion, api)
+		if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) || apierrors.IsMethodNotSupported(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s %s: %w", listGroupVersion, api.kind, err)
+		}
+		if count > 0 {
+			usages = append(usages, api.usage(count))
+		}
+	}
+	return usages, nil
+}
+
+// countObjectsManagedWithAPI counts the objects whose managed fields record the removed API version.
+// The objects are listed in pages to limit the memory used for kinds with many objects.
+func countObjectsManagedWithAPI(ctx context.Context, remoteClient client.Client, listGroupVersion schema.GroupVersion, api removedAPI) (int, error) {
+	count := 0
+	continueToken := ""
+	for {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(listGroupVersion.WithKind(api.kind + "List"))
+		if err := remoteClient.List(ctx, list, client.Limit(deprecatedAPIScanPageSize), client.Continue(continueToken)); err != nil {
+			return 0, err
+		}
+		for _, obj := range list.Items {
+			if slices.ContainsFunc(obj.GetManagedFields(), func(entry metav1.ManagedFieldsEntry) bool {
+				return entry.APIVersion == api.groupVersion.String()
+			}) {
+				count++
+			}
+		}
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			return count, nil
+		}
+	}
+}
+
+// servedGroupVersionForKind returns the removed group version if it is still served, otherwise the version of its replacement if served.
+func servedGroupVersionForKind(discoveryClient discovery.DiscoveryInterface, api removedAPI) (*schema.GroupVersion, error) {
+	candidates := []schema.GroupVersion{api.groupVersion}
+	if api.replacement != "" {
+		replacement, err := schema.ParseGroupVersion(api.replacement)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, replacement)
+	}
+	for _, gv := range candidates {
+		resources, err := discoveryClient.ServerResourcesForGroupVersion(gv.String())
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if slices.ContainsFunc(resources.APIResources, func(resource metav1.APIResource) bool {
+			return resource.Kind == api.kind
+		}) {
+			return &gv, nil
+		}
+	}
+	return nil, nil
+}
+
+// scanPluginsForRemovedAPIs reports the upcoming and the already removed API versions used in the manifests of the Helm releases of the Plugins deployed to the cluster.
+func scanPluginsForRemovedAPIs(ctx context.Context, c client.Client, restClientGetter *clientutil.RestClientGetter, cluster *greenhousev1alpha1.Cluster, upcomingAPIs, alreadyRemovedAPIs []removedAPI) (upcoming, removed []greenhousev1alpha1.PluginDeprecatedAPIUsage, err error) {
+	pluginList := &greenhousev1alpha1.PluginList{}
+	if err := c.List(ctx, pluginList, client.InNamespace(cluster.GetNamespace()), client.MatchingLabels{greenhouseapis.LabelKeyCluster: cluster.GetName()}); err != nil {
+		return nil, nil, fmt.Errorf("failed to list plugins: %w", err)
+	}
+	for _, plugin := range pluginList.Items {
+		helmRelease, err := helm.GetReleaseForHelmChartFromPlugin(ctx, restClientGetter, &plugin)
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get helm release of plugin %s: %w", plugin.GetName(), err)
+		}
+		upcomingUsages, err := removedAPIsInManifest(helmRelease.Manifest, upcomingAPIs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse manifest of plugin %s: %w", plugin.GetName(), err)
+		}
+		if len(upcomingUsages) > 0 {
+			upcoming = append(upcoming, greenhousev1alpha1.PluginDeprecatedAPIUsage{Name: plugin.GetName(), APIs: upcomingUsages})
+		}
+		removedUsages, err := removedAPIsInManifest(helmRelease.Manifest, alreadyRemovedAPIs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse manifest of plugin %s: %w", plugin.GetName(), err)
+		}
+		if len(removedUsages) > 0 {
+			removed = append(removed, greenhousev1alpha1.PluginDeprecatedAPIUsage{Name: plugin.GetName(), APIs: removedUsages})
+		}
+	}
+	byName := func(a, b greenhousev1alpha1.PluginDeprecatedAPIUsage) int {
+		return strings.Compare(a.Name, b.Name)
+	}
+	slices.SortFunc(upcoming, byName)
+	slices.SortFunc(removed, byName)
+	return upcoming, removed, nil
+}
+
+// removedAPIsInManifest counts the objects in the manifest using one of the given removed API versions.
+func removedAPIsInManifest(manifest string, apis []removedAPI) ([]greenhousev1alpha1.DeprecatedAPIUsage, error) {
+	counts := make(map[int]int)
+	for _, document := range releaseutil.SplitManifests(manifest) {
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal([]byte(document), &typeMeta); err != nil {
+			return nil, err
+		}
+		idx := slices.IndexFunc(apis, func(api removedAPI) bool {
+			return api.groupVersion.String() == typeMeta.APIVersion && api.kind == typeMeta.Kind
+		})
+		if idx >= 0 {
+			counts[idx]++
+		}
+	}
+	var usages []greenhousev1alpha1.DeprecatedAPIUsage
+	for idx, api := range apis {
+		if count := counts[idx]; count > 0 {
+			usages = append(usages, api.usage(count))
+		}
+	}
+	return usages, nil
+}
+
+// deprecatedAPIsCondition returns a condition reflecting whether the cluster or its Plugins use API versions removed in upcoming or already removed in the current Kubernetes version.
+func deprecatedAPIsCondition(cluster *greenhousev1alpha1.Cluster) greenhousev1alpha1.Condition {
+	report := cluster.Status.DeprecatedAPIs
+	if report == nil {
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.DeprecatedAPIsInUse, "", "cluster was not scanned for deprecated APIs")
+	}
+	// The target version is only set by a successful scan.
+	if report.TargetKubernetesVersion == "" {
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.DeprecatedAPIsInUse, "", "failed to scan cluster for deprecated APIs: "+report.LastScanError)
+	}
+	var messages []string
+	if blockers := deprecatedAPIUsers(report.Objects, report.Plugins); len(blockers) > 0 {
+		messages = append(messages, fmt.Sprintf("upgrade to Kubernetes %s is blocked by %s", report.TargetKubernetesVersion, strings.Join(blockers, ", ")))
+	}
+	if users := deprecatedAPIUsers(report.RemovedObjects, report.RemovedPlugins); len(users) > 0 {
+		messages = append(messages, "already removed API versions are used by "+strings.Join(users, ", "))
+	}
+	if len(messages) == 0 {
+		return greenhousev1alpha1.FalseCondition(greenhousev1alpha1.DeprecatedAPIsInUse, "", "")
+	}
+	return greenhousev1alpha1.TrueCondition(greenhousev1alpha1.DeprecatedAPIsInUse, "", strings.Join(messages, "; "))
+}
+
+// deprecatedAPIUsers lists the objects and Plugins using removed API versions for the condition message.
+func deprecatedAPIUsers(objects []greenhousev1alpha1.DeprecatedAPIUsage, plugins []greenhousev1alpha1.PluginDeprecatedAPIUsage) []string {
+	var users []string
+	for _, usage := range objects {
+		users = append(users, fmt.Sprintf("%d %s %s", usage.Count, usage.APIVersion, usage.Kind))
+	}
+	for _, plugin := range plugins {
+		users = append(users, "plugin "+plugin.Name)
+	}
+	return users
+}
@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Deprecated API scanning", func() {
+	It("should only consider APIs removed after the current and up to the target version", func() {
+		removedIn := func(apis []removedAPI) []string {
+			var versions []string
+			for _, api := range apis {
+				versions = append(versions, api.removedIn)
+			}
+			return versions
+		}
+		upcoming := removedIn(removedAPIsUpTo(version.MajorMinor(1, 24), version.MajorMinor(1, 26)))
+		Expect(upcoming).To(ContainElements("1.25", "1.26"))
+		Expect(upcoming).ToNot(ContainElements("1.22", "1.27"), "APIs removed up to the current or after the target version should not be considered")
+
+		removed := removedIn(removedAPIsUpTo(nil, version.MajorMinor(1, 24)))
+		Expect(removed).To(ContainElement("1.22"))
+		Expect(removed).ToNot(ContainElement("1.25"))
+	})
+
+	It("should find removed APIs in a release manifest", func() {
+		manifest := `---
+# Source: chart/templates/pdb.yaml
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: a
+---
+# Source: chart/templates/pdb2.yaml
+apiVersion: policy/v1beta1
+kind: PodDisruptionBudget
+metadata:
+  name: b
+---
+# Source: chart/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: c
+`
+		usages, err := removedAPIsInManifest(manifest, removedAPIsUpTo(version.MajorMinor(1, 24), version.MajorMinor(1, 27)))
+		Expect(err).ToNot(HaveOccurred(), "there should be no error parsing the manifest")
+		Expect(usages).To(Equal([]greenhousev1alpha1.DeprecatedAPIUsage{
+			{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", RemovedInVersion: "1.25", Replacement: "policy/v1", Count: 2},
+		}))
+	})
+
+	It("should count objects last written with a removed API version", func() {
+		newPDB := func(name, apiVersion string) *policyv1.PodDisruptionBudget {
+			return &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{
+				Namespace:     "default",
+				Name:          name,
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: apiVersion}},
+			}}
+		}
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).
+			WithObjects(newPDB("old", "policy/v1beta1"), newPDB("new", "policy/v1")).Build()
+		discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+			GroupVersion: "policy/v1",
+			APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget", Namespaced: true}},
+		}}}}
+
+		usages, err := scanObjectsForRemovedAPIs(test.Ctx, discoveryClient, remoteClient, removedAPIsUpTo(version.MajorMinor(1, 24), version.MajorMinor(1, 27)))
+		Expect(err).ToNot(HaveOccurred(), "there should be no error scanning the objects")
+		Expect(usages).To(Equal([]greenhousev1alpha1.DeprecatedAPIUsage{
+			{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", RemovedInVersion: "1.25", Replacement: "policy/v1", Count: 1},
+		}))
+	})
+
+	It("should report the blockers of an upgrade in the condition", func() {
+		cluster := &greenhousev1alpha1.Cluster{}
+		Expect(deprecatedAPIsCondition(cluster).Status).To(Equal(metav1.ConditionUnknown))
+
+		cluster.Status.DeprecatedAPIs = &greenhousev1alpha1.ClusterDeprecatedAPIReport{TargetKubernetesVersion: "1.27"}
+		Expect(deprecatedAPIsCondition(cluster).Status).To(Equal(metav1.ConditionFalse))
+
+		cluster.Status.DeprecatedAPIs.Plugins = []greenhousev1alpha1.PluginDeprecatedAPIUsage{{Name: "ingress"}}
+		condition := deprecatedAPIsCondition(cluster)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("upgrade to Kubernetes 1.27 is blocked by plugin ingress"))
+
+		cluster.Status.DeprecatedAPIs.Plugins = nil
+		cluster.Status.DeprecatedAPIs.RemovedObjects = []greenhousev1alpha1.DeprecatedAPIUsage{{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", Count: 1}}
+		condition = deprecatedAPIsCondition(cluster)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Message).To(Equal("already removed API versions are used by 1 policy/v1beta1 PodDisruptionBudget"), "already removed APIs should not be reported as blockers of the upgrade")
+	})
+
+	It("should list the objects in pages", func() {
+		newPDB := func(name string) *policyv1.PodDisruptionBudget {
+			return &policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{
+				Namespace:     "default",
+				Name:          name,
+				ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "policy/v1beta1"}},
+			}}
+		}
+		var pages int
+		// The fake client does not paginate, so every page returns one object until the last object is returned.
+		remoteClient := interceptor.NewClient(fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(newPDB("a"), newPDB("b"), newPDB("c")).Build(), interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				listOpts := (&client.ListOptions{}).ApplyOptions(opts)
+				Expect(listOpts.Limit).To(BeEquivalentTo(deprecatedAPIScanPageSize), "the objects should be listed in pages")
+				if err := c.List(ctx, list); err != nil {
+					return err
+				}
+				metadataList := list.(*metav1.PartialObjectMetadataList) //nolint:errcheck
+				metadataList.Items = metadataList.Items[pages : pages+1]
+				pages++
+				if pages < 3 {
+					metadataList.SetContinue(fmt.Sprintf("page-%d", pages))
+				}
+				return nil
+			},
+		})
+		discoveryClient := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{{
+			GroupVersion: "policy/v1",
+			APIResources: []metav1.APIResource{{Name: "poddisruptionbudgets", Kind: "PodDisruptionBudget", Namespaced: true}},
+		}}}}
+
+		usages, err := scanObjectsForRemovedAPIs(test.Ctx, discoveryClient, remoteClient, removedAPIsUpTo(version.MajorMinor(1, 24), version.MajorMinor(1, 25)))
+		Expect(err).ToNot(HaveOccurred(), "there should be no error scanning the objects")
+		Expect(pages).To(Equal(3), "all pages should be listed")
+		Expect(usages).To(Equal([]greenhousev1alpha1.DeprecatedAPIUsage{
+			{APIVersion: "policy/v1beta1", Kind: "PodDisruptionBudget", RemovedInVersion: "1.25", Replacement: "policy/v1", Count: 3},
+		}))
+	})
+
+	It("should record failed scans and keep the previous results", func() {
+		r := &RemoteClusterReconciler{DeprecatedAPIScanInterval: time.Hour}
+		restClientGetter := clientutil.NewRestClientGetterFromBytes([]byte("invalid"), "")
+		cluster := &greenhousev1alpha1.Cluster{}
+
+		r.reconcileDeprecatedAPIs(test.Ctx, restClientGetter, nil, cluster)
+		Expect(cluster.Status.DeprecatedAPIs).ToNot(BeNil(), "the failed scan should be recorded")
+		Expect(cluster.Status.DeprecatedAPIs.LastScanTime.IsZero()).To(BeFalse(), "the time of the failed scan should be recorded")
+		Expect(cluster.Status.DeprecatedAPIs.LastScanError).ToNot(BeEmpty())
+		Expect(deprecatedAPIsCondition(cluster).Status).To(Equal(metav1.ConditionUnknown), "the usage of deprecated APIs is unknown without a successful scan")
+
+		By("not scanning again before the interval passed")
+		lastScanTime := cluster.Status.DeprecatedAPIs.LastScanTime
+		r.reconcileDeprecatedAPIs(test.Ctx, restClientGetter, nil, cluster)
+		Expect(cluster.Status.DeprecatedAPIs.LastScanTime).To(Equal(lastScanTime), "the failed scan should not be repeated before the interval passed")
+
+		By("keeping the results of the last successful scan")
+		cluster.Status.DeprecatedAPIs = &greenhousev1alpha1.ClusterDeprecatedAPIReport{
+			TargetKubernetesVersion: "1.27",
+			Plugins:                 []greenhousev1alpha1.PluginDeprecatedAPIUsage{{Name: "ingress"}},
+			LastScanTime:            metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+		}
+		r.reconcileDeprecatedAPIs(test.Ctx, restClientGetter, nil, cluster)
+		Expect(cluster.Status.DeprecatedAPIs.LastScanTime.Time).To(BeTemporally("~", time.Now(), time.Minute))
+		Expect(cluster.Status.DeprecatedAPIs.LastScanError).ToNot(BeEmpty())
+		Expect(cluster.Status.DeprecatedAPIs.Plugins).To(HaveLen(1), "the results of the last successful scan should be kept")
+		Expect(deprecatedAPIsCondition(cluster).Status).To(Equal(metav1.ConditionTrue))
+	})
+})
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/lifecycle"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = DescribeTable("Deleting the plugins of a cluster",
+	func(deletionPolicy greenhousev1alpha1.ClusterDeletionPolicy, expectDetached bool) {
+		cluster := &greenhousev1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"},
+			Spec:       greenhousev1alpha1.ClusterSpec{DeletionPolicy: deletionPolicy},
+		}
+		plugin := &greenhousev1alpha1.Plugin{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "test-org",
+				Name:       "ingress",
+				Labels:     map[string]string{greenhouseapis.LabelKeyCluster: "test-cluster"},
+				Finalizers: []string{lifecycle.CommonCleanupFinalizer},
+			},
+		}
+		k8sClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(plugin).Build()
+
+		count, err := deletePlugins(test.Ctx, k8sClient, cluster)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error deleting the plugins")
+		Expect(count).To(Equal(1), "one plugin should be deleted")
+
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKeyFromObject(plugin), plugin)).To(Succeed())
+		Expect(plugin.GetDeletionTimestamp()).ToNot(BeNil(), "the plugin should be marked for deletion")
+		if expectDetached {
+			Expect(plugin.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.PluginDetachAnnotation, "true"), "the plugin should be detached")
+		} else {
+			Expect(plugin.GetAnnotations()).ToNot(HaveKey(greenhouseapis.PluginDetachAnnotation), "the plugin should not be detached")
+		}
+	},
+	Entry("with the default deletion policy", greenhousev1alpha1.ClusterDeletionPolicy(""), false),
+	Entry("with the Delete deletion policy", greenhousev1alpha1.ClusterDeletionPolicyDelete, false),
+	Entry("with the Detach deletion policy", greenhousev1alpha1.ClusterDeletionPolicyDetach, true),
+)
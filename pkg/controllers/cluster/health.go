@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+const (
+	// healthProbeTimeout is the timeout of each health probe against a cluster.
+	healthProbeTimeout = 10 * time.Second
+	// certificateExpiryThreshold is the remaining validity of the API server certificate below which it is considered about to expire.
+	certificateExpiryThreshold = 7 * 24 * time.Hour
+	// dnsLabelKey and dnsLabelValue select the pods of the cluster DNS. The label is used by both CoreDNS and kube-dns.
+	dnsLabelKey   = "k8s-app"
+	dnsLabelValue = "kube-dns"
+	// metricsAPIServiceName is the name of the APIService registered by the metrics-server.
+	metricsAPIServiceName = "v1beta1.metrics.k8s.io"
+)
+
+var apiServiceGVK = schema.GroupVersionKind{Group: "apiregistration.k8s.io", Version: "v1", Kind: "APIService"}
+
+// clusterHealth holds the results of the health probes of a cluster.
+type clusterHealth struct {
+	apiServerReady            greenhousev1alpha1.Condition
+	apiServerCertificateValid greenhousev1alpha1.Condition
+	dnsReady                  greenhousev1alpha1.Condition
+	metricsServerReady        greenhousev1alpha1.Condition
+	// readyzLatency is the duration of the request to the /readyz endpoint of the API server.
+	readyzLatency time.Duration
+	// certificateExpiry is the expiry of the serving certificate of the API server. It is zero if unknown.
+	certificateExpiry time.Time
+}
+
+func (h clusterHealth) conditions() []greenhousev1alpha1.Condition {
+	return []greenhousev1alpha1.Condition{h.apiServerReady, h.apiServerCertificateValid, h.dnsReady, h.metricsServerReady}
+}
+
+// unknownClusterHealth returns the health of a cluster that cannot be probed.
+func unknownClusterHealth(message string) clusterHealth {
+	return clusterHealth{
+		apiServerReady:            greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.APIServerReady, "", message),
+		apiServerCertificateValid: greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.APIServerCertificateValid, "", message),
+		dnsReady:                  greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ClusterDNSReady, "", message),
+		metricsServerReady:        greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.MetricsServerReady, "", message),
+	}
+}
+
+// reconcileHealth probes the API server and the core components of the cluster.
+func (r *RemoteClusterReconciler) reconcileHealth(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter) clusterHealth {
+	restConfig, err := restClientGetter.ToRESTConfig()
+	if err != nil {
+		return unknownClusterHealth(err.Error())
+	}
+	health := unknownClusterHealth("")
+	health.apiServerReady, health.apiServerCertificateValid, health.readyzLatency, health.certificateExpiry = probeAPIServer(ctx, restConfig)
+
+	remoteClient, err := clientutil.NewK8sClientFromRestClientGetter(restClientGetter)
+	if err != nil {
+		health.dnsReady.Message = err.Error()
+		health.metricsServerReady.Message = err.Error()
+		return health
+	}
+	health.dnsReady = probeDNS(ctx, remoteClient)
+	health.metricsServerReady = probeMetricsServer(ctx, remoteClient)
+	return health
+}
+
+// probeAPIServer requests the /readyz endpoint of the API server and inspects its serving certificate.
+func probeAPIServer(ctx context.Context, restConfig *rest.Config) (
+	readyCondition, certificateCondition greenhousev1alpha1.Condition,
+	latency time.Duration,
+	certificateExpiry time.Time,
+) {
+
+	readyCondition = greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.APIServerReady, "", "")
+	certificateCondition = greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.APIServerCertificateValid, "", "")
+
+	httpClient, err := rest.HTTPClientFor(restConfig)
+	if err != nil {
+		readyCondition.Message = err.Error()
+		return
+	}
+	serverURL, _, err := rest.DefaultServerUrlFor(restConfig)
+	if err != nil {
+		readyCondition.Message = err.Error()
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL.JoinPath("/readyz").String(), http.NoBody)
+	if err != nil {
+		readyCondition.Message = err.Error()
+		return
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	latency = time.Since(start)
+	if err != nil {
+		readyCondition = greenhousev1alpha1.FalseCondition(greenhousev1alpha1.APIServerReady, "", err.Error())
+		return
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:errcheck
+
+	if resp.StatusCode == http.StatusOK {
+		readyCondition = greenhousev1alpha1.TrueCondition(greenhousev1alpha1.APIServerReady, "", "")
+	} else {
+		message := resp.Status
+		if trimmed := strings.TrimSpace(string(body)); trimmed != "" {
+			message += ": " + trimmed
+		}
+		readyCondition = greenhousev1alpha1.FalseCondition(greenhousev1alpha1.APIServerReady, "", message)
+	}
+
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		certificateCondition.Message = "API server is not served via TLS"
+		return
+	}
+	certificateExpiry = resp.TLS.PeerCertificates[0].NotAfter
+	switch remaining := time.Until(certificateExpiry); {
+	case remaining <= 0:
+		certificateCondition = greenhousev1alpha1.FalseCondition(greenhousev1alpha1.APIServerCertificateValid, "", "certificate expired at "+certificateExpiry.Format(time.DateTime))
+	case remaining < certificateExpiryThreshold:
+		certificateCondition = greenhousev1alpha1.FalseCondition(greenhousev1alpha1.APIServerCertificateValid, "", "certificate expires at "+certificateExpiry.Format(time.DateTime))
+	default:
+		certificateCondition = greenhousev1alpha1.TrueCondition(greenhousev1alpha1.APIServerCertificateValid, "", "")
+	}
+	return
+}
+
+// probeDNS checks the readiness of the cluster DNS pods. The condition is unknown if the cluster has no DNS pods with the well-known label.
+func probeDNS(ctx context.Context, remoteClient client.Client) greenhousev1alpha1.Condition {
+	var podList = new(corev1.PodList)
+	if err := remoteClient.List(ctx, podList, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{dnsLabelKey: dnsLabelValue}); err != nil {
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ClusterDNSReady, "", err.Error())
+	}
+	if len(podList.Items) == 0 {
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ClusterDNSReady, "", "no DNS pods found")
+	}
+	for _, pod := range podList.Items {
+		if isPodReady(pod) {
+			return greenhousev1alpha1.TrueCondition(greenhousev1alpha1.ClusterDNSReady, "", "")
+		}
+	}
+	return greenhousev1alpha1.FalseCondition(greenhousev1alpha1.ClusterDNSReady, "", "no DNS pod is ready")
+}
+
+// probeMetricsServer checks the availability of the APIService registered by the metrics-server.
+// The condition is unknown if the metrics-server is not installed.
+func probeMetricsServer(ctx context.Context, remoteClient client.Client) greenhousev1alpha1.Condition {
+	apiService := new(unstructured.Unstructured)
+	apiService.SetGroupVersionKind(apiServiceGVK)
+	if err := remoteClient.Get(ctx, types.NamespacedName{Name: metricsAPIServiceName}, apiService); err != nil {
+		if apierrors.IsNotFound(err) {
+			return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.MetricsServerReady, "", "metrics-server not installed")
+		}
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.MetricsServerReady, "", err.Error())
+	}
+	conditions, _, err := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	if err != nil {
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.MetricsServerReady, "", err.Error())
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		if condition["status"] == string(corev1.ConditionTrue) {
+			return greenhousev1alpha1.TrueCondition(greenhousev1alpha1.MetricsServerReady, "", "")
+		}
+		message, _ := condition["message"].(string) //nolint:errcheck
+		return greenhousev1alpha1.FalseCondition(greenhousev1alpha1.MetricsServerReady, "", message)
+	}
+	return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.MetricsServerReady, "", "availability of metrics-server not reported")
+}
+
+func isPodReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
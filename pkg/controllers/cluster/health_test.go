@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	prometheusTest "github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Cluster health probes", func() {
+	newAPIServer := func(readyzStatus int) (*httptest.Server, *rest.Config) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/readyz" {
+				http.NotFound(w, req)
+				return
+			}
+			w.WriteHeader(readyzStatus)
+			if readyzStatus != http.StatusOK {
+				_, _ = w.Write([]byte("[-]etcd failed: reason withheld")) //nolint:errcheck
+			}
+		}))
+		DeferCleanup(server.Close)
+		restConfig := &rest.Config{
+			Host: server.URL,
+			TLSClientConfig: rest.TLSClientConfig{
+				CAData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}),
+			},
+		}
+		return server, restConfig
+	}
+
+	It("should report a ready API server and its certificate expiry", func() {
+		server, restConfig := newAPIServer(http.StatusOK)
+		readyCondition, certificateCondition, latency, certificateExpiry := probeAPIServer(test.Ctx, restConfig)
+		Expect(readyCondition.IsTrue()).To(BeTrue(), "the API server should be ready")
+		Expect(latency).To(BeNumerically(">", 0), "the latency should be measured")
+		Expect(certificateExpiry).To(BeTemporally("==", server.Certificate().NotAfter), "the expiry of the serving certificate should be reported")
+		Expect(certificateCondition.IsTrue()).To(BeTrue(), "the certificate of the test server should be valid")
+	})
+
+	It("should report an API server that is not ready", func() {
+		_, restConfig := newAPIServer(http.StatusInternalServerError)
+		readyCondition, _, _, _ := probeAPIServer(test.Ctx, restConfig)
+		Expect(readyCondition.IsFalse()).To(BeTrue(), "the API server should not be ready")
+		Expect(readyCondition.Message).To(ContainSubstring("etcd failed"), "the failing check should be part of the message")
+	})
+
+	It("should report the readiness of the cluster DNS", func() {
+		dnsPod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: metav1.NamespaceSystem, Labels: map[string]string{dnsLabelKey: dnsLabelValue}},
+				Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+			}
+		}
+		Expect(probeDNS(test.Ctx, fake.NewClientBuilder().Build()).Status).
+			To(Equal(metav1.ConditionUnknown), "the DNS should be unknown without DNS pods")
+		Expect(probeDNS(test.Ctx, fake.NewClientBuilder().WithObjects(dnsPod("coredns-a", corev1.ConditionFalse)).Build()).Status).
+			To(Equal(metav1.ConditionFalse), "the DNS should not be ready without a ready DNS pod")
+		Expect(probeDNS(test.Ctx, fake.NewClientBuilder().WithObjects(dnsPod("coredns-a", corev1.ConditionFalse), dnsPod("coredns-b", corev1.ConditionTrue)).Build()).Status).
+			To(Equal(metav1.ConditionTrue), "the DNS should be ready with a ready DNS pod")
+	})
+
+	It("should report the availability of the metrics-server", func() {
+		apiService := func(available string) *unstructured.Unstructured {
+			obj := new(unstructured.Unstructured)
+			obj.SetGroupVersionKind(apiServiceGVK)
+			obj.SetName(metricsAPIServiceName)
+			Expect(unstructured.SetNestedSlice(obj.Object, []any{
+				map[string]any{"type": "Available", "status": available, "message": "failing or missing response"},
+			}, "status", "conditions")).To(Succeed())
+			return obj
+		}
+		Expect(probeMetricsServer(test.Ctx, fake.NewClientBuilder().Build()).Status).
+			To(Equal(metav1.ConditionUnknown), "the metrics-server should be unknown if it is not installed")
+		condition := probeMetricsServer(test.Ctx, fake.NewClientBuilder().WithObjects(apiService("False")).Build())
+		Expect(condition.IsFalse()).To(BeTrue(), "the metrics-server should not be ready if the APIService is not available")
+		Expect(condition.Message).To(Equal("failing or missing response"))
+		Expect(probeMetricsServer(test.Ctx, fake.NewClientBuilder().WithObjects(apiService("True")).Build()).Status).
+			To(Equal(metav1.ConditionTrue), "the metrics-server should be ready if the APIService is available")
+	})
+
+	It("should export the results of the health probes as metrics", func() {
+		cluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "health-cluster", Namespace: "health"}}
+		health := unknownClusterHealth("")
+		health.apiServerReady = greenhousev1alpha1.TrueCondition(greenhousev1alpha1.APIServerReady, "", "")
+		health.dnsReady = greenhousev1alpha1.FalseCondition(greenhousev1alpha1.ClusterDNSReady, "", "no DNS pod is ready")
+		updateHealthMetrics(cluster, health)
+
+		Expect(prometheusTest.ToFloat64(healthCheckGauge.WithLabelValues(cluster.Name, cluster.Namespace, string(greenhousev1alpha1.APIServerReady)))).To(BeEquivalentTo(1))
+		Expect(prometheusTest.ToFloat64(healthCheckGauge.WithLabelValues(cluster.Name, cluster.Namespace, string(greenhousev1alpha1.ClusterDNSReady)))).To(BeEquivalentTo(0))
+		Expect(prometheusTest.ToFloat64(healthCheckGauge.WithLabelValues(cluster.Name, cluster.Namespace, string(greenhousev1alpha1.MetricsServerReady)))).To(BeEquivalentTo(-1))
+
+		healthChecks, readyzLatencies := prometheusTest.CollectAndCount(healthCheckGauge), prometheusTest.CollectAndCount(apiServerReadyzLatencyGauge)
+		deleteHealthMetrics(cluster)
+		Expect(prometheusTest.CollectAndCount(healthCheckGauge)).To(Equal(healthChecks-len(health.conditions())), "the health checks of the deleted cluster should be removed")
+		Expect(prometheusTest.CollectAndCount(apiServerReadyzLatencyGauge)).To(Equal(readyzLatencies-1), "the readyz latency of the deleted cluster should be removed")
+	})
+
+	It("should not gate the readiness of the cluster on an unknown DNS state and the metrics-server", func() {
+		r := &RemoteClusterReconciler{}
+		readyCondition := r.reconcileReadyStatus(
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.KubeConfigValid, "", ""),
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.AllNodesReady, "", ""),
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.APIServerReady, "", ""),
+			greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ClusterDNSReady, "", "kube-dns not found"),
+		)
+		Expect(readyCondition.IsTrue()).To(BeTrue(), "the cluster should be ready if the state of the DNS is unknown")
+	})
+
+	It("should not mark the cluster as ready if the DNS is failing", func() {
+		r := &RemoteClusterReconciler{}
+		readyCondition := r.reconcileReadyStatus(
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.KubeConfigValid, "", ""),
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.AllNodesReady, "", ""),
+			greenhousev1alpha1.TrueCondition(greenhousev1alpha1.APIServerReady, "", ""),
+			greenhousev1alpha1.FalseCondition(greenhousev1alpha1.ClusterDNSReady, "", "no DNS pod is ready"),
+		)
+		Expect(readyCondition.IsFalse()).To(BeTrue(), "the cluster should not be ready if the DNS is failing")
+		Expect(readyCondition.Message).To(Equal("no DNS pod is ready"))
+	})
+})
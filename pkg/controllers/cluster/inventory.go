@@ -0,0 +1,178 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+// DefaultInventoryInterval is the default interval in which the inventory of a cluster is collected.
+const DefaultInventoryInterval = time.Hour
+
+var (
+	// regionLabelKeys are the node labels containing the region, in order of precedence.
+	regionLabelKeys = []string{corev1.LabelTopologyRegion, corev1.LabelFailureDomainBetaRegion}
+	// zoneLabelKeys are the node labels containing the zone, in order of precedence.
+	zoneLabelKeys = []string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone}
+	// instanceTypeLabelKeys are the node labels containing the instance type, in order of precedence.
+	instanceTypeLabelKeys = []string{corev1.LabelInstanceTypeStable, corev1.LabelInstanceType}
+)
+
+// reconcileInventory refreshes the inventory of the cluster if it is due and keeps the inventory labels of the cluster in sync.
+// Failing to collect the inventory does not fail the reconciliation, the previous inventory is kept instead.
+func (r *RemoteClusterReconciler) reconcileInventory(ctx context.Context, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster) error {
+	if r.InventoryInterval <= 0 {
+		return nil
+	}
+	inventory := cluster.Status.Inventory
+	if inventory == nil || time.Since(inventory.LastUpdateTime.Time) >= r.InventoryInterval {
+		collected, err := collectInventory(ctx, remoteClient)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to collect cluster inventory", "cluster", cluster.Name)
+		} else {
+			inventory = collected
+		}
+	}
+
+	// Patching the labels refreshes the cluster, so the inventory is set on the status afterwards.
+	if _, err := clientutil.Patch(ctx, r.Client, cluster, func() error {
+		cluster.SetLabels(inventoryLabels(cluster.GetLabels(), inventory, cluster.Status.KubernetesVersion))
+		return nil
+	}); err != nil {
+		return err
+	}
+	cluster.Status.Inventory = inventory
+	return nil
+}
+
+// collectInventory collects the inventory from the nodes and CustomResourceDefinitions of the cluster.
+func collectInventory(ctx context.Context, remoteClient client.Client) (*greenhousev1alpha1.ClusterInventory, error) {
+	var nodeList = new(corev1.NodeList)
+	if err := remoteClient.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+	var crdList = new(apiextensionsv1.CustomResourceDefinitionList)
+	if err := remoteClient.List(ctx, crdList); err != nil {
+		return nil, err
+	}
+
+	inventory := &greenhousev1alpha1.ClusterInventory{
+		NodeCount:      len(nodeList.Items),
+		LastUpdateTime: metav1.Now(),
+	}
+	var (
+		providers, regions                = make(map[string]int), make(map[string]int)
+		zones, runtimes, crdGroups        = make(map[string]struct{}), make(map[string]struct{}), make(map[string]struct{})
+		allocatableCPU, allocatableMemory = resource.Quantity{}, resource.Quantity{}
+	)
+	for _, node := range nodeList.Items {
+		if provider, _, ok := strings.Cut(node.Spec.ProviderID, "://"); ok && provider != "" {
+			providers[provider]++
+		}
+		if region := firstLabelValue(node.Labels, regionLabelKeys); region != "" {
+			regions[region]++
+		}
+		if zone := firstLabelValue(node.Labels, zoneLabelKeys); zone != "" {
+			zones[zone] = struct{}{}
+		}
+		if instanceType := firstLabelValue(node.Labels, instanceTypeLabelKeys); instanceType != "" {
+			if inventory.InstanceTypes == nil {
+				inventory.InstanceTypes = make(map[string]int)
+			}
+			inventory.InstanceTypes[instanceType]++
+		}
+		if runtime := node.Status.NodeInfo.ContainerRuntimeVersion; runtime != "" {
+			runtimes[runtime] = struct{}{}
+		}
+		allocatableCPU.Add(*node.Status.Allocatable.Cpu())
+		allocatableMemory.Add(*node.Status.Allocatable.Memory())
+	}
+	for _, crd := range crdList.Items {
+		crdGroups[crd.Spec.Group] = struct{}{}
+	}
+
+	inventory.Provider = mostCommon(providers)
+	inventory.Region = mostCommon(regions)
+	inventory.Zones = sortedKeys(zones)
+	inventory.ContainerRuntimeVersions = sortedKeys(runtimes)
+	inventory.CRDGroups = sortedKeys(crdGroups)
+	inventory.AllocatableCPU = allocatableCPU
+	inventory.AllocatableMemory = allocatableMemory
+	return inventory, nil
+}
+
+// inventoryLabels returns the labels of the cluster with all inventory labels replaced by the ones derived from the inventory.
+// Labels with values that are not valid label values are omitted.
+func inventoryLabels(labels map[string]string, inventory *greenhousev1alpha1.ClusterInventory, kubernetesVersion string) map[string]string {
+	desired := make(map[string]string)
+	if kubernetesVersion != clusterK8sVersionUnknown {
+		desired[greenhouseapis.LabelKeyClusterKubernetesVersion] = kubernetesVersion
+	}
+	if inventory != nil {
+		desired[greenhouseapis.LabelKeyClusterProvider] = inventory.Provider
+		desired[greenhouseapis.LabelKeyClusterRegion] = inventory.Region
+		if len(inventory.ContainerRuntimeVersions) > 0 {
+			runtime, _, _ := strings.Cut(inventory.ContainerRuntimeVersions[0], "://")
+			if slices.IndexFunc(inventory.ContainerRuntimeVersions, func(v string) bool { return !strings.HasPrefix(v, runtime+"://") }) == -1 {
+				desired[greenhouseapis.LabelKeyClusterContainerRuntime] = runtime
+			}
+		}
+	}
+
+	result := make(map[string]string, len(labels)+len(desired))
+	for key, value := range labels {
+		if !strings.HasPrefix(key, greenhouseapis.LabelKeyPrefixClusterInventory) {
+			result[key] = value
+		}
+	}
+	for key, value := range desired {
+		if value != "" && len(validation.IsValidLabelValue(value)) == 0 {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+func firstLabelValue(labels map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value, ok := labels[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// mostCommon returns the key with the highest count. Ties are broken by the lexically smallest key.
+func mostCommon(counts map[string]int) string {
+	var result string
+	for _, key := range sortedKeys(counts) {
+		if counts[key] > counts[result] {
+			result = key
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Cluster inventory", func() {
+	newNode := func(name, region, instanceType, runtime string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					corev1.LabelTopologyRegion:     region,
+					corev1.LabelTopologyZone:       region + "a",
+					corev1.LabelInstanceTypeStable: instanceType,
+				},
+			},
+			Spec: corev1.NodeSpec{ProviderID: "openstack:///" + name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("2"),
+					corev1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+				NodeInfo: corev1.NodeSystemInfo{ContainerRuntimeVersion: runtime},
+			},
+		}
+	}
+
+	It("should collect the inventory from nodes and CustomResourceDefinitions", func() {
+		remoteClient := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(
+			newNode("node-a", "eu-de-1", "m1.large", "containerd://1.7.2"),
+			newNode("node-b", "eu-de-1", "m1.large", "containerd://1.7.2"),
+			newNode("node-c", "eu-de-2", "m1.xlarge", "containerd://1.7.3"),
+			&apiextensionsv1.CustomResourceDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "prometheuses.monitoring.coreos.com"},
+				Spec:       apiextensionsv1.CustomResourceDefinitionSpec{Group: "monitoring.coreos.com"},
+			},
+		).Build()
+
+		inventory, err := collectInventory(test.Ctx, remoteClient)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error collecting the inventory")
+		Expect(inventory.Provider).To(Equal("openstack"))
+		Expect(inventory.Region).To(Equal("eu-de-1"), "the region of most nodes should be used")
+		Expect(inventory.Zones).To(Equal([]string{"eu-de-1a", "eu-de-2a"}))
+		Expect(inventory.NodeCount).To(Equal(3))
+		Expect(inventory.InstanceTypes).To(Equal(map[string]int{"m1.large": 2, "m1.xlarge": 1}))
+		Expect(inventory.AllocatableCPU.Equal(resource.MustParse("6"))).To(BeTrue(), "the allocatable CPU should be summed up")
+		Expect(inventory.AllocatableMemory.Equal(resource.MustParse("12Gi"))).To(BeTrue(), "the allocatable memory should be summed up")
+		Expect(inventory.ContainerRuntimeVersions).To(Equal([]string{"containerd://1.7.2", "containerd://1.7.3"}))
+		Expect(inventory.CRDGroups).To(Equal([]string{"monitoring.coreos.com"}))
+	})
+
+	It("should derive the labels from the inventory", func() {
+		labels := map[string]string{
+			"owner":                                "team-a",
+			greenhouseapis.LabelKeyClusterProvider: "aws",
+			greenhouseapis.LabelKeyPrefixClusterInventory + "stale": "true",
+		}
+		inventory := &greenhousev1alpha1.ClusterInventory{
+			Region:                   "eu-de-1",
+			ContainerRuntimeVersions: []string{"containerd://1.7.2", "containerd://1.7.3"},
+		}
+		Expect(inventoryLabels(labels, inventory, "v1.32.2")).To(Equal(map[string]string{
+			"owner":                              "team-a",
+			greenhouseapis.LabelKeyClusterRegion: "eu-de-1",
+			greenhouseapis.LabelKeyClusterContainerRuntime:  "containerd",
+			greenhouseapis.LabelKeyClusterKubernetesVersion: "v1.32.2",
+		}), "the inventory labels should be replaced and other labels kept")
+
+		inventory.ContainerRuntimeVersions = append(inventory.ContainerRuntimeVersions, "cri-o://1.31.0")
+		Expect(inventoryLabels(nil, inventory, "v1.30.0+k3s1")).To(Equal(map[string]string{
+			greenhouseapis.LabelKeyClusterRegion: "eu-de-1",
+		}), "mixed container runtimes and invalid label values should be omitted")
+	})
+})
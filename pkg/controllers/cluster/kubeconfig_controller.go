@@ -151,17 +151,8 @@ func (r *KubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			}}
 		kubeconfig.Spec.Kubeconfig.AuthInfo = []v1alpha1.ClusterKubeconfigAuthInfoItem{
 			{
-				Name: "oidc@" + cluster.Name,
-				AuthInfo: v1alpha1.ClusterKubeconfigAuthInfo{
-					AuthProvider: clientcmdapi.AuthProviderConfig{
-						Name: "oidc",
-						Config: map[string]string{
-							"client-id":      oidc.ClientID,
-							"client-secret":  oidc.ClientSecret,
-							"idp-issuer-url": oidc.IssuerURL,
-						},
-					},
-				},
+				Name:     "oidc@" + cluster.Name,
+				AuthInfo: oidc.authInfo(),
 			},
 		}
 		return nil
@@ -175,9 +166,59 @@ func (r *KubeconfigReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 }
 
 type OIDCInfo struct {
-	ClientID     string
-	ClientSecret string
-	IssuerURL    string
+	ClientID         string
+	ClientSecret     string
+	IssuerURL        string
+	CredentialPlugin v1alpha1.KubeconfigCredentialPlugin
+}
+
+// authInfo returns the user of the kubeconfig authenticating with the credential plugin chosen by the organization.
+func (o OIDCInfo) authInfo() v1alpha1.ClusterKubeconfigAuthInfo {
+	switch o.CredentialPlugin {
+	case v1alpha1.KubeconfigCredentialPluginKubelogin:
+		return v1alpha1.ClusterKubeconfigAuthInfo{
+			Exec: &v1alpha1.ClusterKubeconfigExecConfig{
+				APIVersion: "client.authentication.k8s.io/v1beta1",
+				Command:    "kubectl",
+				Args: []string{
+					"oidc-login",
+					"get-token",
+					"--oidc-issuer-url=" + o.IssuerURL,
+					"--oidc-client-id=" + o.ClientID,
+					"--oidc-client-secret=" + o.ClientSecret,
+				},
+				InstallHint:     "kubelogin is required, see https://github.com/int128/kubelogin",
+				InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+			},
+		}
+	case v1alpha1.KubeconfigCredentialPluginGreenhousectl:
+		return v1alpha1.ClusterKubeconfigAuthInfo{
+			Exec: &v1alpha1.ClusterKubeconfigExecConfig{
+				APIVersion: "client.authentication.k8s.io/v1",
+				Command:    "greenhousectl",
+				Args: []string{
+					"kubeconfig",
+					"get-token",
+					"--issuer", o.IssuerURL,
+					"--client-id", o.ClientID,
+					"--client-secret", o.ClientSecret,
+				},
+				InstallHint:     "greenhousectl is required, see https://github.com/cloudoperators/greenhouse/releases",
+				InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+			},
+		}
+	default:
+		return v1alpha1.ClusterKubeconfigAuthInfo{
+			AuthProvider: &clientcmdapi.AuthProviderConfig{
+				Name: "oidc",
+				Config: map[string]string{
+					"client-id":      o.ClientID,
+					"client-secret":  o.ClientSecret,
+					"idp-issuer-url": o.IssuerURL,
+				},
+			},
+		}
+	}
 }
 
 func (r *KubeconfigReconciler) getOIDCInfo(ctx context.Context, orgName string) (OIDCInfo, error) {
@@ -212,9 +253,10 @@ func (r *KubeconfigReconciler) getOIDCInfo(ctx context.Context, orgName string)
 		return OIDCInfo{}, err
 	}
 	oidc := OIDCInfo{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		IssuerURL:    org.Spec.Authentication.OIDCConfig.Issuer,
+		ClientID:         clientID,
+		ClientSecret:     clientSecret,
+		IssuerURL:        org.Spec.Authentication.OIDCConfig.Issuer,
+		CredentialPlugin: org.Spec.Authentication.OIDCConfig.KubeconfigCredentialPlugin,
 	}
 	return oidc, nil
 }
@@ -4,6 +4,7 @@
 package cluster_test
 
 import (
+	"encoding/json"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -206,6 +207,33 @@ users:
 
 	})
 
+	It("should configure the exec credential plugin chosen by the organization", func() {
+		organization := v1alpha1.Organization{}
+		Expect(test.K8sClient.Get(test.Ctx, types.NamespacedName{Name: setup.Namespace(), Namespace: setup.Namespace()}, &organization)).To(Succeed())
+		organization.Spec.Authentication.OIDCConfig.KubeconfigCredentialPlugin = v1alpha1.KubeconfigCredentialPluginGreenhousectl
+		Expect(test.K8sClient.Update(test.Ctx, &organization)).To(Succeed())
+
+		clusterKubeconfig := v1alpha1.ClusterKubeconfig{}
+		Eventually(func(g Gomega) *v1alpha1.ClusterKubeconfigExecConfig {
+			g.Expect(test.K8sClient.Get(test.Ctx, types.NamespacedName{Name: cluster.Name, Namespace: setup.Namespace()}, &clusterKubeconfig)).ShouldNot(HaveOccurred(), "There should be no error getting the ClusterKubeconfig resource")
+			return clusterKubeconfig.Spec.Kubeconfig.AuthInfo[0].AuthInfo.Exec
+		}).ShouldNot(BeNil(), "eventually the ClusterKubeconfig should use the exec credential plugin")
+
+		Expect(clusterKubeconfig.Spec.Kubeconfig.AuthInfo).Should(HaveLen(1))
+		Expect(clusterKubeconfig.Spec.Kubeconfig.AuthInfo[0].AuthInfo.AuthProvider).Should(BeNil(), "the legacy auth-provider should be removed")
+		execConfig := clusterKubeconfig.Spec.Kubeconfig.AuthInfo[0].AuthInfo.Exec
+		Expect(execConfig.Command).Should(Equal("greenhousectl"))
+		Expect(execConfig.Args).Should(ContainElements("--issuer", "new-issuer-url", "--client-id", "new-client-id"))
+
+		By("loading the kubeconfig with clientcmd")
+		kubeconfigData, err := json.Marshal(clusterKubeconfig.Spec.Kubeconfig)
+		Expect(err).ToNot(HaveOccurred())
+		kubeCfg, err := clientcmd.Load(kubeconfigData)
+		Expect(err).ToNot(HaveOccurred(), "the kubeconfig should be loadable by clientcmd")
+		Expect(kubeCfg.AuthInfos["oidc@"+cluster.Name].Exec).ToNot(BeNil(), "the user should use the exec credential plugin")
+		Expect(kubeCfg.AuthInfos["oidc@"+cluster.Name].AuthProvider).To(BeNil(), "the user should not use the auth-provider")
+	})
+
 	It("should fail with ClusterKubeconfig when organization OIDC data is not found", func() {
 		organization := v1alpha1.Organization{}
 		Expect(test.K8sClient.Get(test.Ctx, types.NamespacedName{Name: setup.Namespace(), Namespace: setup.Namespace()}, &organization)).To(Succeed())
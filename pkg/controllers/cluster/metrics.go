@@ -22,13 +22,54 @@ var (
 	secondsToTokenExpiryGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "greenhouse_cluster_kubeconfig_validity_seconds",
+			Help: "Seconds until the token used to access a cluster expires",
 		},
 		[]string{"cluster", "namespace"})
+
+	apiServerReadyzLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_cluster_apiserver_readyz_latency_seconds",
+			Help: "Latency of the request to the /readyz endpoint of the API server of a cluster",
+		},
+		[]string{"cluster", "namespace"})
+
+	secondsToCertificateExpiryGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_cluster_apiserver_certificate_validity_seconds",
+			Help: "Seconds until the serving certificate of the API server of a cluster expires",
+		},
+		[]string{"cluster", "namespace"})
+
+	healthCheckGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_cluster_health_check",
+			Help: "Result of a health check of a cluster: 1 if healthy, 0 if unhealthy and -1 if unknown",
+		},
+		[]string{"cluster", "namespace", "check"})
+
+	deprecatedAPIsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_cluster_deprecated_api_objects",
+			Help: "Number of objects in a cluster or the Helm release of a Plugin using an API version removed in an upcoming Kubernetes version",
+		},
+		[]string{"cluster", "namespace", "plugin", "api_version", "kind", "removed_in"})
+
+	removedAPIsGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_cluster_removed_api_objects",
+			Help: "Number of objects in a cluster or the Helm release of a Plugin still using an API version already removed in the current Kubernetes version",
+		},
+		[]string{"cluster", "namespace", "plugin", "api_version", "kind", "removed_in"})
 )
 
 func init() {
 	metrics.Registry.MustRegister(kubernetesVersionsGauge)
 	metrics.Registry.MustRegister(secondsToTokenExpiryGauge)
+	metrics.Registry.MustRegister(apiServerReadyzLatencyGauge)
+	metrics.Registry.MustRegister(secondsToCertificateExpiryGauge)
+	metrics.Registry.MustRegister(healthCheckGauge)
+	metrics.Registry.MustRegister(deprecatedAPIsGauge)
+	metrics.Registry.MustRegister(removedAPIsGauge)
 }
 
 func updateMetrics(cluster *greenhousev1alpha1.Cluster) {
@@ -46,3 +87,64 @@ func updateMetrics(cluster *greenhousev1alpha1.Cluster) {
 	}
 	secondsToTokenExpiryGauge.With(secondsToExpiryLabels).Set(float64(secondsToExpiry))
 }
+
+func updateHealthMetrics(cluster *greenhousev1alpha1.Cluster, health clusterHealth) {
+	clusterLabels := prometheus.Labels{
+		"cluster":   cluster.Name,
+		"namespace": cluster.Namespace,
+	}
+	if health.apiServerReady.IsUnknown() {
+		apiServerReadyzLatencyGauge.Delete(clusterLabels)
+	} else {
+		apiServerReadyzLatencyGauge.With(clusterLabels).Set(health.readyzLatency.Seconds())
+	}
+	if health.certificateExpiry.IsZero() {
+		secondsToCertificateExpiryGauge.Delete(clusterLabels)
+	} else {
+		secondsToCertificateExpiryGauge.With(clusterLabels).Set(time.Until(health.certificateExpiry).Seconds())
+	}
+	for _, condition := range health.conditions() {
+		value := -1.0
+		switch {
+		case condition.IsTrue():
+			value = 1
+		case condition.IsFalse():
+			value = 0
+		}
+		healthCheckGauge.WithLabelValues(cluster.Name, cluster.Namespace, string(condition.Type)).Set(value)
+	}
+}
+
+// deleteHealthMetrics deletes the series of the health probes of a deleted cluster.
+func deleteHealthMetrics(cluster *greenhousev1alpha1.Cluster) {
+	clusterLabels := prometheus.Labels{
+		"cluster":   cluster.Name,
+		"namespace": cluster.Namespace,
+	}
+	apiServerReadyzLatencyGauge.Delete(clusterLabels)
+	secondsToCertificateExpiryGauge.Delete(clusterLabels)
+	healthCheckGauge.DeletePartialMatch(clusterLabels)
+}
+
+func updateDeprecatedAPIMetrics(cluster *greenhousev1alpha1.Cluster) {
+	clusterLabels := prometheus.Labels{"cluster": cluster.Name, "namespace": cluster.Namespace}
+	deprecatedAPIsGauge.DeletePartialMatch(clusterLabels)
+	removedAPIsGauge.DeletePartialMatch(clusterLabels)
+	report := cluster.Status.DeprecatedAPIs
+	if report == nil {
+		return
+	}
+	setAPIUsageMetrics(deprecatedAPIsGauge, cluster, report.Objects, report.Plugins)
+	setAPIUsageMetrics(removedAPIsGauge, cluster, report.RemovedObjects, report.RemovedPlugins)
+}
+
+func setAPIUsageMetrics(gauge *prometheus.GaugeVec, cluster *greenhousev1alpha1.Cluster, objects []greenhousev1alpha1.DeprecatedAPIUsage, plugins []greenhousev1alpha1.PluginDeprecatedAPIUsage) {
+	for _, usage := range objects {
+		gauge.WithLabelValues(cluster.Name, cluster.Namespace, "", usage.APIVersion, usage.Kind, usage.RemovedInVersion).Set(float64(usage.Count))
+	}
+	for _, plugin := range plugins {
+		for _, usage := range plugin.APIs {
+			gauge.WithLabelValues(cluster.Name, cluster.Namespace, plugin.Name, usage.APIVersion, usage.Kind, usage.RemovedInVersion).Set(float64(usage.Count))
+		}
+	}
+}
@@ -0,0 +1,408 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/controllers/cluster/utils"
+	"github.com/cloudoperators/greenhouse/pkg/lifecycle"
+)
+
+// isMigrationRequested returns true if the cluster is to be migrated to another Organization.
+func isMigrationRequested(cluster *greenhousev1alpha1.Cluster) bool {
+	_, ok := cluster.GetAnnotations()[greenhouseapis.ClusterMigrateToOrganizationAnnotation]
+	return ok
+}
+
+// isMigrated returns true if the cluster was migrated to another Organization and is removed from the source Organization.
+func isMigrated(cluster *greenhousev1alpha1.Cluster) bool {
+	return cluster.Status.Migration != nil && cluster.Status.Migration.Phase == greenhousev1alpha1.ClusterMigrationPhaseCompleted
+}
+
+// reconcileMigration migrates the cluster to the Organization given in the migrate-to-organization annotation.
+// All steps are idempotent and repeated until the migration completes. A failed step is reported in phase Failed and retried.
+// Once migrated, the cluster is deleted from the source Organization without uninstalling the Helm releases.
+func (r *RemoteClusterReconciler) reconcileMigration(ctx context.Context, remoteClient client.Client, cluster *greenhousev1alpha1.Cluster, clusterSecret *corev1.Secret) (ctrl.Result, lifecycle.ReconcileResult, error) {
+	targetOrganization := cluster.GetAnnotations()[greenhouseapis.ClusterMigrateToOrganizationAnnotation]
+	migration := cluster.Status.Migration
+	if migration == nil || migration.TargetOrganization != targetOrganization {
+		migration = &greenhousev1alpha1.ClusterMigrationStatus{
+			TargetOrganization: targetOrganization,
+			Phase:              greenhousev1alpha1.ClusterMigrationPhasePending,
+			StartTimestamp:     metav1.Now(),
+		}
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, "MigrationStarted", "Started the migration of the cluster to organization %s", targetOrganization)
+	}
+	fail := func(step greenhousev1alpha1.ClusterMigrationPhase, err error) (ctrl.Result, lifecycle.ReconcileResult, error) {
+		migration.Phase = greenhousev1alpha1.ClusterMigrationPhaseFailed
+		migration.FailedStep = step
+		migration.Message = err.Error()
+		cluster.Status.Migration = migration
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, greenhousev1alpha1.FailedEvent, "Failed to migrate the cluster to organization %s: %s", targetOrganization, err)
+		return ctrl.Result{}, lifecycle.Failed, err
+	}
+
+	if err := validateMigrationTarget(ctx, r.Client, cluster, targetOrganization); err != nil {
+		return fail(greenhousev1alpha1.ClusterMigrationPhasePending, err)
+	}
+	steps := []struct {
+		phase greenhousev1alpha1.ClusterMigrationPhase
+		run   func() error
+	}{
+		{greenhousev1alpha1.ClusterMigrationPhaseHandingOverAccess, func() error {
+			// The access of clusters connected via OIDC is granted to the Organization in the remote cluster.
+			if clusterSecret.Type == greenhouseapis.SecretTypeOIDCConfig {
+				return nil
+			}
+			return handOverClusterRoleBinding(ctx, remoteClient, targetOrganization)
+		}},
+		{greenhousev1alpha1.ClusterMigrationPhaseCopyingCluster, func() error {
+			return copyClusterToOrganization(ctx, r.Client, cluster, clusterSecret, targetOrganization)
+		}},
+		{greenhousev1alpha1.ClusterMigrationPhaseMigratingPlugins, func() error {
+			return migratePlugins(ctx, r.Client, cluster, targetOrganization)
+		}},
+		{greenhousev1alpha1.ClusterMigrationPhaseMigratingTeamRoleBindings, func() (err error) {
+			migration.SkippedTeamRoleBindings, err = migrateTeamRoleBindings(ctx, r.Client, cluster, targetOrganization)
+			return err
+		}},
+	}
+	for _, step := range steps {
+		// The phase is persisted before the step runs, so the progress of the migration is visible while it runs.
+		if migration.Phase != step.phase {
+			_, err := clientutil.PatchStatus(ctx, r.Client, cluster, func() error {
+				migration.Phase = step.phase
+				migration.FailedStep = ""
+				migration.Message = ""
+				cluster.Status.Migration = migration
+				return nil
+			})
+			if err != nil {
+				return fail(step.phase, err)
+			}
+		}
+		if err := step.run(); err != nil {
+			return fail(step.phase, err)
+		}
+	}
+
+	// Remove the cluster from the source Organization. The annotations satisfy the deletion webhook and the Plugins were detached already.
+	_, err := clientutil.Patch(ctx, r.Client, cluster, func() error {
+		annotations := cluster.GetAnnotations()
+		annotations[greenhouseapis.MarkClusterDeletionAnnotation] = "true"
+		annotations[greenhouseapis.ScheduleClusterDeletionAnnotation] = time.Now().UTC().Format(time.DateTime)
+		cluster.SetAnnotations(annotations)
+		cluster.Spec.DeletionPolicy = greenhousev1alpha1.ClusterDeletionPolicyDetach
+		return nil
+	})
+	if err != nil {
+		return fail(greenhousev1alpha1.ClusterMigrationPhaseMigratingTeamRoleBindings, err)
+	}
+	// The completion is persisted before the deletion, as it prevents the removal of the access to the remote cluster.
+	_, err = clientutil.PatchStatus(ctx, r.Client, cluster, func() error {
+		migration.Phase = greenhousev1alpha1.ClusterMigrationPhaseCompleted
+		migration.FailedStep = ""
+		migration.Message = fmt.Sprintf("cluster was migrated to organization %s", targetOrganization)
+		cluster.Status.Migration = migration
+		return nil
+	})
+	if err != nil {
+		return fail(greenhousev1alpha1.ClusterMigrationPhaseMigratingTeamRoleBindings, err)
+	}
+	if err := r.Client.Delete(ctx, cluster); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, lifecycle.Failed, err
+	}
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, greenhousev1alpha1.SuccessEvent, "Migrated the cluster to organization %s", targetOrganization)
+	return ctrl.Result{}, lifecycle.Success, nil
+}
+
+// validateMigrationTarget ensures the target Organization exists and does not contain another cluster with the same name.
+func validateMigrationTarget(ctx context.Context, c client.Client, cluster *greenhousev1alpha1.Cluster, targetOrganization string) error {
+	if targetOrganization == cluster.GetNamespace() {
+		return errors.New("the cluster already belongs to organization " + targetOrganization)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Name: targetOrganization}, &greenhousev1alpha1.Organization{}); err != nil {
+		return fmt.Errorf("failed to get organization %s: %w", targetOrganization, err)
+	}
+	var targetCluster = new(greenhousev1alpha1.Cluster)
+	err := c.Get(ctx, client.ObjectKey{Namespace: targetOrganization, Name: cluster.GetName()}, targetCluster)
+	switch {
+	case apierrors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	case targetCluster.GetAnnotations()[greenhouseapis.ClusterMigratedFromOrganizationAnnotation] != cluster.GetNamespace():
+		return fmt.Errorf("another cluster %s already exists in organization %s", cluster.GetName(), targetOrganization)
+	default:
+		return nil
+	}
+}
+
+// errMigrationTargetExists is returned if an object in the target Organization was not migrated from the source Organization.
+var errMigrationTargetExists = errors.New("object already exists in the target organization and was not migrated from the source organization")
+
+// claimMigrationTarget marks an object in the target Organization as migrated from the source Organization.
+// It must be called in the mutate function of CreateOrPatch, so that existing objects of the target Organization are not taken over.
+func claimMigrationTarget(obj client.Object, sourceOrganization string) error {
+	annotations := obj.GetAnnotations()
+	if obj.GetResourceVersion() != "" && annotations[greenhouseapis.ClusterMigratedFromOrganizationAnnotation] != sourceOrganization {
+		return errMigrationTargetExists
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[greenhouseapis.ClusterMigratedFromOrganizationAnnotation] = sourceOrganization
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// handOverClusterRoleBinding grants the service account of the target Organization the permissions of Greenhouse in the remote cluster.
+func handOverClusterRoleBinding(ctx context.Context, remoteClient client.Client, targetOrganization string) error {
+	crb := &rbacv1.ClusterRoleBinding{}
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: utils.ServiceAccountName}, crb); err != nil {
+		return err
+	}
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: utils.ServiceAccountName, Namespace: targetOrganization}
+	if slices.Contains(crb.Subjects, subject) {
+		return nil
+	}
+	_, err := clientutil.Patch(ctx, remoteClient, crb, func() error {
+		crb.Subjects = append(crb.Subjects, subject)
+		return nil
+	})
+	return err
+}
+
+// releaseClusterRoleBinding revokes the permissions of the service account of the source Organization once the cluster was migrated.
+func releaseClusterRoleBinding(ctx context.Context, remoteClient client.Client, sourceOrganization string) error {
+	crb := &rbacv1.ClusterRoleBinding{}
+	if err := remoteClient.Get(ctx, client.ObjectKey{Name: utils.ServiceAccountName}, crb); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	_, err := clientutil.Patch(ctx, remoteClient, crb, func() error {
+		crb.Subjects = slices.DeleteFunc(crb.Subjects, func(s rbacv1.Subject) bool {
+			return s.Kind == rbacv1.ServiceAccountKind && s.Name == utils.ServiceAccountName && s.Namespace == sourceOrganization
+		})
+		return nil
+	})
+	return err
+}
+
+// notMigratedSecretAnnotations are the annotations of the kubeconfig Secret that are specific to the source Organization.
+// The tunnel configuration is generated for the cluster in the target Organization.
+var notMigratedSecretAnnotations = []string{
+	greenhouseapis.SecretOIDCConfigGeneratedOnAnnotation,
+	greenhouseapis.SecretTunnelProxyURLAnnotation,
+	greenhouseapis.SecretTunnelServerAddressAnnotation,
+}
+
+// notMigratedSecretKeys are the keys of the kubeconfig Secret that are generated for the cluster in the target Organization.
+var notMigratedSecretKeys = []string{
+	greenhouseapis.GreenHouseKubeConfigKey,
+	greenhouseapis.TunnelTokenKey,
+	greenhouseapis.TunnelProxyTokenKey,
+	greenhouseapis.TunnelProxyCAKey,
+}
+
+// copyClusterToOrganization creates the Cluster and its kubeconfig Secret in the target Organization.
+// The kubeconfig generated by Greenhouse is used to bootstrap the cluster in the target Organization, which then generates its own.
+func copyClusterToOrganization(ctx context.Context, c client.Client, cluster *greenhousev1alpha1.Cluster, clusterSecret *corev1.Secret, targetOrganization string) error {
+	derivedLabels := strings.Split(cluster.GetAnnotations()[greenhouseapis.ClusterDerivedLabelsAnnotation], ",")
+	targetCluster := &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: targetOrganization, Name: cluster.GetName()}}
+	_, err := clientutil.CreateOrPatch(ctx, c, targetCluster, func() error {
+		// Derived and inventory labels are determined by the target Organization.
+		labels := maps.Clone(cluster.GetLabels())
+		maps.DeleteFunc(labels, func(key, _ string) bool {
+			return slices.Contains(derivedLabels, key) || strings.HasPrefix(key, greenhouseapis.LabelKeyPrefixClusterInventory)
+		})
+		targetCluster.SetLabels(labels)
+		if err := claimMigrationTarget(targetCluster, cluster.GetNamespace()); err != nil {
+			return err
+		}
+		annotations := targetCluster.GetAnnotations()
+		if connectivity, ok := cluster.GetAnnotations()[greenhouseapis.ClusterConnectivityAnnotation]; ok {
+			annotations[greenhouseapis.ClusterConnectivityAnnotation] = connectivity
+		}
+		targetCluster.SetAnnotations(annotations)
+		targetCluster.Spec = *cluster.Spec.DeepCopy()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create cluster in organization %s: %w", targetOrganization, err)
+	}
+
+	targetSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: targetOrganization, Name: cluster.GetSecretName()}}
+	_, err = clientutil.CreateOrPatch(ctx, c, targetSecret, func() error {
+		isCreated := !targetSecret.CreationTimestamp.IsZero()
+		if err := claimMigrationTarget(targetSecret, cluster.GetNamespace()); err != nil {
+			return err
+		}
+		// The Secret is maintained by the target Organization once created.
+		if isCreated {
+			return nil
+		}
+		targetSecret.Type = clusterSecret.Type
+		for key, value := range clusterSecret.GetAnnotations() {
+			if !slices.Contains(notMigratedSecretAnnotations, key) {
+				targetSecret.Annotations[key] = value
+			}
+		}
+		targetSecret.Data = maps.Clone(clusterSecret.Data)
+		for _, key := range notMigratedSecretKeys {
+			delete(targetSecret.Data, key)
+		}
+		if kubeConfig, ok := clusterSecret.Data[greenhouseapis.GreenHouseKubeConfigKey]; ok && clusterSecret.Type == greenhouseapis.SecretTypeKubeConfig {
+			targetSecret.Data[greenhouseapis.KubeConfigKey] = kubeConfig
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create kubeconfig secret in organization %s: %w", targetOrganization, err)
+	}
+	return nil
+}
+
+// migratePlugins recreates the Plugins deployed to the cluster in the target Organization and detaches them in the source Organization.
+// The Plugins in the target Organization adopt the existing Helm releases, as these keep their name and namespace.
+func migratePlugins(ctx context.Context, c client.Client, cluster *greenhousev1alpha1.Cluster, targetOrganization string) error {
+	pluginList := &greenhousev1alpha1.PluginList{}
+	if err := c.List(ctx, pluginList, client.InNamespace(cluster.GetNamespace()), client.MatchingLabels{greenhouseapis.LabelKeyCluster: cluster.GetName()}); err != nil {
+		return fmt.Errorf("failed to list plugins: %w", err)
+	}
+	for _, plugin := range pluginList.Items {
+		if err := copyPluginSecrets(ctx, c, &plugin, targetOrganization); err != nil {
+			return err
+		}
+		targetPlugin := &greenhousev1alpha1.Plugin{ObjectMeta: metav1.ObjectMeta{Namespace: targetOrganization, Name: plugin.GetName()}}
+		_, err := clientutil.CreateOrPatch(ctx, c, targetPlugin, func() error {
+			if err := claimMigrationTarget(targetPlugin, cluster.GetNamespace()); err != nil {
+				return err
+			}
+			// PluginPresets of the source Organization do not manage the Plugin in the target Organization.
+			labels := maps.Clone(plugin.GetLabels())
+			delete(labels, greenhouseapis.LabelKeyPluginPreset)
+			targetPlugin.SetLabels(labels)
+			targetPlugin.Spec = *plugin.Spec.DeepCopy()
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create plugin %s in organization %s: %w", plugin.GetName(), targetOrganization, err)
+		}
+		if err := detachPlugin(ctx, c, &plugin); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		if err := c.Delete(ctx, &plugin); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyPluginSecrets copies the Secrets referenced by the option values of the Plugin to the target Organization.
+// Secrets copied before are not overwritten. The migration fails if another Secret with the same name exists in the target Organization.
+func copyPluginSecrets(ctx context.Context, c client.Client, plugin *greenhousev1alpha1.Plugin, targetOrganization string) error {
+	for _, value := range plugin.Spec.OptionValues {
+		if value.ValueFrom == nil || value.ValueFrom.Secret == nil {
+			continue
+		}
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: plugin.GetNamespace(), Name: value.ValueFrom.Secret.Name}, secret); err != nil {
+			return fmt.Errorf("failed to get secret %s of plugin %s: %w", value.ValueFrom.Secret.Name, plugin.GetName(), err)
+		}
+		targetSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: targetOrganization, Name: secret.GetName()}}
+		result, err := clientutil.CreateOrPatch(ctx, c, targetSecret, func() error {
+			isCreated := !targetSecret.CreationTimestamp.IsZero()
+			if err := claimMigrationTarget(targetSecret, plugin.GetNamespace()); err != nil {
+				return err
+			}
+			if isCreated {
+				return nil
+			}
+			targetSecret.Type = secret.Type
+			targetSecret.Data = maps.Clone(secret.Data)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to copy secret %s of plugin %s: %w", secret.GetName(), plugin.GetName(), err)
+		}
+		if result == clientutil.OperationResultCreated {
+			log.FromContext(ctx).Info("copied secret", "namespace", targetOrganization, "name", secret.GetName(), "plugin", plugin.GetName())
+		}
+	}
+	return nil
+}
+
+// migrateTeamRoleBindings recreates the TeamRoleBindings for the cluster in the target Organization and removes them from the source Organization.
+// TeamRoleBindings selecting clusters by labels are not migrated. TeamRoleBindings whose Team or TeamRole does not exist in the target Organization
+// or whose name is taken by another TeamRoleBinding in the target Organization are skipped and returned.
+func migrateTeamRoleBindings(ctx context.Context, c client.Client, cluster *greenhousev1alpha1.Cluster, targetOrganization string) ([]string, error) {
+	teamRoleBindingList := &greenhousev1alpha1.TeamRoleBindingList{}
+	if err := c.List(ctx, teamRoleBindingList, client.InNamespace(cluster.GetNamespace())); err != nil {
+		return nil, fmt.Errorf("failed to list teamrolebindings: %w", err)
+	}
+	var skipped []string
+	for _, trb := range teamRoleBindingList.Items {
+		if trb.Spec.ClusterName != cluster.GetName() {
+			continue
+		}
+		isTeamFound, err := isObjectFound(ctx, c, client.ObjectKey{Namespace: targetOrganization, Name: trb.Spec.TeamRef}, &greenhousev1alpha1.Team{})
+		if err != nil {
+			return nil, err
+		}
+		isTeamRoleFound, err := isObjectFound(ctx, c, client.ObjectKey{Namespace: targetOrganization, Name: trb.Spec.TeamRoleRef}, &greenhousev1alpha1.TeamRole{})
+		if err != nil {
+			return nil, err
+		}
+		if !isTeamFound || !isTeamRoleFound {
+			skipped = append(skipped, trb.GetName())
+			continue
+		}
+		targetTRB := &greenhousev1alpha1.TeamRoleBinding{ObjectMeta: metav1.ObjectMeta{Namespace: targetOrganization, Name: trb.GetName()}}
+		_, err = clientutil.CreateOrPatch(ctx, c, targetTRB, func() error {
+			if err := claimMigrationTarget(targetTRB, cluster.GetNamespace()); err != nil {
+				return err
+			}
+			targetTRB.SetLabels(maps.Clone(trb.GetLabels()))
+			targetTRB.Spec = *trb.Spec.DeepCopy()
+			return nil
+		})
+		if errors.Is(err, errMigrationTargetExists) {
+			skipped = append(skipped, trb.GetName())
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create teamrolebinding %s in organization %s: %w", trb.GetName(), targetOrganization, err)
+		}
+		if err := c.Delete(ctx, &trb); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+	}
+	return skipped, nil
+}
+
+func isObjectFound(ctx context.Context, c client.Client, key client.ObjectKey, obj client.Object) (bool, error) {
+	err := c.Get(ctx, key, obj)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
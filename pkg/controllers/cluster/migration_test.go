@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/controllers/cluster/utils"
+	"github.com/cloudoperators/greenhouse/pkg/lifecycle"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Cluster migration", func() {
+	var (
+		cluster       *greenhousev1alpha1.Cluster
+		clusterSecret *corev1.Secret
+		k8sClient     client.Client
+		remoteClient  client.Client
+		r             *RemoteClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &greenhousev1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "source-org",
+				Name:        "test-cluster",
+				Labels:      map[string]string{"region": "eu", "tier": "prod", greenhouseapis.LabelKeyClusterProvider: "aws"},
+				Annotations: map[string]string{greenhouseapis.ClusterMigrateToOrganizationAnnotation: "target-org", greenhouseapis.ClusterDerivedLabelsAnnotation: "tier"},
+				Finalizers:  []string{lifecycle.CommonCleanupFinalizer},
+			},
+			Spec: greenhousev1alpha1.ClusterSpec{AccessMode: greenhousev1alpha1.ClusterAccessModeDirect},
+		}
+		clusterSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "source-org", Name: "test-cluster"},
+			Type:       greenhouseapis.SecretTypeKubeConfig,
+			Data: map[string][]byte{
+				greenhouseapis.KubeConfigKey:           []byte("user-kubeconfig"),
+				greenhouseapis.GreenHouseKubeConfigKey: []byte("greenhouse-kubeconfig"),
+			},
+		}
+		k8sClient = fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithStatusSubresource(cluster).WithObjects(
+			cluster,
+			clusterSecret,
+			&greenhousev1alpha1.Organization{ObjectMeta: metav1.ObjectMeta{Name: "target-org"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "source-org", Name: "ingress-secret"}, Data: map[string][]byte{"password": []byte("secret")}},
+			&greenhousev1alpha1.Plugin{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "source-org",
+					Name:       "ingress",
+					Labels:     map[string]string{greenhouseapis.LabelKeyCluster: "test-cluster", greenhouseapis.LabelKeyPluginPreset: "ingress"},
+					Finalizers: []string{lifecycle.CommonCleanupFinalizer},
+				},
+				Spec: greenhousev1alpha1.PluginSpec{
+					PluginDefinition: "ingress",
+					ClusterName:      "test-cluster",
+					ReleaseNamespace: "kube-system",
+					OptionValues: []greenhousev1alpha1.PluginOptionValue{{
+						Name:      "password",
+						ValueFrom: &greenhousev1alpha1.ValueFromSource{Secret: &greenhousev1alpha1.SecretKeyReference{Name: "ingress-secret", Key: "password"}},
+					}},
+				},
+			},
+			&greenhousev1alpha1.Team{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "admins"}},
+			&greenhousev1alpha1.TeamRole{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "cluster-admin"}},
+			&greenhousev1alpha1.TeamRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "source-org", Name: "admins"},
+				Spec:       greenhousev1alpha1.TeamRoleBindingSpec{TeamRef: "admins", TeamRoleRef: "cluster-admin", ClusterName: "test-cluster"},
+			},
+			&greenhousev1alpha1.TeamRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "source-org", Name: "viewers"},
+				Spec:       greenhousev1alpha1.TeamRoleBindingSpec{TeamRef: "viewers", TeamRoleRef: "cluster-viewer", ClusterName: "test-cluster"},
+			},
+		).Build()
+		remoteClient = fake.NewClientBuilder().WithObjects(&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: utils.ServiceAccountName},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: utils.ServiceAccountName, Namespace: "source-org"}},
+		}).Build()
+		r = &RemoteClusterReconciler{Client: k8sClient, recorder: record.NewFakeRecorder(10)}
+	})
+
+	It("should migrate the cluster to the target organization", func() {
+		_, result, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error migrating the cluster")
+		Expect(result).To(Equal(lifecycle.Success))
+		Expect(cluster.Status.Migration.Phase).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseCompleted))
+		Expect(cluster.Status.Migration.SkippedTeamRoleBindings).To(ConsistOf("viewers"), "the TeamRoleBinding without Team in the target organization should be skipped")
+
+		By("checking the remote cluster is accessible for the target organization")
+		crb := &rbacv1.ClusterRoleBinding{}
+		Expect(remoteClient.Get(test.Ctx, client.ObjectKey{Name: utils.ServiceAccountName}, crb)).To(Succeed())
+		Expect(crb.Subjects).To(ContainElement(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: utils.ServiceAccountName, Namespace: "target-org"}))
+
+		By("checking the cluster and secret were copied")
+		targetCluster := &greenhousev1alpha1.Cluster{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "test-cluster"}, targetCluster)).To(Succeed())
+		Expect(targetCluster.GetLabels()).To(Equal(map[string]string{"region": "eu"}), "derived and inventory labels should not be copied")
+		Expect(targetCluster.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.ClusterMigratedFromOrganizationAnnotation, "source-org"))
+		targetSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "test-cluster"}, targetSecret)).To(Succeed())
+		Expect(targetSecret.Data).To(Equal(map[string][]byte{greenhouseapis.KubeConfigKey: []byte("greenhouse-kubeconfig")}), "the greenhouse kubeconfig should be used to bootstrap the cluster")
+
+		By("checking the plugin and its secret were migrated")
+		targetPlugin := &greenhousev1alpha1.Plugin{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "ingress"}, targetPlugin)).To(Succeed())
+		Expect(targetPlugin.Spec.ReleaseNamespace).To(Equal("kube-system"))
+		Expect(targetPlugin.GetLabels()).ToNot(HaveKey(greenhouseapis.LabelKeyPluginPreset))
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "ingress-secret"}, &corev1.Secret{})).To(Succeed())
+		sourcePlugin := &greenhousev1alpha1.Plugin{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "source-org", Name: "ingress"}, sourcePlugin)).To(Succeed())
+		Expect(sourcePlugin.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.PluginDetachAnnotation, "true"), "the source plugin should be detached")
+		Expect(sourcePlugin.GetDeletionTimestamp()).ToNot(BeNil(), "the source plugin should be deleted")
+
+		By("checking the teamrolebindings were migrated")
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "admins"}, &greenhousev1alpha1.TeamRoleBinding{})).To(Succeed())
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "source-org", Name: "admins"}, &greenhousev1alpha1.TeamRoleBinding{})).To(Satisfy(apierrors.IsNotFound))
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "source-org", Name: "viewers"}, &greenhousev1alpha1.TeamRoleBinding{})).To(Succeed())
+
+		By("checking the source cluster is deleted without uninstalling the releases")
+		sourceCluster := &greenhousev1alpha1.Cluster{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKeyFromObject(cluster), sourceCluster)).To(Succeed())
+		Expect(sourceCluster.GetDeletionTimestamp()).ToNot(BeNil(), "the source cluster should be deleted")
+		Expect(sourceCluster.Spec.DeletionPolicy).To(Equal(greenhousev1alpha1.ClusterDeletionPolicyDetach))
+		Expect(isMigrated(sourceCluster)).To(BeTrue(), "the completed migration should be persisted")
+
+		By("checking the access of the source organization is revoked")
+		Expect(releaseClusterRoleBinding(test.Ctx, remoteClient, "source-org")).To(Succeed())
+		Expect(remoteClient.Get(test.Ctx, client.ObjectKey{Name: utils.ServiceAccountName}, crb)).To(Succeed())
+		Expect(crb.Subjects).To(ConsistOf(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: utils.ServiceAccountName, Namespace: "target-org"}))
+	})
+
+	It("should fail if another cluster with the same name exists in the target organization", func() {
+		Expect(k8sClient.Create(test.Ctx, &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "test-cluster"}})).To(Succeed())
+		_, result, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).To(HaveOccurred(), "there should be an error migrating the cluster")
+		Expect(result).To(Equal(lifecycle.Failed))
+		Expect(cluster.Status.Migration.Phase).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseFailed))
+		Expect(cluster.Status.Migration.FailedStep).To(Equal(greenhousev1alpha1.ClusterMigrationPhasePending))
+		Expect(cluster.Status.Migration.Message).To(ContainSubstring("already exists"))
+	})
+
+	It("should fail if another plugin with the same name exists in the target organization", func() {
+		Expect(k8sClient.Create(test.Ctx, &greenhousev1alpha1.Plugin{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "ingress"}})).To(Succeed())
+		_, result, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).To(MatchError(errMigrationTargetExists), "there should be an error migrating the plugin")
+		Expect(result).To(Equal(lifecycle.Failed))
+		Expect(cluster.Status.Migration.Phase).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseFailed))
+		Expect(cluster.Status.Migration.FailedStep).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseMigratingPlugins))
+		sourcePlugin := &greenhousev1alpha1.Plugin{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "source-org", Name: "ingress"}, sourcePlugin)).To(Succeed())
+		Expect(sourcePlugin.GetDeletionTimestamp()).To(BeNil(), "the source plugin should not be deleted")
+	})
+
+	It("should fail if another secret with the same name exists in the target organization", func() {
+		Expect(k8sClient.Create(test.Ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "ingress-secret"}})).To(Succeed())
+		_, _, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).To(MatchError(errMigrationTargetExists), "there should be an error copying the secret")
+		Expect(cluster.Status.Migration.FailedStep).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseMigratingPlugins))
+	})
+
+	It("should skip teamrolebindings whose name is taken in the target organization", func() {
+		Expect(k8sClient.Create(test.Ctx, &greenhousev1alpha1.TeamRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "target-org", Name: "admins"},
+			Spec:       greenhousev1alpha1.TeamRoleBindingSpec{TeamRef: "admins", TeamRoleRef: "cluster-admin", ClusterName: "other-cluster"},
+		})).To(Succeed())
+		_, result, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error migrating the cluster")
+		Expect(result).To(Equal(lifecycle.Success))
+		Expect(cluster.Status.Migration.SkippedTeamRoleBindings).To(ConsistOf("admins", "viewers"))
+		targetTRB := &greenhousev1alpha1.TeamRoleBinding{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "admins"}, targetTRB)).To(Succeed())
+		Expect(targetTRB.Spec.ClusterName).To(Equal("other-cluster"), "the existing teamrolebinding should not be overwritten")
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "source-org", Name: "admins"}, &greenhousev1alpha1.TeamRoleBinding{})).To(Succeed())
+	})
+
+	It("should persist the phase of the migration before each step", func() {
+		var observedPhase greenhousev1alpha1.ClusterMigrationPhase
+		observingClient := interceptor.NewClient(k8sClient.(client.WithWatch), interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*greenhousev1alpha1.Cluster); ok && obj.GetNamespace() == "target-org" {
+					sourceCluster := &greenhousev1alpha1.Cluster{}
+					if err := c.Get(ctx, client.ObjectKeyFromObject(cluster), sourceCluster); err != nil {
+						return err
+					}
+					if sourceCluster.Status.Migration != nil {
+						observedPhase = sourceCluster.Status.Migration.Phase
+					}
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+		r.Client = observingClient
+
+		_, _, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error migrating the cluster")
+		Expect(observedPhase).To(Equal(greenhousev1alpha1.ClusterMigrationPhaseCopyingCluster),
+			"the phase of the running step should be persisted while the cluster is copied")
+	})
+
+	It("should not copy the tunnel configuration of the source organization", func() {
+		Expect(k8sClient.Patch(test.Ctx, clusterSecret, client.RawPatch(types.MergePatchType, []byte(`{"metadata":{"annotations":{`+
+			`"`+greenhouseapis.SecretClusterAccessModeAnnotation+`":"reverse-tunnel",`+
+			`"`+greenhouseapis.SecretTunnelProxyURLAnnotation+`":"https://source-org%2Ftest-cluster@greenhouse-tunnel.greenhouse.svc:8090",`+
+			`"`+greenhouseapis.SecretTunnelServerAddressAnnotation+`":"10.0.0.1:8090"}},`+
+			`"data":{"`+greenhouseapis.TunnelTokenKey+`":"dG9rZW4=","`+greenhouseapis.TunnelProxyTokenKey+`":"cHJveHktdG9rZW4=","`+greenhouseapis.TunnelProxyCAKey+`":"Y2E="}}`)))).
+			To(Succeed(), "there should be no error adding the tunnel configuration")
+		cluster.Spec.AccessMode = greenhousev1alpha1.ClusterAccessModeReverseTunnel
+		Expect(k8sClient.Update(test.Ctx, cluster)).To(Succeed(), "there should be no error setting the access mode")
+
+		_, _, err := r.reconcileMigration(test.Ctx, remoteClient, cluster, clusterSecret)
+		Expect(err).ToNot(HaveOccurred(), "there should be no error migrating the cluster")
+
+		targetCluster := &greenhousev1alpha1.Cluster{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "test-cluster"}, targetCluster)).To(Succeed())
+		Expect(targetCluster.Spec.AccessMode).To(Equal(greenhousev1alpha1.ClusterAccessModeReverseTunnel))
+		targetSecret := &corev1.Secret{}
+		Expect(k8sClient.Get(test.Ctx, client.ObjectKey{Namespace: "target-org", Name: "test-cluster"}, targetSecret)).To(Succeed())
+		Expect(targetSecret.GetAnnotations()).To(HaveKeyWithValue(greenhouseapis.SecretClusterAccessModeAnnotation, "reverse-tunnel"))
+		Expect(targetSecret.GetAnnotations()).ToNot(HaveKey(greenhouseapis.SecretTunnelProxyURLAnnotation), "the proxy URL identifies the cluster of the source organization")
+		Expect(targetSecret.GetAnnotations()).ToNot(HaveKey(greenhouseapis.SecretTunnelServerAddressAnnotation))
+		Expect(targetSecret.Data).To(Equal(map[string][]byte{greenhouseapis.KubeConfigKey: []byte("greenhouse-kubeconfig")}),
+			"the tunnel tokens should be generated in the target organization")
+	})
+})
@@ -201,6 +201,61 @@ var _ = Describe("Cluster status", Ordered, func() {
 
 	})
 
+	It("should collect the inventory of a cluster", func() {
+		Eventually(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&validCluster), &validCluster)).To(Succeed(), "There should be no error getting the cluster resource")
+			g.Expect(validCluster.Status.Inventory).ToNot(BeNil(), "The inventory should be collected")
+			g.Expect(validCluster.Status.Inventory.NodeCount).To(Equal(3), "The inventory should contain all nodes of the remote cluster")
+			g.Expect(validCluster.Labels).To(HaveKeyWithValue(greenhouseapis.LabelKeyClusterKubernetesVersion, validCluster.Status.KubernetesVersion), "The Kubernetes version label should be set")
+		}).Should(Succeed())
+	})
+
+	It("should reconcile the health of a cluster", func() {
+		By("checking the health conditions of the cluster")
+		Eventually(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&validCluster), &validCluster)).To(Succeed(), "There should be no error getting the cluster resource")
+			apiServerReadyCondition := validCluster.Status.GetConditionByType(greenhousev1alpha1.APIServerReady)
+			g.Expect(apiServerReadyCondition).ToNot(BeNil(), "The APIServerReady condition should be present")
+			g.Expect(apiServerReadyCondition.Status).To(Equal(metav1.ConditionTrue), "The API server of the remote cluster should be ready")
+			dnsReadyCondition := validCluster.Status.GetConditionByType(greenhousev1alpha1.ClusterDNSReady)
+			g.Expect(dnsReadyCondition).ToNot(BeNil(), "The ClusterDNSReady condition should be present")
+			g.Expect(dnsReadyCondition.Status).To(Equal(metav1.ConditionUnknown), "The DNS should be unknown without DNS pods")
+		}).Should(Succeed())
+
+		By("creating a DNS pod that is not ready in the remote cluster")
+		dnsPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "coredns",
+				Namespace: metav1.NamespaceSystem,
+				Labels:    map[string]string{"k8s-app": "kube-dns"},
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "coredns", Image: "coredns"}},
+			},
+		}
+		Expect(remoteClient.Create(test.Ctx, dnsPod)).To(Succeed(), "there should be no error creating the DNS pod")
+
+		By("triggering a cluster reconcile")
+		Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&validCluster), &validCluster)).To(Succeed(), "There should be no error getting the cluster resource")
+		validCluster.SetLabels(map[string]string{"reconcile-me": "dns"})
+		Expect(test.K8sClient.Update(test.Ctx, &validCluster)).To(Succeed(), "There should be no error updating the cluster resource")
+
+		By("checking the cluster is not ready")
+		Eventually(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(&validCluster), &validCluster)).To(Succeed(), "There should be no error getting the cluster resource")
+			dnsReadyCondition := validCluster.Status.GetConditionByType(greenhousev1alpha1.ClusterDNSReady)
+			g.Expect(dnsReadyCondition).ToNot(BeNil(), "The ClusterDNSReady condition should be present")
+			g.Expect(dnsReadyCondition.Status).To(Equal(metav1.ConditionFalse), "The DNS should not be ready")
+			readyCondition := validCluster.Status.GetConditionByType(greenhousev1alpha1.ReadyCondition)
+			g.Expect(readyCondition).ToNot(BeNil(), "The Ready condition should be present")
+			g.Expect(readyCondition.Status).To(Equal(metav1.ConditionFalse), "The cluster should not be ready if the DNS is not ready")
+			g.Expect(readyCondition.Message).To(Equal("no DNS pod is ready"))
+		}).Should(Succeed())
+
+		By("removing the DNS pod from the remote cluster")
+		Expect(remoteClient.Delete(test.Ctx, dnsPod, client.GracePeriodSeconds(0))).To(Succeed(), "there should be no error deleting the DNS pod")
+	})
+
 	It("should reconcile the status of a cluster without a secret", func() {
 		By("checking cluster conditions")
 		Eventually(func(g Gomega) bool {
@@ -15,6 +15,9 @@ import (
 	"github.com/cloudoperators/greenhouse/pkg/test"
 )
 
+// tunnelServerAddress is the address the tunnel server of the reverse-tunnel tests listens on.
+const tunnelServerAddress = "127.0.0.1:6890"
+
 var (
 	bootstrapReconciler *clusterpkg.BootstrapReconciler
 )
@@ -31,6 +34,8 @@ var _ = BeforeSuite(func() {
 	test.RegisterController("clusterDirectAccess", (&clusterpkg.RemoteClusterReconciler{
 		RemoteClusterBearerTokenValidity:   10 * time.Minute,
 		RenewRemoteClusterBearerTokenAfter: 9 * time.Minute,
+		TunnelProxyURL:                     "http://" + tunnelServerAddress,
+		InventoryInterval:                  clusterpkg.DefaultInventoryInterval,
 	}).SetupWithManager)
 
 	test.RegisterWebhook("clusterValidation", admission.SetupClusterWebhookWithManager)
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+// tokenRenewalFailureThreshold is the number of consecutive failed token renewals after which a warning event is emitted.
+// Any failure to access the cluster counts as a failed renewal, as the token cannot be renewed without access.
+const tokenRenewalFailureThreshold = 3
+
+// tokenRenewalFailures counts the consecutive failed token renewals per cluster.
+type tokenRenewalFailures struct {
+	mu     sync.Mutex
+	counts map[types.NamespacedName]int
+}
+
+func (f *tokenRenewalFailures) increment(key types.NamespacedName) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.counts == nil {
+		f.counts = make(map[types.NamespacedName]int)
+	}
+	f.counts[key]++
+	return f.counts[key]
+}
+
+func (f *tokenRenewalFailures) reset(key types.NamespacedName) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.counts, key)
+}
+
+// recordTokenRenewalFailure emits a warning event on the cluster once accessing it to renew its token failed several times in a row.
+func (r *RemoteClusterReconciler) recordTokenRenewalFailure(cluster *greenhousev1alpha1.Cluster, err error) {
+	if failures := r.tokenRenewalFailures.increment(client.ObjectKeyFromObject(cluster)); failures >= tokenRenewalFailureThreshold {
+		r.recorder.Eventf(cluster, corev1.EventTypeWarning, greenhousev1alpha1.FailedEvent,
+			"Failed to access the cluster to renew its token %d times in a row, the token expires at %s: %s",
+			failures, cluster.Status.BearerTokenExpirationTimestamp.Format(time.DateTime), err)
+	}
+}
+
+// tokenExpiringSoonCondition returns a condition reflecting whether the token used to access the cluster expires within the given duration.
+// Tokens are renewed well before they expire, so a token about to expire indicates that its renewal keeps failing.
+func tokenExpiringSoonCondition(cluster *greenhousev1alpha1.Cluster, within time.Duration) greenhousev1alpha1.Condition {
+	expiry := cluster.Status.BearerTokenExpirationTimestamp
+	switch {
+	case expiry.IsZero():
+		return greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.TokenExpiringSoon, "", "token expiration is unknown")
+	case !expiry.After(time.Now()):
+		return greenhousev1alpha1.TrueCondition(greenhousev1alpha1.TokenExpiringSoon, "", "token expired at "+expiry.Format(time.DateTime))
+	case time.Until(expiry.Time) < within:
+		return greenhousev1alpha1.TrueCondition(greenhousev1alpha1.TokenExpiringSoon, "", "token expires at "+expiry.Format(time.DateTime)+" and was not renewed")
+	default:
+		return greenhousev1alpha1.FalseCondition(greenhousev1alpha1.TokenExpiringSoon, "", "")
+	}
+}
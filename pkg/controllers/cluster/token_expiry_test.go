@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Token expiry", func() {
+	var cluster *greenhousev1alpha1.Cluster
+
+	BeforeEach(func() {
+		cluster = &greenhousev1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Namespace: "test-org", Name: "test-cluster"}}
+	})
+
+	DescribeTable("should reflect whether the token expires soon",
+		func(expiresIn time.Duration, expectedStatus metav1.ConditionStatus) {
+			if expiresIn != 0 {
+				cluster.Status.BearerTokenExpirationTimestamp = metav1.NewTime(time.Now().Add(expiresIn))
+			}
+			condition := tokenExpiringSoonCondition(cluster, 10*time.Hour)
+			Expect(condition.Type).To(Equal(greenhousev1alpha1.TokenExpiringSoon))
+			Expect(condition.Status).To(Equal(expectedStatus))
+		},
+		Entry("unknown expiration", time.Duration(0), metav1.ConditionUnknown),
+		Entry("token renewed recently", 48*time.Hour, metav1.ConditionFalse),
+		Entry("token not renewed", 5*time.Hour, metav1.ConditionTrue),
+		Entry("token expired", -time.Hour, metav1.ConditionTrue),
+	)
+
+	It("should emit an event once the renewal failed several times in a row", func() {
+		recorder := record.NewFakeRecorder(10)
+		r := &RemoteClusterReconciler{recorder: recorder}
+		err := errors.New("connection refused")
+
+		for range tokenRenewalFailureThreshold - 1 {
+			r.recordTokenRenewalFailure(cluster, err)
+		}
+		Expect(recorder.Events).To(BeEmpty(), "no event should be emitted below the threshold")
+
+		r.recordTokenRenewalFailure(cluster, err)
+		Expect(recorder.Events).To(Receive(ContainSubstring("3 times in a row")), "an event should be emitted at the threshold")
+
+		r.tokenRenewalFailures.reset(client.ObjectKeyFromObject(cluster))
+		r.recordTokenRenewalFailure(cluster, err)
+		Expect(recorder.Events).To(BeEmpty(), "the failures should be counted again after a successful renewal")
+	})
+
+	It("should count failures to access the cluster before the token is renewed", func() {
+		recorder := record.NewFakeRecorder(10)
+		cluster.Spec.AccessMode = greenhousev1alpha1.ClusterAccessModeDirect
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name},
+			Type:       corev1.SecretTypeOpaque,
+		}
+		r := &RemoteClusterReconciler{
+			Client:   fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(secret).Build(),
+			recorder: recorder,
+		}
+		for range tokenRenewalFailureThreshold {
+			_, _, err := r.EnsureCreated(test.Ctx, cluster)
+			Expect(err).To(HaveOccurred(), "the cluster should not be accessible with an invalid secret")
+		}
+		Expect(recorder.Events).To(Receive(ContainSubstring("3 times in a row")), "an event should be emitted at the threshold")
+	})
+})
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/tunnel"
+)
+
+// tunnelTokenLength is the number of random bytes of the tokens the tunnel agent and the proxy clients authenticate with.
+const tunnelTokenLength = 32
+
+// reconcileTunnel ensures the Secret of a cluster with the reverse-tunnel access mode contains the tokens for the tunnel agent
+// and the proxy clients, the CA of the tunnel server and the proxy URL which routes all requests to the cluster through the tunnel server.
+func (r *RemoteClusterReconciler) reconcileTunnel(ctx context.Context, cluster *greenhousev1alpha1.Cluster, secret *corev1.Secret) error {
+	if r.TunnelProxyURL == "" {
+		return errors.New("the reverse-tunnel access mode is not enabled, --cluster-tunnel-proxy-url is not set")
+	}
+	proxyURL, err := url.Parse(r.TunnelProxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid tunnel proxy URL: %w", err)
+	}
+	// The proxy user identifies the cluster at the tunnel server. The password is added from the Secret when creating clients.
+	proxyURL.User = url.User(tunnel.ClusterKey(client.ObjectKeyFromObject(cluster)))
+
+	result, err := clientutil.CreateOrPatch(ctx, r.Client, secret, func() error {
+		for _, key := range []string{greenhouseapis.TunnelTokenKey, greenhouseapis.TunnelProxyTokenKey} {
+			if err := ensureTunnelToken(secret, key); err != nil {
+				return err
+			}
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string)
+		}
+		secret.Annotations[greenhouseapis.SecretTunnelProxyURLAnnotation] = proxyURL.String()
+		if len(r.TunnelProxyCA) > 0 {
+			secret.Data[greenhouseapis.TunnelProxyCAKey] = r.TunnelProxyCA
+		} else {
+			delete(secret.Data, greenhouseapis.TunnelProxyCAKey)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if result != clientutil.OperationResultNone {
+		log.FromContext(ctx).Info("updated tunnel configuration", "namespace", secret.GetNamespace(), "name", secret.GetName())
+	}
+	return nil
+}
+
+// ensureTunnelToken generates a random token for the key of the Secret unless it is already present.
+func ensureTunnelToken(secret *corev1.Secret, key string) error {
+	if clientutil.IsSecretContainsKey(secret, key) {
+		return nil
+	}
+	token := make([]byte, tunnelTokenLength)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("failed to generate %s: %w", key, err)
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[key] = []byte(hex.EncodeToString(token))
+	return nil
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package cluster_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+	"github.com/cloudoperators/greenhouse/pkg/tunnel"
+)
+
+var _ = Describe("Reverse tunnel", Ordered, func() {
+	const tunnelTestCase = "reverse-tunnel"
+
+	var (
+		setup            *test.TestSetup
+		remoteEnvTest    *envtest.Environment
+		remoteKubeConfig []byte
+		tunnelServer     *tunnel.Server
+		httpServer       *http.Server
+		cancelAgent      context.CancelFunc
+	)
+
+	BeforeAll(func() {
+		_, _, remoteEnvTest, remoteKubeConfig = test.StartControlPlane("6887", false, false)
+		setup = test.NewTestSetup(test.Ctx, test.K8sClient, tunnelTestCase)
+
+		By("starting the tunnel server")
+		listener, err := net.Listen("tcp", tunnelServerAddress)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error listening on the tunnel server address")
+		tunnelServer = tunnel.NewServer(test.K8sClient, tunnelServerAddress, "", nil)
+		httpServer = &http.Server{Handler: tunnelServer, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			defer GinkgoRecover()
+			if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				Fail("tunnel server failed: " + err.Error())
+			}
+		}()
+	})
+
+	AfterAll(func() {
+		if cancelAgent != nil {
+			cancelAgent()
+		}
+		Expect(httpServer.Close()).To(Succeed(), "there should be no error stopping the tunnel server")
+		Expect(remoteEnvTest.Stop()).To(Succeed(), "there should be no error stopping the remote environment")
+	})
+
+	It("should access the remote cluster through the tunnel of an in-process agent", func() {
+		By("creating a kubeconfig Secret with the reverse-tunnel access mode")
+		secret := setup.CreateSecret(test.Ctx, tunnelTestCase,
+			test.WithSecretType(greenhouseapis.SecretTypeKubeConfig),
+			test.WithSecretAnnotations(map[string]string{
+				greenhouseapis.SecretClusterAccessModeAnnotation: string(greenhousev1alpha1.ClusterAccessModeReverseTunnel),
+			}),
+			test.WithSecretData(map[string][]byte{greenhouseapis.KubeConfigKey: remoteKubeConfig}))
+
+		By("checking the Secret contains the tunnel tokens and the proxy URL")
+		clusterSecret := &corev1.Secret{}
+		Eventually(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), clusterSecret)).To(Succeed())
+			g.Expect(clusterSecret.Data).To(HaveKey(greenhouseapis.TunnelTokenKey))
+			g.Expect(clusterSecret.Data).To(HaveKey(greenhouseapis.TunnelProxyTokenKey))
+			g.Expect(clusterSecret.Annotations).To(HaveKey(greenhouseapis.SecretTunnelProxyURLAnnotation))
+		}).Should(Succeed(), "eventually the Secret should contain the tunnel configuration")
+
+		By("starting the agent of the remote cluster")
+		kubeConfig, err := clientcmd.Load(remoteKubeConfig)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error loading the remote kubeconfig")
+		apiServerURL, err := url.Parse(kubeConfig.Clusters[kubeConfig.Contexts[kubeConfig.CurrentContext].Cluster].Server)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error parsing the remote API server URL")
+		cluster := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		agent := &tunnel.Agent{
+			ServerURL:         "http://" + tunnelServerAddress,
+			Cluster:           cluster,
+			Token:             string(clusterSecret.Data[greenhouseapis.TunnelTokenKey]),
+			Target:            apiServerURL.Host,
+			ReconnectInterval: 100 * time.Millisecond,
+		}
+		var agentCtx context.Context
+		agentCtx, cancelAgent = context.WithCancel(test.Ctx)
+		go agent.Run(agentCtx)
+		Eventually(func() bool {
+			return tunnelServer.IsConnected(cluster)
+		}).Should(BeTrue(), "eventually the agent should be connected")
+
+		By("checking the cluster is bootstrapped through the tunnel")
+		Eventually(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, client.ObjectKeyFromObject(secret), clusterSecret)).To(Succeed())
+			g.Expect(clusterSecret.Data).To(HaveKey(greenhouseapis.GreenHouseKubeConfigKey))
+		}).Should(Succeed(), "eventually the greenhouse kubeconfig should be generated through the tunnel")
+
+		By("getting the version of the remote cluster with a client created from the Secret")
+		restClientGetter, err := clientutil.NewRestClientGetterFromSecret(clusterSecret, setup.Namespace())
+		Expect(err).NotTo(HaveOccurred(), "there should be no error getting the rest client getter from the secret")
+		kubeVersion, err := clientutil.GetKubernetesVersion(restClientGetter)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error getting the kubernetes version through the tunnel")
+		Expect(kubeVersion).NotTo(BeNil(), "the kubernetes version should not be nil")
+
+		By("checking a client with an invalid proxy token is rejected")
+		invalidSecret := clusterSecret.DeepCopy()
+		invalidSecret.Data[greenhouseapis.TunnelProxyTokenKey] = []byte("invalid")
+		restClientGetter, err = clientutil.NewRestClientGetterFromSecret(invalidSecret, setup.Namespace())
+		Expect(err).NotTo(HaveOccurred(), "there should be no error getting the rest client getter from the secret")
+		_, err = clientutil.GetKubernetesVersion(restClientGetter)
+		Expect(err).To(HaveOccurred(), "the client with an invalid proxy token should be rejected")
+
+		test.MustDeleteCluster(test.Ctx, test.K8sClient, cluster)
+	})
+})
@@ -32,6 +32,8 @@ type TokenHelper struct {
 	RenewRemoteClusterBearerTokenAfter time.Duration
 	SecretType                         corev1.SecretType
 	OIDCServiceAccount                 string
+	// ForceRenewal requests a new token regardless of the validity of the current one.
+	ForceRenewal bool
 }
 
 type KubeConfigHelper struct {
@@ -91,6 +93,10 @@ func (t *TokenHelper) GenerateTokenRequest(ctx context.Context, restClientGetter
 	var tokenInfo = &claims{}
 	var actualTokenExpiry metav1.Time
 
+	if t.ForceRenewal {
+		return t.requestToken(ctx, cluster)
+	}
+
 	remoteRestConfig, err := restClientGetter.ToRESTConfig()
 	if err != nil {
 		return nil, err
@@ -119,7 +125,12 @@ func (t *TokenHelper) GenerateTokenRequest(ctx context.Context, restClientGetter
 		log.FromContext(ctx).V(5).Info("bearer token is still valid", "cluster", cluster.Name, "expirationTimestamp", cluster.Status.BearerTokenExpirationTimestamp.Time)
 		return nil, nil
 	}
+	return t.requestToken(ctx, cluster)
+}
 
+// requestToken requests a new token for the service account depending on the secret type.
+func (t *TokenHelper) requestToken(ctx context.Context, cluster *greenhousev1alpha1.Cluster) (*authenticationv1.TokenRequest, error) {
+	var err error
 	tokenRequest := &authenticationv1.TokenRequest{
 		Spec: authenticationv1.TokenRequestSpec{
 			ExpirationSeconds: ptr.To(int64(t.RemoteClusterBearerTokenValidity / time.Second)),
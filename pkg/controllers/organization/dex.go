@@ -236,6 +236,8 @@ func ensureCallbackURL(url string) string {
 func getRedirects(orgName string, redirectURIs []string) []string {
 	defaultRedirects := []string{
 		"http://localhost:8085", // allowing local development of idproxy url
+		"http://localhost:8000", // allowing the browser flow of greenhousectl login
+		"/device/callback",      // allowing the device flow of greenhousectl login
 		"https://dashboard." + common.DNSDomain,
 		getRedirectForOrg(orgName),
 	}
@@ -27,7 +27,6 @@ import (
 	dexstore "github.com/cloudoperators/greenhouse/pkg/dex"
 	dexapi "github.com/cloudoperators/greenhouse/pkg/dex/api"
 	"github.com/cloudoperators/greenhouse/pkg/lifecycle"
-	"github.com/cloudoperators/greenhouse/pkg/scim"
 	"github.com/cloudoperators/greenhouse/pkg/util"
 )
 
@@ -287,47 +286,6 @@ func (r *OrganizationReconciler) reconcileRBAC(ctx context.Context, org *greenho
 	return nil
 }
 
-func (r *OrganizationReconciler) checkSCIMAPIAvailability(ctx context.Context, org *greenhousesapv1alpha1.Organization) greenhousesapv1alpha1.Condition {
-	if org.Spec.Authentication == nil || org.Spec.Authentication.SCIMConfig == nil {
-		// SCIM Config is optional.
-		return greenhousesapv1alpha1.UnknownCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMConfigErrorReason, "SCIM Config not provided")
-	}
-
-	if org.Spec.MappedOrgAdminIDPGroup == "" {
-		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMRequestFailedReason, ".Spec.MappedOrgAdminIDPGroup is not set in Organization")
-	}
-
-	namespace := org.Name
-	scimConfig := org.Spec.Authentication.SCIMConfig
-
-	config, err := util.GreenhouseSCIMConfigToSCIMConfig(ctx, r.Client, scimConfig, namespace)
-	if err != nil {
-		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMConfigErrorReason, err.Error())
-	}
-	logger := ctrl.LoggerFrom(ctx)
-	scimClient, err := scim.NewSCIMClient(logger, config)
-	if err != nil {
-		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMRequestFailedReason, "Failed to create SCIM client")
-	}
-
-	// verify that the SCIM API can be accessed
-	opts := &scim.QueryOptions{
-		Filter:             scim.GroupFilterByDisplayName(org.Spec.MappedOrgAdminIDPGroup),
-		ExcludedAttributes: scim.SetAttributes(scim.AttrMembers),
-	}
-
-	groups, err := scimClient.GetGroups(ctx, opts)
-	if err != nil {
-		logger.Error(err, "Failed to request data from SCIM API")
-		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMRequestFailedReason, "Failed to request data from SCIM API")
-	}
-	if len(groups) == 0 {
-		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, greenhousesapv1alpha1.SCIMRequestFailedReason, org.Spec.MappedOrgAdminIDPGroup+" Group not found in SCIM API")
-	}
-
-	return greenhousesapv1alpha1.TrueCondition(greenhousesapv1alpha1.SCIMAPIAvailableCondition, lifecycle.CreatedReason, "SCIM API is available")
-}
-
 func calculateReadyCondition(scimAPIAvailableCondition greenhousesapv1alpha1.Condition) greenhousesapv1alpha1.Condition {
 	if scimAPIAvailableCondition.IsFalse() {
 		return greenhousesapv1alpha1.FalseCondition(greenhousesapv1alpha1.ReadyCondition, greenhousesapv1alpha1.SCIMAPIUnavailableReason, "")
@@ -351,7 +309,7 @@ func (r *OrganizationReconciler) setStatus() lifecycle.Conditioner {
 		if !ok {
 			return
 		}
-		scimAPIAvailableCondition := r.checkSCIMAPIAvailability(ctx, org)
+		scimAPIAvailableCondition := util.CheckSCIMAPIAvailability(ctx, r.Client, org)
 		readyCondition := calculateReadyCondition(scimAPIAvailableCondition)
 		org.Status.SetConditions(scimAPIAvailableCondition, readyCondition)
 	}
@@ -351,12 +351,12 @@ var _ = Describe("Test Organization reconciliation", Ordered, func() {
 					switch orgClient.ID {
 					case oidcOrgName:
 						Expect(orgClient.ID).To(Equal(oidcOrgName), "the oauth client ID should be equal to organization name")
-						Expect(orgClient.RedirectURIs).To(HaveLen(5), "the oauth client redirect URIs should have the default 3 elements + 2 additionalRedirects")
+						Expect(orgClient.RedirectURIs).To(HaveLen(7), "the oauth client redirect URIs should have the default 5 elements + 2 additionalRedirects")
 						Expect(orgClient.RedirectURIs).To(ContainElements("https://example.com/app", "http://localhost:33768/auth/callback"), "the oauth client redirect URIs should be equal to organization redirect URIs")
 					case greenhouseOrgName:
 						Expect(orgClient.ID).To(Equal(greenhouseOrgName), "the oauth client ID should be equal to organization name")
 						Expect(orgClient.RedirectURIs).To(ContainElements("https://test-oidc-org.dashboard."), "the greenhouse client should contain the org's dashboard redirect uri")
-						Expect(orgClient.RedirectURIs).To(HaveLen(5), "the oauth client redirect URIs should have 4 elements (default 3 + 1 org + 1 additional)")
+						Expect(orgClient.RedirectURIs).To(HaveLen(7), "the oauth client redirect URIs should have 7 elements (default 5 + 1 org + 1 additional)")
 					default:
 						Fail("unexpected oauth client ID")
 					}
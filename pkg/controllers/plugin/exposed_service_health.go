@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+const (
+	// DefaultExposedServiceHealthCheckInterval is the default interval in which exposed services are checked.
+	DefaultExposedServiceHealthCheckInterval = 5 * time.Minute
+	// exposedServiceHealthCheckTimeout is the timeout of a single health check request.
+	exposedServiceHealthCheckTimeout = 10 * time.Second
+)
+
+var (
+	exposedServiceUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "greenhouse_plugin_exposed_service_up",
+			Help: "The result of the health check of an exposed service of the plugin",
+		},
+		[]string{"cluster", "plugin", "namespace", "service", "service_namespace"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(exposedServiceUp)
+}
+
+// healthCheckFromService returns the health check configured by annotations on the exposed service or nil if none is configured.
+// An invalid expected status is returned as error together with the health check expecting the default status.
+func healthCheckFromService(o runtime.Object) (*greenhousev1alpha1.ServiceHealthCheck, error) {
+	svc, err := convertRuntimeObjectToCoreV1Service(o)
+	if err != nil {
+		return nil, err
+	}
+	path, ok := svc.Annotations[greenhouseapis.AnnotationKeyExposeHealthCheckPath]
+	if !ok {
+		return nil, nil
+	}
+	healthCheck := &greenhousev1alpha1.ServiceHealthCheck{
+		Path:           "/" + strings.TrimPrefix(path, "/"),
+		ExpectedStatus: http.StatusOK,
+	}
+	if status, ok := svc.Annotations[greenhouseapis.AnnotationKeyExposeHealthCheckStatus]; ok {
+		expectedStatus, err := strconv.ParseInt(status, 10, 32)
+		if err != nil {
+			return healthCheck, fmt.Errorf("invalid expected health check status %q of service %s: %w", status, svc.Name, err)
+		}
+		healthCheck.ExpectedStatus = int32(expectedStatus)
+	}
+	return healthCheck, nil
+}
+
+// reconcileExposedServicesHealth checks the health of all exposed services with a configured health check
+// and reflects the results in the ExposedServices and the ExposedServicesHealthyCondition of the Plugin.
+func (r *PluginReconciler) reconcileExposedServicesHealth(
+	ctx context.Context,
+	restClientGetter genericclioptions.RESTClientGetter,
+	plugin *greenhousev1alpha1.Plugin,
+) *reconcileResult {
+
+	// Drop the results of services that are no longer exposed or checked, the current ones are set again below.
+	deleteExposedServiceMetrics(plugin)
+
+	urls := make([]string, 0, len(plugin.Status.ExposedServices))
+	for url, svc := range plugin.Status.ExposedServices {
+		if svc.HealthCheck != nil {
+			urls = append(urls, url)
+		}
+	}
+	if len(urls) == 0 {
+		plugin.SetCondition(greenhousev1alpha1.TrueCondition(greenhousev1alpha1.ExposedServicesHealthyCondition, "",
+			"No health checks configured for exposed services"))
+		return nil
+	}
+	sort.Strings(urls)
+
+	restConfig, err := restClientGetter.ToRESTConfig()
+	if err != nil {
+		plugin.SetCondition(greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ExposedServicesHealthyCondition, "", err.Error()))
+		return nil
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		plugin.SetCondition(greenhousev1alpha1.UnknownCondition(greenhousev1alpha1.ExposedServicesHealthyCondition, "", err.Error()))
+		return nil
+	}
+
+	var unhealthy []string
+	for _, url := range urls {
+		svc := plugin.Status.ExposedServices[url]
+		healthy, message := probeExposedService(ctx, clientset, svc)
+		svc.Healthy = ptr.To(healthy)
+		plugin.Status.ExposedServices[url] = svc
+
+		value := 0.0
+		if healthy {
+			value = 1
+		} else {
+			unhealthy = append(unhealthy, fmt.Sprintf("%s/%s: %s", svc.Namespace, svc.Name, message))
+		}
+		exposedServiceUp.WithLabelValues(plugin.Spec.ClusterName, plugin.Name, plugin.Namespace, svc.Name, svc.Namespace).Set(value)
+	}
+
+	if len(unhealthy) > 0 {
+		plugin.SetCondition(greenhousev1alpha1.FalseCondition(greenhousev1alpha1.ExposedServicesHealthyCondition, "",
+			"Following exposed services are not healthy: [ "+strings.Join(unhealthy, ", ")+" ]"))
+	} else {
+		plugin.SetCondition(greenhousev1alpha1.TrueCondition(greenhousev1alpha1.ExposedServicesHealthyCondition, "",
+			"All exposed services are healthy"))
+	}
+
+	if r.ExposedServiceHealthCheckInterval <= 0 {
+		return nil
+	}
+	return &reconcileResult{requeueAfter: r.ExposedServiceHealthCheckInterval}
+}
+
+// deleteExposedServiceMetrics removes the health check results of all exposed services of the Plugin.
+func deleteExposedServiceMetrics(plugin *greenhousev1alpha1.Plugin) {
+	exposedServiceUp.DeletePartialMatch(prometheus.Labels{"plugin": plugin.Name, "namespace": plugin.Namespace})
+}
+
+// probeExposedService requests the health check path of the service through the API server service proxy.
+// It returns whether the service responded with the expected status and a message otherwise.
+func probeExposedService(ctx context.Context, clientset kubernetes.Interface, svc greenhousev1alpha1.Service) (healthy bool, message string) {
+	ctx, cancel := context.WithTimeout(ctx, exposedServiceHealthCheckTimeout)
+	defer cancel()
+
+	serviceName := fmt.Sprintf("%s:%d", svc.Name, svc.Port)
+	if svc.Protocol != nil && *svc.Protocol == "https" {
+		serviceName = "https:" + serviceName
+	}
+	var statusCode int
+	result := clientset.CoreV1().RESTClient().Get().
+		Namespace(svc.Namespace).
+		Resource("services").
+		Name(serviceName).
+		SubResource("proxy").
+		Suffix(svc.HealthCheck.Path).
+		Do(ctx).
+		StatusCode(&statusCode)
+	if statusCode == 0 {
+		return false, "request failed: " + result.Error().Error()
+	}
+	if int32(statusCode) != svc.HealthCheck.ExpectedStatus { //nolint:gosec
+		return false, fmt.Sprintf("expected status %d, got %d", svc.HealthCheck.ExpectedStatus, statusCode)
+	}
+	return true, ""
+}
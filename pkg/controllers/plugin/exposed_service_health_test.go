@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+func TestHealthCheckFromService(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    *greenhousev1alpha1.ServiceHealthCheck
+		expectErr   bool
+	}{
+		{
+			name:     "no health check configured",
+			expected: nil,
+		},
+		{
+			name:        "path with default status",
+			annotations: map[string]string{greenhouseapis.AnnotationKeyExposeHealthCheckPath: "healthz"},
+			expected:    &greenhousev1alpha1.ServiceHealthCheck{Path: "/healthz", ExpectedStatus: http.StatusOK},
+		},
+		{
+			name: "path with expected status",
+			annotations: map[string]string{
+				greenhouseapis.AnnotationKeyExposeHealthCheckPath:   "/-/ready",
+				greenhouseapis.AnnotationKeyExposeHealthCheckStatus: "204",
+			},
+			expected: &greenhousev1alpha1.ServiceHealthCheck{Path: "/-/ready", ExpectedStatus: http.StatusNoContent},
+		},
+		{
+			name: "invalid expected status",
+			annotations: map[string]string{
+				greenhouseapis.AnnotationKeyExposeHealthCheckPath:   "/healthz",
+				greenhouseapis.AnnotationKeyExposeHealthCheckStatus: "ok",
+			},
+			expected:  &greenhousev1alpha1.ServiceHealthCheck{Path: "/healthz", ExpectedStatus: http.StatusOK},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test-service", Annotations: tt.annotations}}
+			healthCheck, err := healthCheckFromService(svc)
+			if tt.expectErr && err == nil {
+				t.Error("expected error, got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (healthCheck == nil) != (tt.expected == nil) || healthCheck != nil && *healthCheck != *tt.expected {
+				t.Errorf("expected health check %v, got %v", tt.expected, healthCheck)
+			}
+		})
+	}
+}
+
+// TestProbeExposedService tests that the health check is sent to the API server service proxy and the status code is evaluated.
+func TestProbeExposedService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/v1/namespaces/monitoring/services/prometheus:9090/proxy/-/ready":
+			rw.WriteHeader(http.StatusOK)
+		case "/api/v1/namespaces/monitoring/services/https:alertmanager:9093/proxy/healthz":
+			rw.WriteHeader(http.StatusNoContent)
+		default:
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create clientset: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		svc           greenhousev1alpha1.Service
+		expectHealthy bool
+	}{
+		{
+			name: "healthy service",
+			svc: greenhousev1alpha1.Service{
+				Namespace: "monitoring", Name: "prometheus", Port: 9090,
+				HealthCheck: &greenhousev1alpha1.ServiceHealthCheck{Path: "/-/ready", ExpectedStatus: http.StatusOK},
+			},
+			expectHealthy: true,
+		},
+		{
+			name: "healthy https service with custom status",
+			svc: greenhousev1alpha1.Service{
+				Namespace: "monitoring", Name: "alertmanager", Port: 9093, Protocol: ptr.To("https"),
+				HealthCheck: &greenhousev1alpha1.ServiceHealthCheck{Path: "/healthz", ExpectedStatus: http.StatusNoContent},
+			},
+			expectHealthy: true,
+		},
+		{
+			name: "unhealthy service",
+			svc: greenhousev1alpha1.Service{
+				Namespace: "monitoring", Name: "prometheus", Port: 9090,
+				HealthCheck: &greenhousev1alpha1.ServiceHealthCheck{Path: "/healthz", ExpectedStatus: http.StatusOK},
+			},
+			expectHealthy: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthy, message := probeExposedService(context.Background(), clientset, tt.svc)
+			if healthy != tt.expectHealthy {
+				t.Errorf("expected healthy %v, got %v: %s", tt.expectHealthy, healthy, message)
+			}
+		})
+	}
+}
+
+// TestDeleteExposedServiceMetrics tests that only the health check results of the given Plugin are removed.
+func TestDeleteExposedServiceMetrics(t *testing.T) {
+	exposedServiceUp.Reset()
+	exposedServiceUp.WithLabelValues("cluster-a", "test-plugin", "org-a", "prometheus", "monitoring").Set(0)
+	exposedServiceUp.WithLabelValues("cluster-a", "test-plugin", "org-b", "prometheus", "monitoring").Set(1)
+
+	deleteExposedServiceMetrics(&greenhousev1alpha1.Plugin{ObjectMeta: metav1.ObjectMeta{Namespace: "org-a", Name: "test-plugin"}})
+
+	if count := testutil.CollectAndCount(exposedServiceUp); count != 1 {
+		t.Fatalf("expected 1 remaining series, got %d", count)
+	}
+	if value := testutil.ToFloat64(exposedServiceUp.WithLabelValues("cluster-a", "test-plugin", "org-b", "prometheus", "monitoring")); value != 1 {
+		t.Errorf("expected the series of the other organization to be kept, got %v", value)
+	}
+}
@@ -42,7 +42,9 @@ const helmReleaseSecretType = "helm.sh/release.v1" //nolint:gosec
 type PluginReconciler struct {
 	client.Client
 	KubeRuntimeOpts clientutil.RuntimeOptions
-	kubeClientOpts  []clientutil.KubeClientOption
+	// ExposedServiceHealthCheckInterval is the interval in which exposed services with a configured health check are probed.
+	ExposedServiceHealthCheckInterval time.Duration
+	kubeClientOpts                    []clientutil.KubeClientOption
 }
 
 //+kubebuilder:rbac:groups=greenhouse.sap,resources=plugindefinitions,verbs=get;list;watch;create;update;patch;delete
@@ -120,6 +122,14 @@ func (r *PluginReconciler) setConditions() lifecycle.Conditioner {
 func (r *PluginReconciler) EnsureDeleted(ctx context.Context, resource lifecycle.RuntimeObject) (ctrl.Result, lifecycle.ReconcileResult, error) {
 	plugin := resource.(*greenhousev1alpha1.Plugin) //nolint:errcheck
 
+	deleteExposedServiceMetrics(plugin)
+
+	// A detached Plugin keeps its Helm release running in the cluster.
+	if plugin.GetAnnotations()[greenhouseapis.PluginDetachAnnotation] == "true" {
+		log.FromContext(ctx).Info("detaching helm release", "namespace", plugin.Spec.ReleaseNamespace, "name", plugin.Name)
+		return ctrl.Result{}, lifecycle.Success, nil
+	}
+
 	restClientGetter, err := initClientGetter(ctx, r.Client, r.kubeClientOpts, *plugin)
 	if err != nil {
 		metrics.UpdateMetrics(plugin, metrics.MetricResultError, metrics.MetricReasonClusterAccessFailed)
@@ -177,6 +187,8 @@ func (r *PluginReconciler) EnsureCreated(ctx context.Context, resource lifecycle
 
 	helmChartTestResult, helmChartTestErr := r.reconcileHelmChartTest(ctx, plugin)
 
+	exposedServicesHealthResult := r.reconcileExposedServicesHealth(ctx, restClientGetter, plugin)
+
 	if reconcileErr != nil {
 		return ctrl.Result{}, lifecycle.Failed, fmt.Errorf("helm reconcile failed: %s", reconcileErr.Error())
 	}
@@ -192,6 +204,9 @@ func (r *PluginReconciler) EnsureCreated(ctx context.Context, resource lifecycle
 	if helmChartTestResult != nil {
 		return ctrl.Result{RequeueAfter: helmChartTestResult.requeueAfter}, lifecycle.Pending, nil
 	}
+	if exposedServicesHealthResult != nil {
+		return ctrl.Result{RequeueAfter: exposedServicesHealthResult.requeueAfter}, lifecycle.Success, nil
+	}
 
 	return ctrl.Result{}, lifecycle.Success, nil
 }
@@ -311,7 +326,7 @@ func (r *PluginReconciler) reconcileStatus(ctx context.Context,
 	helmRelease, err := helm.GetReleaseForHelmChartFromPlugin(ctx, restClientGetter, plugin)
 	if err == nil {
 		// Ensure the status is always reported.
-		serviceList, err := getExposedServicesForPluginFromHelmRelease(restClientGetter, helmRelease, plugin)
+		serviceList, err := getExposedServicesForPluginFromHelmRelease(ctx, restClientGetter, helmRelease, plugin)
 		if err == nil {
 			exposedServices = serviceList
 			plugin.SetCondition(greenhousev1alpha1.TrueCondition(greenhousev1alpha1.StatusUpToDateCondition, "", ""))
@@ -406,7 +421,7 @@ func enqueuePluginForReleaseSecret(_ context.Context, o client.Object) []ctrl.Re
 
 // getExposedServicesForPluginFromHelmRelease returns a map of exposed services for a plugin from a Helm release.
 // The exposed services are collected from Helm release manifest and not from the template to make sure they are deployed.
-func getExposedServicesForPluginFromHelmRelease(restClientGetter genericclioptions.RESTClientGetter, helmRelease *release.Release, plugin *greenhousev1alpha1.Plugin) (map[string]greenhousev1alpha1.Service, error) {
+func getExposedServicesForPluginFromHelmRelease(ctx context.Context, restClientGetter genericclioptions.RESTClientGetter, helmRelease *release.Release, plugin *greenhousev1alpha1.Plugin) (map[string]greenhousev1alpha1.Service, error) {
 	// Collect exposed services from the manifest.
 	exposedServiceList, err := helm.ObjectMapFromRelease(restClientGetter, helmRelease, &helm.ManifestObjectFilter{
 		APIVersion: "v1",
@@ -434,12 +449,18 @@ func getExposedServicesForPluginFromHelmRelease(restClientGetter genericclioptio
 		if namespace == "" {
 			namespace = helmRelease.Namespace // default namespace to release namespace
 		}
+		healthCheck, err := healthCheckFromService(svc.Object)
+		if err != nil {
+			// An invalid annotation must not fail the Plugin, the default expected status is used instead.
+			log.FromContext(ctx).Error(err, "using default health check status", "service", svc.Name)
+		}
 		exposedURL := common.URLForExposedServiceInPlugin(svc.Name, plugin)
 		exposedServices[exposedURL] = greenhousev1alpha1.Service{
-			Namespace: namespace,
-			Name:      svc.Name,
-			Protocol:  svcPort.AppProtocol,
-			Port:      svcPort.Port,
+			Namespace:   namespace,
+			Name:        svc.Name,
+			Protocol:    svcPort.AppProtocol,
+			Port:        svcPort.Port,
+			HealthCheck: healthCheck,
 		}
 	}
 	return exposedServices, nil
@@ -126,6 +126,12 @@ func (r *PluginPresetReconciler) EnsureDeleted(ctx context.Context, resource lif
 	}
 	allErrs := make([]error, 0)
 	for _, plugin := range plugins.Items {
+		if pluginPreset.Spec.DeletionPolicy == greenhousev1alpha1.DeletionPolicyOrphan {
+			if err := r.orphanPlugin(ctx, pluginPreset, &plugin); err != nil {
+				allErrs = append(allErrs, err)
+			}
+			continue
+		}
 		if err := r.Client.Delete(ctx, &plugin); err != nil && !apierrors.IsNotFound(err) {
 			allErrs = append(allErrs, err)
 		}
@@ -139,6 +145,23 @@ func (r *PluginPresetReconciler) EnsureDeleted(ctx context.Context, resource lif
 	return ctrl.Result{}, lifecycle.Success, nil
 }
 
+// orphanPlugin removes the owner reference and the PluginPreset label from the Plugin.
+// The Plugin keeps running and is no longer managed by the PluginPreset.
+func (r *PluginPresetReconciler) orphanPlugin(ctx context.Context, preset *greenhousev1alpha1.PluginPreset, plugin *greenhousev1alpha1.Plugin) error {
+	_, err := clientutil.Patch(ctx, r.Client, plugin, func() error {
+		delete(plugin.Labels, greenhouseapis.LabelKeyPluginPreset)
+		plugin.SetOwnerReferences(slices.DeleteFunc(plugin.GetOwnerReferences(), func(ref metav1.OwnerReference) bool {
+			return ref.UID == preset.UID
+		}))
+		return nil
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	log.FromContext(ctx).Info("orphaned plugin of deleted PluginPreset", "plugin", plugin.Name)
+	return nil
+}
+
 // reconcilePluginPreset reconciles the PluginPreset by creating or updating the Plugins for the given clusters.
 // It skips reconciliation for Plugins that do not have the labels of the PluginPreset.
 func (r *PluginPresetReconciler) reconcilePluginPreset(ctx context.Context, preset *greenhousev1alpha1.PluginPreset, clusters *greenhousev1alpha1.ClusterList) error {
@@ -155,7 +178,7 @@ func (r *PluginPresetReconciler) reconcilePluginPreset(ctx context.Context, pres
 
 	for _, cluster := range clusters.Items {
 		plugin := &greenhousev1alpha1.Plugin{}
-		err := r.Get(ctx, client.ObjectKey{Namespace: preset.GetNamespace(), Name: generatePluginName(preset, &cluster)}, plugin)
+		err := r.Get(ctx, client.ObjectKey{Namespace: preset.GetNamespace(), Name: GeneratePluginName(preset, cluster.GetName())}, plugin)
 
 		switch {
 		case !cluster.DeletionTimestamp.IsZero():
@@ -170,7 +193,7 @@ func (r *PluginPresetReconciler) reconcilePluginPreset(ctx context.Context, pres
 		case apierrors.IsNotFound(err):
 			plugin = &greenhousev1alpha1.Plugin{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      generatePluginName(preset, &cluster),
+					Name:      GeneratePluginName(preset, cluster.GetName()),
 					Namespace: preset.GetNamespace(),
 				},
 			}
@@ -190,7 +213,7 @@ func (r *PluginPresetReconciler) reconcilePluginPreset(ctx context.Context, pres
 			plugin.Spec.ClusterName = cluster.GetName()
 
 			// overrides options based on preset definition
-			overridesPluginOptionValues(plugin, preset)
+			OverridesPluginOptionValues(plugin, preset)
 			return nil
 		})
 		if err != nil {
@@ -324,7 +347,8 @@ func shouldSkipPlugin(plugin *greenhousev1alpha1.Plugin, preset *greenhousev1alp
 	return true
 }
 
-func overridesPluginOptionValues(plugin *greenhousev1alpha1.Plugin, preset *greenhousev1alpha1.PluginPreset) {
+// OverridesPluginOptionValues overrides the option values of the Plugin with the PluginPreset's overrides for the Plugin's cluster.
+func OverridesPluginOptionValues(plugin *greenhousev1alpha1.Plugin, preset *greenhousev1alpha1.PluginPreset) {
 	index := slices.IndexFunc(preset.Spec.ClusterOptionOverrides, func(override greenhousev1alpha1.ClusterOptionOverride) bool {
 		return override.ClusterName == plugin.Spec.ClusterName
 	})
@@ -348,9 +372,9 @@ func overridesPluginOptionValues(plugin *greenhousev1alpha1.Plugin, preset *gree
 	}
 }
 
-// generatePluginName generates a name for a plugin based on the used PluginPreset's name and the Cluster.
-func generatePluginName(p *greenhousev1alpha1.PluginPreset, cluster *greenhousev1alpha1.Cluster) string {
-	return fmt.Sprintf("%s-%s", p.Name, cluster.GetName())
+// GeneratePluginName generates a name for a plugin based on the used PluginPreset's name and the Cluster.
+func GeneratePluginName(p *greenhousev1alpha1.PluginPreset, clusterName string) string {
+	return fmt.Sprintf("%s-%s", p.Name, clusterName)
 }
 
 func initPluginPresetStatus(p *greenhousev1alpha1.PluginPreset) {
@@ -307,6 +307,37 @@ var _ = Describe("PluginPreset Controller Lifecycle", Ordered, func() {
 		}).Should(Succeed(), "the PluginPreset should be reconciled")
 	})
 
+	It("should orphan the managed Plugins when deleting a PluginPreset with the Orphan deletion policy", func() {
+		By("creating a PluginPreset with the Orphan deletion policy")
+		testPluginPreset := pluginPreset(pluginPresetName+"-orphan", clusterA)
+		testPluginPreset.Spec.DeletionPolicy = greenhousev1alpha1.DeletionPolicyOrphan
+		Expect(test.K8sClient.Create(test.Ctx, testPluginPreset)).To(Succeed(), "failed to create PluginPreset")
+
+		pluginObjectKey := types.NamespacedName{Name: pluginPresetName + "-orphan-" + clusterA, Namespace: test.TestNamespace}
+		plugin := &greenhousev1alpha1.Plugin{}
+		Eventually(func() error {
+			return test.K8sClient.Get(test.Ctx, pluginObjectKey, plugin)
+		}).Should(Succeed(), "the Plugin should be created successfully")
+
+		By("deleting the PluginPreset")
+		_, err := clientutil.Patch(test.Ctx, test.K8sClient, testPluginPreset, func() error {
+			delete(testPluginPreset.Annotations, preventDeletionAnnotation)
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred(), "failed to patch PluginPreset")
+		test.EventuallyDeleted(test.Ctx, test.K8sClient, testPluginPreset)
+
+		By("checking that the Plugin is kept without owner reference")
+		Consistently(func(g Gomega) {
+			g.Expect(test.K8sClient.Get(test.Ctx, pluginObjectKey, plugin)).To(Succeed(), "the Plugin should not be deleted")
+			g.Expect(plugin.DeletionTimestamp).To(BeNil(), "the Plugin should not be deleted")
+			g.Expect(plugin.OwnerReferences).To(BeEmpty(), "the Plugin should not have an owner reference")
+			g.Expect(plugin.Labels).ToNot(HaveKey(greenhouseapis.LabelKeyPluginPreset), "the Plugin should not be managed by the PluginPreset")
+		}).Should(Succeed(), "the Plugin should be orphaned")
+
+		Expect(test.K8sClient.Delete(test.Ctx, plugin)).To(Succeed(), "failed to delete orphaned Plugin")
+	})
+
 	It("should reconcile PluginStatuses for PluginPreset", func() {
 		By("onboarding another Cluster")
 		err := test.K8sClient.Create(test.Ctx, cluster(otherTestClusterName))
@@ -1029,9 +1060,9 @@ var _ = Describe("Plugin Preset skip changes", Ordered, func() {
 	)
 })
 
-var _ = Describe("overridesPluginOptionValues", Ordered, func() {
+var _ = Describe("OverridesPluginOptionValues", Ordered, func() {
 	DescribeTable("test cases", func(plugin *greenhousev1alpha1.Plugin, preset *greenhousev1alpha1.PluginPreset, expectedPlugin *greenhousev1alpha1.Plugin) {
-		overridesPluginOptionValues(plugin, preset)
+		OverridesPluginOptionValues(plugin, preset)
 		Expect(plugin).To(BeEquivalentTo(expectedPlugin))
 	},
 		Entry("with no defined pluginPresetOverrides",
@@ -285,6 +285,10 @@ func shouldReconcileOrRequeue(ctx context.Context, c client.Client, plugin *gree
 	if err != nil {
 		return nil, err
 	}
+	if targetOrganization, ok := cluster.GetAnnotations()[greenhouseapis.ClusterMigrateToOrganizationAnnotation]; ok {
+		logger.Info("cluster is being migrated, will skip reconciliation", "cluster", cluster.Name, "targetOrganization", targetOrganization)
+		return &reconcileResult{requeueAfter: time.Minute}, nil
+	}
 	scheduleExists, schedule, err := clientutil.ExtractDeletionSchedule(cluster.GetAnnotations())
 	if err != nil {
 		return nil, err
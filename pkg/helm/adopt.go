@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+// greenhouseValuesPrefix is the prefix of the values injected by Greenhouse, which are not adopted into the Plugin.
+const greenhouseValuesPrefix = "global.greenhouse."
+
+// invalidSecretKeyChars matches the characters not allowed in the keys of a Secret.
+var invalidSecretKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// ListDeployedReleases returns the deployed Helm releases in all namespaces of the cluster.
+func ListDeployedReleases(restClientGetter genericclioptions.RESTClientGetter) ([]*release.Release, error) {
+	cfg, err := newHelmAction(restClientGetter, "")
+	if err != nil {
+		return nil, err
+	}
+	listAction := action.NewList(cfg)
+	listAction.AllNamespaces = true
+	listAction.StateMask = action.ListDeployed
+	return listAction.Run()
+}
+
+// PluginForRelease returns a Plugin adopting the given Helm release.
+// The PluginDefinition is matched by the name of the Helm chart, preferring the one with the same chart version.
+// The user-supplied values of the release become the option values of the Plugin, so the release is upgraded in place instead of being reinstalled.
+// Values of secret options are moved to the returned Secret and referenced by the Plugin. The Secret is nil if there are none.
+func PluginForRelease(rel *release.Release, pluginDefinitions []greenhousev1alpha1.PluginDefinition, namespace, clusterName string) (*greenhousev1alpha1.Plugin, *corev1.Secret, error) {
+	if rel.Chart == nil || rel.Chart.Metadata == nil {
+		return nil, nil, fmt.Errorf("release %s/%s has no chart metadata", rel.Namespace, rel.Name)
+	}
+	var pluginDefinition *greenhousev1alpha1.PluginDefinition
+	for i, pd := range pluginDefinitions {
+		if pd.Spec.HelmChart == nil || pd.Spec.HelmChart.Name != rel.Chart.Metadata.Name {
+			continue
+		}
+		if pluginDefinition == nil || pd.Spec.HelmChart.Version == rel.Chart.Metadata.Version {
+			pluginDefinition = &pluginDefinitions[i]
+		}
+	}
+	if pluginDefinition == nil {
+		return nil, nil, fmt.Errorf("no PluginDefinition found for chart %s of release %s/%s", rel.Chart.Metadata.Name, rel.Namespace, rel.Name)
+	}
+
+	optionValues, err := flattenReleaseValues("", rel.Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert the values of release %s/%s: %w", rel.Namespace, rel.Name, err)
+	}
+	slices.SortFunc(optionValues, func(a, b greenhousev1alpha1.PluginOptionValue) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	secret, err := extractSecretValues(optionValues, pluginDefinition, rel.Name+"-values", namespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert the secret values of release %s/%s: %w", rel.Namespace, rel.Name, err)
+	}
+
+	plugin := &greenhousev1alpha1.Plugin{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: greenhousev1alpha1.GroupVersion.String(),
+			Kind:       "Plugin",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rel.Name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				greenhouseapis.LabelKeyCluster: clusterName,
+			},
+		},
+		Spec: greenhousev1alpha1.PluginSpec{
+			PluginDefinition: pluginDefinition.GetName(),
+			ClusterName:      clusterName,
+			ReleaseNamespace: rel.Namespace,
+			OptionValues:     optionValues,
+		},
+	}
+	return plugin, secret, nil
+}
+
+// extractSecretValues moves the values of the secret options of the PluginDefinition into a Secret with the given name
+// and replaces them by references to it. It returns nil if there are no values of secret options.
+func extractSecretValues(optionValues []greenhousev1alpha1.PluginOptionValue, pluginDefinition *greenhousev1alpha1.PluginDefinition, name, namespace string) (*corev1.Secret, error) {
+	secretOptions := make(map[string]struct{})
+	for _, option := range pluginDefinition.Spec.Options {
+		if option.Type == greenhousev1alpha1.PluginOptionTypeSecret {
+			secretOptions[option.Name] = struct{}{}
+		}
+	}
+	data := make(map[string][]byte)
+	for i, optionValue := range optionValues {
+		if _, ok := secretOptions[optionValue.Name]; !ok || optionValue.Value == nil {
+			continue
+		}
+		key := invalidSecretKeyChars.ReplaceAllString(optionValue.Name, "_")
+		if _, exists := data[key]; exists {
+			return nil, fmt.Errorf("secret options %s share the key %s", optionValue.Name, key)
+		}
+		// String values are stored as is, all other values as JSON.
+		var value string
+		if err := json.Unmarshal(optionValue.Value.Raw, &value); err != nil {
+			value = string(optionValue.Value.Raw)
+		}
+		data[key] = []byte(value)
+		optionValues[i] = greenhousev1alpha1.PluginOptionValue{
+			Name: optionValue.Name,
+			ValueFrom: &greenhousev1alpha1.ValueFromSource{
+				Secret: &greenhousev1alpha1.SecretKeyReference{Name: name, Key: key},
+			},
+		}
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}, nil
+}
+
+// flattenReleaseValues converts nested Helm values into option values named by their path.
+// Lists are kept as a single value. Characters with a special meaning in Helm value paths are escaped.
+func flattenReleaseValues(prefix string, values map[string]any) ([]greenhousev1alpha1.PluginOptionValue, error) {
+	var optionValues []greenhousev1alpha1.PluginOptionValue
+	for k, v := range values {
+		name := prefix + escapeValueKey(k)
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			nestedValues, err := flattenReleaseValues(name+".", nested)
+			if err != nil {
+				return nil, err
+			}
+			optionValues = append(optionValues, nestedValues...)
+			continue
+		}
+		if strings.HasPrefix(name+".", greenhouseValuesPrefix) {
+			continue
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		optionValues = append(optionValues, greenhousev1alpha1.PluginOptionValue{Name: name, Value: &apiextensionsv1.JSON{Raw: raw}})
+	}
+	return optionValues, nil
+}
+
+func escapeValueKey(key string) string {
+	return strings.NewReplacer(`.`, `\.`, `[`, `\[`, `,`, `\,`, `=`, `\=`).Replace(key)
+}
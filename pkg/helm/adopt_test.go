@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package helm_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/release"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/helm"
+	"github.com/cloudoperators/greenhouse/pkg/test"
+)
+
+var _ = Describe("Adopting Helm releases", func() {
+	pluginDefinitions := []greenhousev1alpha1.PluginDefinition{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress-old"},
+			Spec:       greenhousev1alpha1.PluginDefinitionSpec{HelmChart: &greenhousev1alpha1.HelmChartReference{Name: "ingress-nginx", Version: "1.0.0"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress"},
+			Spec:       greenhousev1alpha1.PluginDefinitionSpec{HelmChart: &greenhousev1alpha1.HelmChartReference{Name: "ingress-nginx", Version: "2.0.0"}},
+		},
+	}
+
+	It("should convert the release into a Plugin", func() {
+		rel := &release.Release{
+			Name:      "ingress",
+			Namespace: "ingress-system",
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "ingress-nginx", Version: "2.0.0"}},
+			Config: map[string]any{
+				"replicas": 2,
+				"controller": map[string]any{
+					"image": map[string]any{"tag": "v1"},
+					"args":  []any{"--verbose"},
+				},
+				"annotations": map[string]any{"example.com/owner": "team"},
+				"global":      map[string]any{"greenhouse": map[string]any{"clusterNames": []any{"a"}}},
+			},
+		}
+		plugin, secret, err := helm.PluginForRelease(rel, pluginDefinitions, "test-org", "test-cluster")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error converting the release")
+		Expect(secret).To(BeNil(), "there should be no secret without values of secret options")
+		Expect(plugin.GetName()).To(Equal("ingress"))
+		Expect(plugin.GetNamespace()).To(Equal("test-org"))
+		Expect(plugin.Spec.PluginDefinition).To(Equal("ingress"), "the PluginDefinition with the same chart version should be preferred")
+		Expect(plugin.Spec.ClusterName).To(Equal("test-cluster"))
+		Expect(plugin.Spec.ReleaseNamespace).To(Equal("ingress-system"))
+		Expect(plugin.Spec.OptionValues).To(Equal([]greenhousev1alpha1.PluginOptionValue{
+			{Name: `annotations.example\.com/owner`, Value: test.MustReturnJSONFor("team")},
+			{Name: "controller.args", Value: test.MustReturnJSONFor([]string{"--verbose"})},
+			{Name: "controller.image.tag", Value: test.MustReturnJSONFor("v1")},
+			{Name: "replicas", Value: test.MustReturnJSONFor(2)},
+		}), "the user-supplied values without the greenhouse values should be adopted")
+	})
+
+	It("should move the values of secret options into a Secret", func() {
+		secretPluginDefinitions := []greenhousev1alpha1.PluginDefinition{{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress"},
+			Spec: greenhousev1alpha1.PluginDefinitionSpec{
+				HelmChart: &greenhousev1alpha1.HelmChartReference{Name: "ingress-nginx", Version: "2.0.0"},
+				Options: []greenhousev1alpha1.PluginOption{
+					{Name: "auth.password", Type: greenhousev1alpha1.PluginOptionTypeSecret},
+					{Name: "auth.user", Type: greenhousev1alpha1.PluginOptionTypeString},
+				},
+			},
+		}}
+		rel := &release.Release{
+			Name:      "ingress",
+			Namespace: "ingress-system",
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "ingress-nginx", Version: "2.0.0"}},
+			Config:    map[string]any{"auth": map[string]any{"password": "s3cr3t", "user": "admin"}},
+		}
+		plugin, secret, err := helm.PluginForRelease(rel, secretPluginDefinitions, "test-org", "test-cluster")
+		Expect(err).ToNot(HaveOccurred(), "there should be no error converting the release")
+		Expect(secret).ToNot(BeNil(), "the values of secret options should be moved into a Secret")
+		Expect(secret.GetName()).To(Equal("ingress-values"))
+		Expect(secret.GetNamespace()).To(Equal("test-org"))
+		Expect(secret.Data).To(Equal(map[string][]byte{"auth.password": []byte("s3cr3t")}))
+		Expect(plugin.Spec.OptionValues).To(Equal([]greenhousev1alpha1.PluginOptionValue{
+			{Name: "auth.password", ValueFrom: &greenhousev1alpha1.ValueFromSource{Secret: &greenhousev1alpha1.SecretKeyReference{Name: "ingress-values", Key: "auth.password"}}},
+			{Name: "auth.user", Value: test.MustReturnJSONFor("admin")},
+		}), "the secret option should reference the Secret")
+	})
+
+	It("should fail without a matching PluginDefinition", func() {
+		rel := &release.Release{
+			Name:      "unknown",
+			Namespace: "default",
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "unknown", Version: "1.0.0"}},
+		}
+		_, _, err := helm.PluginForRelease(rel, pluginDefinitions, "test-org", "test-cluster")
+		Expect(err).To(HaveOccurred(), "there should be an error if no PluginDefinition matches the chart")
+	})
+})
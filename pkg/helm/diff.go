@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -24,6 +25,8 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/yaml"
+
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 )
 
 const (
@@ -33,6 +36,9 @@ const (
 	secretAfterMask        = "***** - after"
 )
 
+// manifestSeparator splits a Helm manifest into its documents.
+var manifestSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
 type (
 	// DiffObject is a Kubernetes object where the deployed state differs from the one in the Helm chart manifest.
 	DiffObject struct {
@@ -339,6 +345,87 @@ func maskSecret(live, merged runtime.Object) (maskedBefore, maskedAfter runtime.
 	return live, merged, nil
 }
 
+// MaskSecret returns an unstructured copy of the object with all values under data and stringData masked if it is a Secret.
+// The mask is not valid base64, hence typed Secrets cannot hold it. Other objects are returned unchanged.
+func MaskSecret(o runtime.Object) (runtime.Object, error) {
+	if !isSecret(o) {
+		return o, nil
+	}
+	u, err := toUnstructeredContent(o)
+	if err != nil || u == nil {
+		return o, err
+	}
+	for _, field := range []string{"data", "stringData"} {
+		data, found, err := unstructured.NestedMap(u, field)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s from secret: %w", field, err)
+		}
+		if !found {
+			continue
+		}
+		for k := range data {
+			data[k] = secretMask
+		}
+		if err := unstructured.SetNestedMap(u, data, field); err != nil {
+			return nil, fmt.Errorf("failed to set masked %s in secret: %w", field, err)
+		}
+	}
+	return &unstructured.Unstructured{Object: u}, nil
+}
+
+// MaskSecretOptionValues masks the Helm values of the options of the Plugin that are read from Secrets.
+func MaskSecretOptionValues(values map[string]any, plugin *greenhousev1alpha1.Plugin) map[string]any {
+	for _, optionValue := range plugin.Spec.OptionValues {
+		if optionValue.ValueFrom == nil {
+			continue
+		}
+		fields := strings.Split(optionValue.Name, ".")
+		if _, found, err := unstructured.NestedFieldNoCopy(values, fields...); err != nil || !found {
+			continue
+		}
+		// Values of the path not being maps have been checked above, setting the field cannot fail.
+		_ = unstructured.SetNestedField(values, secretMask, fields...)
+	}
+	return values
+}
+
+// MaskManifestSecrets returns the Helm manifest with the data of all Secrets masked.
+// Documents that are not Secrets are returned as they are, including the comments added by Helm.
+func MaskManifestSecrets(manifest string) (string, error) {
+	documents := manifestSeparator.Split(manifest, -1)
+	for i, document := range documents {
+		u := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(document), &u.Object); err != nil || u.Object == nil {
+			continue
+		}
+		if !isSecret(u) {
+			continue
+		}
+		masked, err := MaskSecret(u)
+		if err != nil {
+			return "", err
+		}
+		data, err := yaml.Marshal(masked)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal masked secret %s: %w", u.GetName(), err)
+		}
+		documents[i] = "\n" + manifestComments(document) + string(data)
+	}
+	return strings.Join(documents, "---"), nil
+}
+
+// manifestComments returns the leading comment lines of a manifest document, e.g. the template source added by Helm.
+func manifestComments(document string) string {
+	var comments strings.Builder
+	for _, line := range strings.Split(strings.TrimLeft(document, "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comments.WriteString(line + "\n")
+	}
+	return comments.String()
+}
+
 // toUnstructeredContent returns the unstructured content of an runtime.Object.
 func toUnstructeredContent(o runtime.Object) (map[string]any, error) {
 	if o == nil {
@@ -513,3 +513,48 @@ var _ = Describe("Ensure helm diff does not leak secrets", Ordered, func() {
 		})
 	})
 })
+
+var _ = Describe("Masking secrets in manifests and values", func() {
+	It("should mask the data of Secrets in the manifest and keep other documents", func() {
+		manifest := `---
+# Source: test/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: test-secret
+data:
+  test: dGVzdC12YWx1ZQ==
+stringData:
+  cert: certificate-data
+---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-configmap
+data:
+  key: value
+`
+		masked, err := helm.MaskManifestSecrets(manifest)
+		Expect(err).NotTo(HaveOccurred(), "there should be no error masking the manifest")
+		Expect(masked).NotTo(ContainSubstring("dGVzdC12YWx1ZQ=="), "the manifest should not contain the value of the data")
+		Expect(masked).NotTo(ContainSubstring("certificate-data"), "the manifest should not contain the value of the stringData")
+		Expect(masked).To(ContainSubstring("# Source: test/templates/secret.yaml\napiVersion: v1"), "the comment of the Secret should be kept")
+		Expect(masked).To(ContainSubstring("test: '*****'"), "the keys of the Secret should be kept")
+		Expect(masked).To(HaveSuffix("---\n# Source: test/templates/configmap.yaml\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test-configmap\ndata:\n  key: value\n"), "the ConfigMap should be unchanged")
+	})
+
+	It("should mask the values of secret options", func() {
+		plugin := &greenhousev1alpha1.Plugin{
+			Spec: greenhousev1alpha1.PluginSpec{
+				OptionValues: []greenhousev1alpha1.PluginOptionValue{
+					{Name: "auth.password", ValueFrom: &greenhousev1alpha1.ValueFromSource{Secret: &greenhousev1alpha1.SecretKeyReference{Name: "secret", Key: "password"}}},
+					{Name: "auth.user", Value: test.MustReturnJSONFor("admin")},
+					{Name: "missing.token", ValueFrom: &greenhousev1alpha1.ValueFromSource{Secret: &greenhousev1alpha1.SecretKeyReference{Name: "secret", Key: "token"}}},
+				},
+			},
+		}
+		values := map[string]any{"auth": map[string]any{"password": "s3cr3t", "user": "admin"}}
+		Expect(helm.MaskSecretOptionValues(values, plugin)).To(Equal(map[string]any{"auth": map[string]any{"password": "*****", "user": "admin"}}), "only the values of secret options should be masked")
+	})
+})
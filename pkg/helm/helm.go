@@ -329,6 +329,15 @@ func GetReleaseForHelmChartFromPlugin(_ context.Context, restClientGetter generi
 	return action.NewGet(cfg).Run(plugin.Name)
 }
 
+// GetReleaseHistoryForPlugin returns all revisions of the Helm release for the given Plugin or an error.
+func GetReleaseHistoryForPlugin(restClientGetter genericclioptions.RESTClientGetter, plugin *greenhousev1alpha1.Plugin) ([]*release.Release, error) {
+	cfg, err := newHelmAction(restClientGetter, plugin.Spec.ReleaseNamespace)
+	if err != nil {
+		return nil, err
+	}
+	return action.NewHistory(cfg).Run(plugin.Name)
+}
+
 // TemplateHelmChartFromPlugin returns the rendered manifest or an error.
 func TemplateHelmChartFromPlugin(ctx context.Context, local client.Client, restClientGetter genericclioptions.RESTClientGetter, pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) (*release.Release, error) {
 	helmRelease, err := installRelease(ctx, local, restClientGetter, pluginDefinition, plugin, true)
@@ -338,6 +347,45 @@ func TemplateHelmChartFromPlugin(ctx context.Context, local client.Client, restC
 	return helmRelease, nil
 }
 
+// TemplateHelmChartFromPluginWithoutCluster returns the rendered manifest or an error without accessing a cluster.
+// The default capabilities of Helm are used. Option values referenced from secrets are replaced by a placeholder and the Greenhouse values are omitted.
+func TemplateHelmChartFromPluginWithoutCluster(ctx context.Context, pluginDefinition *greenhousev1alpha1.PluginDefinition, plugin *greenhousev1alpha1.Plugin) (*release.Release, error) {
+	registryClient, err := registry.NewClient(
+		registry.ClientOptDebug(IsHelmDebug),
+		registry.ClientOptEnableCache(true),
+		registry.ClientOptWriter(os.Stderr),
+		registry.ClientOptCredentialsFile(settings.RegistryConfig),
+	)
+	if err != nil {
+		return nil, err
+	}
+	installAction := action.NewInstall(&action.Configuration{RegistryClient: registryClient, Log: debug})
+	installAction.ReleaseName = plugin.Name
+	installAction.Namespace = plugin.Spec.ReleaseNamespace
+	installAction.DependencyUpdate = true
+	installAction.DryRun = true
+	installAction.ClientOnly = true
+	installAction.Description = pluginDefinition.Spec.Version
+
+	helmChart, err := loadHelmChart(&installAction.ChartPathOptions, pluginDefinition.Spec.HelmChart, settings)
+	if err != nil {
+		return nil, err
+	}
+	optionValues := slices.Clone(mergePluginAndPluginOptionValueSlice(pluginDefinition.Spec.Options, plugin.Spec.OptionValues))
+	for idx, val := range optionValues {
+		if val.ValueFrom != nil && val.ValueFrom.Secret != nil {
+			optionValues[idx].Value = &apiextensionsv1.JSON{Raw: []byte(`"secret-placeholder"`)}
+			optionValues[idx].ValueFrom = nil
+		}
+	}
+	helmPluginValues, err := convertFlatValuesToHelmValues(optionValues)
+	if err != nil {
+		return nil, err
+	}
+	helmValues := mergeMaps(mergeMaps(make(map[string]interface{}), helmChart.Values), helmPluginValues)
+	return installAction.RunWithContext(ctx, helmChart, helmValues)
+}
+
 type ChartLoaderFunc func(name string) (*chart.Chart, error)
 
 var ChartLoader ChartLoaderFunc = loader.Load
@@ -373,6 +373,13 @@ func WithSecretData(data map[string][]byte) func(*corev1.Secret) {
 	}
 }
 
+// WithSecretAnnotations sets the annotations of the Secret
+func WithSecretAnnotations(annotations map[string]string) func(*corev1.Secret) {
+	return func(s *corev1.Secret) {
+		s.Annotations = annotations
+	}
+}
+
 // WithSecretNamespace sets the namespace of the Secret
 func WithSecretNamespace(namespace string) func(*corev1.Secret) {
 	return func(s *corev1.Secret) {
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultReconnectInterval is the default interval in which the agent reconnects after the tunnel was closed.
+const DefaultReconnectInterval = 5 * time.Second
+
+// Agent runs in the remote cluster. It dials out to the tunnel Server and forwards all streams opened by the Server to the Target.
+type Agent struct {
+	// ServerURL is the URL of the Greenhouse tunnel server.
+	ServerURL string
+	// Cluster identifies the Greenhouse cluster of the agent.
+	Cluster types.NamespacedName
+	// Token authenticates the agent. It is stored in the Secret of the cluster.
+	Token string
+	// Target is the address of the API server connections are forwarded to.
+	Target string
+	// TLSConfig is used to connect to a ServerURL with the https scheme.
+	TLSConfig *tls.Config
+	// ReconnectInterval is the interval in which the agent reconnects. Defaults to DefaultReconnectInterval.
+	ReconnectInterval time.Duration
+}
+
+// Run keeps the tunnel open until the context is done.
+func (a *Agent) Run(ctx context.Context) {
+	interval := a.ReconnectInterval
+	if interval <= 0 {
+		interval = DefaultReconnectInterval
+	}
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := a.serve(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "tunnel failed, reconnecting", "interval", interval)
+		}
+	}, interval)
+}
+
+// serve opens the tunnel and forwards streams until the tunnel is closed.
+func (a *Agent) serve(ctx context.Context) error {
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return err
+	}
+	streams := make(chan httpstream.Stream)
+	tunnel, err := spdy.NewServerConnectionWithPings(conn, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+		go func() {
+			<-replySent
+			select {
+			case streams <- stream:
+			case <-ctx.Done():
+				_ = stream.Reset() //nolint:errcheck
+			}
+		}()
+		return nil
+	}, pingPeriod)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return fmt.Errorf("failed to create tunnel: %w", err)
+	}
+	defer tunnel.Close() //nolint:errcheck
+	log.FromContext(ctx).Info("tunnel connected", "server", a.ServerURL)
+
+	for {
+		select {
+		case stream := <-streams:
+			go a.forward(ctx, tunnel, stream)
+		case <-tunnel.CloseChan():
+			return fmt.Errorf("tunnel to %s closed", a.ServerURL)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// forward relays the stream to the Target.
+func (a *Agent) forward(ctx context.Context, tunnel httpstream.Connection, stream httpstream.Stream) {
+	defer tunnel.RemoveStreams(stream)
+	defer stream.Reset() //nolint:errcheck
+	target, err := (&net.Dialer{}).DialContext(ctx, "tcp", a.Target)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "failed to connect to target", "target", a.Target)
+		return
+	}
+	defer target.Close() //nolint:errcheck
+	pipe(target, stream)
+}
+
+// dial connects to the tunnel Server and upgrades the connection.
+func (a *Agent) dial(ctx context.Context) (net.Conn, error) {
+	serverURL, err := url.Parse(a.ServerURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	switch serverURL.Scheme {
+	case "https":
+		addr := serverURL.Host
+		if serverURL.Port() == "" {
+			addr = net.JoinHostPort(serverURL.Hostname(), "443")
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: a.TLSConfig}).DialContext(ctx, "tcp", addr)
+	case "http":
+		addr := serverURL.Host
+		if serverURL.Port() == "" {
+			addr = net.JoinHostPort(serverURL.Hostname(), "80")
+		}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return nil, fmt.Errorf("unsupported server URL scheme %q", serverURL.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to tunnel server: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL.JoinPath(AgentPath).String(), http.NoBody)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, err
+	}
+	req.Header.Set(httpstream.HeaderConnection, httpstream.HeaderUpgrade)
+	req.Header.Set(httpstream.HeaderUpgrade, spdy.HeaderSpdy31)
+	req.Header.Set(ClusterHeader, ClusterKey(a.Cluster))
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to send upgrade request: %w", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("failed to read upgrade response: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		_ = conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("tunnel server rejected agent: %s", resp.Status)
+	}
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
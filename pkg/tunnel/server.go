@@ -0,0 +1,402 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package tunnel
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+)
+
+var agentConnected = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "greenhouse_tunnel_agent_connected",
+		Help: "Indicates whether the tunnel agent of a cluster is connected",
+	},
+	[]string{"cluster", "namespace"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(agentConnected)
+}
+
+// Server accepts tunnels opened by agents and proxies HTTP CONNECT requests of clients through them.
+// Agents authenticate with the token stored in the kubeconfig Secret of their cluster, which must use the reverse-tunnel access mode.
+// Clients identify the cluster by the proxy user "<namespace>/<name>" of the proxy URL
+// and authenticate with the proxy token stored in the Secret of the cluster as password.
+// The Server runs on every replica. The replica an agent is connected to records its advertise address in the Secret
+// of the cluster and the other replicas forward proxy requests for the cluster to it.
+// The Server is served over TLS if a TLS configuration is given. Replicas are expected to share the same certificate.
+type Server struct {
+	client           client.Client
+	bindAddress      string
+	advertiseAddress string
+	tlsConfig        *tls.Config
+
+	mu     sync.RWMutex
+	agents map[types.NamespacedName]httpstream.Connection
+}
+
+// NewServer returns a tunnel Server listening on the given address once started.
+// The advertise address is the address other replicas reach this Server at, forwarding is disabled if empty.
+// The Server serves plain HTTP if the TLS configuration is nil.
+func NewServer(c client.Client, bindAddress, advertiseAddress string, tlsConfig *tls.Config) *Server {
+	return &Server{
+		client:           c,
+		bindAddress:      bindAddress,
+		advertiseAddress: advertiseAddress,
+		tlsConfig:        tlsConfig,
+		agents:           make(map[types.NamespacedName]httpstream.Connection),
+	}
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface.
+// Agents and clients may connect to any replica, so the Server must run on all of them.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves tunnels until the context is done.
+func (s *Server) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.bindAddress,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx) //nolint:errcheck
+	}()
+	var err error
+	if s.tlsConfig != nil {
+		// Agent and client connections are hijacked, which is not possible with HTTP/2.
+		srv.TLSConfig = s.tlsConfig.Clone()
+		srv.TLSConfig.NextProtos = []string{"http/1.1"}
+		srv.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+		log.FromContext(ctx).Info("starting tunnel server", "address", s.bindAddress)
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		log.FromContext(ctx).Info("starting tunnel server without TLS, tokens are transmitted in plain text", "address", s.bindAddress)
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// IsConnected returns whether an agent of the cluster is connected.
+func (s *Server) IsConnected(cluster types.NamespacedName) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.agents[cluster]
+	return ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.Method == http.MethodConnect:
+		s.handleConnect(w, req)
+	case req.URL.Path == AgentPath:
+		s.handleAgent(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// handleAgent upgrades the request of an agent to a SPDY connection used to open streams to the remote cluster.
+// The roles are reversed: the Server creates the streams, so it acts as the client of the SPDY connection.
+func (s *Server) handleAgent(w http.ResponseWriter, req *http.Request) {
+	logger := log.FromContext(req.Context())
+	cluster, err := ParseClusterKey(req.Header.Get(ClusterHeader))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logger = logger.WithValues("cluster", cluster.Name, "namespace", cluster.Namespace)
+	if !httpstream.IsUpgradeRequest(req) || !strings.EqualFold(req.Header.Get(httpstream.HeaderUpgrade), spdy.HeaderSpdy31) {
+		http.Error(w, "expected upgrade to "+spdy.HeaderSpdy31, http.StatusBadRequest)
+		return
+	}
+	if _, err := s.authenticate(req.Context(), cluster, greenhouseapis.TunnelTokenKey, strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")); err != nil {
+		logger.Info("rejected tunnel agent", "error", err.Error())
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "unable to upgrade: connection does not support hijacking", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add(httpstream.HeaderConnection, httpstream.HeaderUpgrade)
+	w.Header().Add(httpstream.HeaderUpgrade, spdy.HeaderSpdy31)
+	w.WriteHeader(http.StatusSwitchingProtocols)
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		logger.Error(err, "failed to hijack agent connection")
+		return
+	}
+	tunnel, err := spdy.NewClientConnectionWithPings(&bufferedConn{Conn: conn, reader: bufrw.Reader}, pingPeriod)
+	if err != nil {
+		logger.Error(err, "failed to create tunnel")
+		_ = conn.Close() //nolint:errcheck
+		return
+	}
+	s.register(cluster, tunnel)
+	logger.Info("tunnel agent connected")
+	if err := s.advertise(req.Context(), cluster); err != nil {
+		logger.Error(err, "failed to advertise tunnel server address")
+	}
+	go func() {
+		<-tunnel.CloseChan()
+		s.unregister(cluster, tunnel)
+		logger.Info("tunnel agent disconnected")
+	}()
+}
+
+// handleConnect opens a stream through the tunnel of the cluster and relays the connection of the client.
+func (s *Server) handleConnect(w http.ResponseWriter, req *http.Request) {
+	user, password, ok := parseProxyAuthorization(req.Header.Get("Proxy-Authorization"))
+	if !ok || password == "" {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="greenhouse-tunnel"`)
+		http.Error(w, "proxy authorization is missing", http.StatusProxyAuthRequired)
+		return
+	}
+	cluster, err := ParseClusterKey(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	secret, err := s.authenticate(req.Context(), cluster, greenhouseapis.TunnelProxyTokenKey, password)
+	if err != nil {
+		log.FromContext(req.Context()).Info("rejected tunnel proxy client", "cluster", cluster.Name, "namespace", cluster.Namespace, "error", err.Error())
+		w.Header().Set("Proxy-Authenticate", `Basic realm="greenhouse-tunnel"`)
+		http.Error(w, "unauthorized", http.StatusProxyAuthRequired)
+		return
+	}
+	s.mu.RLock()
+	tunnel, ok := s.agents[cluster]
+	s.mu.RUnlock()
+	if !ok {
+		// The agent may be connected to another replica, which is only asked once to avoid forwarding loops.
+		address := secret.GetAnnotations()[greenhouseapis.SecretTunnelServerAddressAnnotation]
+		if req.Header.Get(ForwardedHeader) == "" && address != "" && address != s.advertiseAddress {
+			s.forward(w, req, address)
+			return
+		}
+		http.Error(w, "no tunnel agent connected for cluster "+ClusterKey(cluster), http.StatusBadGateway)
+		return
+	}
+	stream, err := tunnel.CreateStream(http.Header{})
+	if err != nil {
+		http.Error(w, "failed to open stream through tunnel: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer tunnel.RemoveStreams(stream)
+	defer stream.Reset() //nolint:errcheck
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "unable to proxy: connection does not support hijacking", http.StatusInternalServerError)
+		return
+	}
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		log.FromContext(req.Context()).Error(err, "failed to hijack client connection")
+		return
+	}
+	defer conn.Close() //nolint:errcheck
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		return
+	}
+	pipe(&bufferedConn{Conn: conn, reader: bufrw.Reader}, stream)
+}
+
+// forward relays the CONNECT request of a client to the replica at the address the agent of the cluster is connected to.
+func (s *Server) forward(w http.ResponseWriter, req *http.Request, address string) {
+	upstream, err := s.dialReplica(req.Context(), address)
+	if err != nil {
+		http.Error(w, "failed to reach tunnel server replica: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer upstream.Close() //nolint:errcheck
+
+	forwardReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: req.Host},
+		Host:   req.Host,
+		Header: req.Header.Clone(),
+	}
+	forwardReq.Header.Set(ForwardedHeader, "true")
+	if err := forwardReq.Write(upstream); err != nil {
+		http.Error(w, "failed to forward request to tunnel server replica: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	upstreamReader := bufio.NewReader(upstream)
+	resp, err := http.ReadResponse(upstreamReader, forwardReq)
+	if err != nil {
+		http.Error(w, "failed to read response of tunnel server replica: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body) //nolint:errcheck
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "unable to proxy: connection does not support hijacking", http.StatusInternalServerError)
+		return
+	}
+	conn, bufrw, err := hijacker.Hijack()
+	if err != nil {
+		log.FromContext(req.Context()).Error(err, "failed to hijack client connection")
+		return
+	}
+	defer conn.Close() //nolint:errcheck
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n")); err != nil {
+		return
+	}
+	pipe(&bufferedConn{Conn: conn, reader: bufrw.Reader}, &bufferedConn{Conn: upstream, reader: upstreamReader})
+}
+
+// dialReplica connects to the replica at the address, using TLS if the Server is served over TLS.
+// Replicas are addressed by their pod IP, which is not covered by the certificate,
+// so the certificate of the replica is verified to be the certificate of this Server instead.
+func (s *Server) dialReplica(ctx context.Context, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if s.tlsConfig == nil {
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	own, err := s.certificate()
+	if err != nil {
+		return nil, err
+	}
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			NextProtos: []string{"http/1.1"},
+			// The peer certificate is verified in VerifyConnection.
+			InsecureSkipVerify: true, //nolint:gosec
+			VerifyConnection: func(state tls.ConnectionState) error {
+				if len(state.PeerCertificates) == 0 || !bytes.Equal(state.PeerCertificates[0].Raw, own.Certificate[0]) {
+					return errors.New("certificate of tunnel server replica does not match")
+				}
+				return nil
+			},
+		},
+	}
+	return tlsDialer.DialContext(ctx, "tcp", address)
+}
+
+// certificate returns the current serving certificate of the Server.
+func (s *Server) certificate() (*tls.Certificate, error) {
+	if s.tlsConfig.GetCertificate != nil {
+		cert, err := s.tlsConfig.GetCertificate(&tls.ClientHelloInfo{})
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil && len(cert.Certificate) > 0 {
+			return cert, nil
+		}
+	}
+	if len(s.tlsConfig.Certificates) > 0 && len(s.tlsConfig.Certificates[0].Certificate) > 0 {
+		return &s.tlsConfig.Certificates[0], nil
+	}
+	return nil, errors.New("no serving certificate configured")
+}
+
+// authenticate compares the token with the token stored under the key in the Secret of the cluster in constant time.
+// Only kubeconfig Secrets of existing clusters with the reverse-tunnel access mode are considered,
+// so arbitrary Secrets of the namespace cannot be used to open tunnels.
+// It returns the Secret if the token is valid.
+func (s *Server) authenticate(ctx context.Context, cluster types.NamespacedName, key, token string) (*corev1.Secret, error) {
+	secret := new(corev1.Secret)
+	if err := s.client.Get(ctx, cluster, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret of cluster: %w", err)
+	}
+	if secret.Type != greenhouseapis.SecretTypeKubeConfig {
+		return nil, fmt.Errorf("secret of cluster has type %s instead of %s", secret.Type, greenhouseapis.SecretTypeKubeConfig)
+	}
+	clusterObj := new(greenhousev1alpha1.Cluster)
+	if err := s.client.Get(ctx, cluster, clusterObj); err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	if clusterObj.Spec.AccessMode != greenhousev1alpha1.ClusterAccessModeReverseTunnel {
+		return nil, fmt.Errorf("cluster has access mode %s instead of %s", clusterObj.Spec.AccessMode, greenhousev1alpha1.ClusterAccessModeReverseTunnel)
+	}
+	expected := secret.Data[key]
+	if len(expected) == 0 || subtle.ConstantTimeCompare(expected, []byte(token)) != 1 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return secret, nil
+}
+
+// advertise records the address of this replica in the Secret of the cluster, so other replicas forward proxy requests to it.
+func (s *Server) advertise(ctx context.Context, cluster types.NamespacedName) error {
+	if s.advertiseAddress == "" {
+		return nil
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Namespace, Name: cluster.Name}}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, greenhouseapis.SecretTunnelServerAddressAnnotation, s.advertiseAddress)
+	return s.client.Patch(ctx, secret, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
+
+// register stores the tunnel of the cluster and closes a previous tunnel of the same cluster.
+func (s *Server) register(cluster types.NamespacedName, tunnel httpstream.Connection) {
+	s.mu.Lock()
+	previous, ok := s.agents[cluster]
+	s.agents[cluster] = tunnel
+	s.mu.Unlock()
+	if ok {
+		_ = previous.Close() //nolint:errcheck
+	}
+	agentConnected.WithLabelValues(cluster.Name, cluster.Namespace).Set(1)
+}
+
+// unregister removes the tunnel of the cluster unless it has been replaced in the meantime.
+func (s *Server) unregister(cluster types.NamespacedName, tunnel httpstream.Connection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.agents[cluster] != tunnel {
+		return
+	}
+	delete(s.agents, cluster)
+	agentConnected.WithLabelValues(cluster.Name, cluster.Namespace).Set(0)
+}
+
+// parseProxyAuthorization parses the basic credentials of the Proxy-Authorization header.
+func parseProxyAuthorization(header string) (username, password string, ok bool) {
+	req := &http.Request{Header: http.Header{"Authorization": []string{header}}}
+	return req.BasicAuth()
+}
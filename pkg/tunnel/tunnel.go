@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tunnel implements the reverse tunnel used to access clusters with the reverse-tunnel access mode.
+// An agent running in the remote cluster dials out to the tunnel Server and keeps a multiplexed SPDY connection open.
+// Clients of the cluster connect through the Server with HTTP CONNECT, the Server opens a stream through the tunnel
+// and the agent forwards it to the API server of the remote cluster. TLS is terminated by the remote API server,
+// so the tunnel only relays encrypted traffic.
+package tunnel
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// AgentPath is the path agents register their tunnel at.
+	AgentPath = "/agent"
+	// ClusterHeader identifies the cluster of an agent by "<namespace>/<name>".
+	ClusterHeader = "X-Greenhouse-Cluster"
+	// ForwardedHeader marks a proxy request forwarded by another replica of the Server, so it is not forwarded again.
+	ForwardedHeader = "X-Greenhouse-Tunnel-Forwarded"
+	// pingPeriod is the interval of pings keeping the tunnel alive and detecting broken connections.
+	pingPeriod = 30 * time.Second
+)
+
+// ClusterKey returns the identifier of a cluster used by the agent and in the proxy URL.
+func ClusterKey(cluster types.NamespacedName) string {
+	return cluster.Namespace + "/" + cluster.Name
+}
+
+// ParseClusterKey parses a cluster identifier in the form "<namespace>/<name>".
+func ParseClusterKey(key string) (types.NamespacedName, error) {
+	namespace, name, ok := strings.Cut(key, "/")
+	if !ok || namespace == "" || name == "" {
+		return types.NamespacedName{}, fmt.Errorf("invalid cluster %q, expected <namespace>/<name>", key)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// bufferedConn is a net.Conn reading through a bufio.Reader which may contain data read ahead during the upgrade.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// pipe copies data in both directions until one of them is done. The caller closes both connections afterwards.
+func pipe(a, b io.ReadWriter) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(a, b) //nolint:errcheck
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(b, a) //nolint:errcheck
+		done <- struct{}{}
+	}()
+	<-done
+}
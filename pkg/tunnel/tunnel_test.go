@@ -0,0 +1,351 @@
+// SPDX-FileCopyrightText: 2024 SAP SE or an SAP affiliate company and Greenhouse contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package tunnel
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	greenhouseapis "github.com/cloudoperators/greenhouse/pkg/apis"
+	greenhousev1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
+	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+)
+
+// TestTunnel tests that a client created from the Secret of a cluster transparently reaches the API server through the tunnel of an in-process agent.
+func TestTunnel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/version" {
+			http.NotFound(rw, req)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"major":"1","minor":"32","gitVersion":"v1.32.2"}`)) //nolint:errcheck
+	}))
+	defer apiServer.Close()
+
+	cluster := types.NamespacedName{Namespace: "test-org", Name: "test-cluster"}
+	secret := clusterSecret(t, cluster, apiServer)
+	server := NewServer(fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(secret, tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeReverseTunnel)).Build(), "", "", nil)
+	tunnelServer := httptest.NewServer(server)
+	defer tunnelServer.Close()
+
+	proxyURL, err := url.Parse(tunnelServer.URL)
+	if err != nil {
+		t.Fatalf("failed to parse tunnel server URL: %v", err)
+	}
+	proxyURL.User = url.User(ClusterKey(cluster))
+	secret.Annotations = map[string]string{greenhouseapis.SecretTunnelProxyURLAnnotation: proxyURL.String()}
+
+	t.Run("rejects agent with invalid token", func(t *testing.T) {
+		agent := &Agent{ServerURL: tunnelServer.URL, Cluster: cluster, Token: "invalid", Target: apiServer.Listener.Addr().String()}
+		if err := agent.serve(ctx); err == nil {
+			t.Error("expected the agent to be rejected")
+		}
+		if server.IsConnected(cluster) {
+			t.Error("expected no agent to be connected")
+		}
+	})
+
+	t.Run("rejects client without connected agent", func(t *testing.T) {
+		if _, err := serverVersion(secret); err == nil {
+			t.Error("expected an error without connected agent")
+		}
+	})
+
+	t.Run("proxies client through the tunnel", func(t *testing.T) {
+		agent := &Agent{
+			ServerURL:         tunnelServer.URL,
+			Cluster:           cluster,
+			Token:             string(secret.Data[greenhouseapis.TunnelTokenKey]),
+			Target:            apiServer.Listener.Addr().String(),
+			ReconnectInterval: 100 * time.Millisecond,
+		}
+		go agent.Run(ctx)
+		deadline := time.Now().Add(10 * time.Second)
+		for !server.IsConnected(cluster) {
+			if time.Now().After(deadline) {
+				t.Fatal("agent did not connect")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		// Every client opens a new connection, so multiple streams are multiplexed over the tunnel.
+		for range 3 {
+			version, err := serverVersion(secret)
+			if err != nil {
+				t.Fatalf("failed to get server version through tunnel: %v", err)
+			}
+			if version != "v1.32.2" {
+				t.Errorf("expected version v1.32.2, got %s", version)
+			}
+		}
+	})
+
+	t.Run("rejects client with invalid proxy token", func(t *testing.T) {
+		invalidSecret := secret.DeepCopy()
+		invalidSecret.Data[greenhouseapis.TunnelProxyTokenKey] = []byte("invalid")
+		if _, err := serverVersion(invalidSecret); err == nil {
+			t.Error("expected the client to be rejected")
+		}
+	})
+
+	t.Run("rejects client without proxy token", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+		resp, err := client.Get(apiServer.URL + "/version")
+		if err == nil {
+			_ = resp.Body.Close() //nolint:errcheck
+			t.Error("expected the CONNECT request to be rejected")
+		}
+	})
+}
+
+// TestTunnelForwarding tests that a replica of the Server without the agent forwards clients to the replica the agent is connected to.
+// The replicas are served over TLS with a certificate of a CA distinct from the CA of the API server.
+func TestTunnelForwarding(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	apiServer := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"major":"1","minor":"32","gitVersion":"v1.32.2"}`)) //nolint:errcheck
+	}))
+	defer apiServer.Close()
+
+	cluster := types.NamespacedName{Namespace: "test-org", Name: "test-cluster"}
+	tlsConfig, caPEM := tunnelTLSConfig(t)
+	secret := clusterSecret(t, cluster, apiServer)
+	secret.Data[greenhouseapis.TunnelProxyCAKey] = caPEM
+	c := fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(secret.DeepCopy(), tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeReverseTunnel)).Build()
+
+	agentReplica := httptest.NewUnstartedServer(nil)
+	agentReplica.Config.Handler = NewServer(c, "", agentReplica.Listener.Addr().String(), tlsConfig)
+	agentReplica.TLS = tlsConfig.Clone()
+	agentReplica.StartTLS()
+	defer agentReplica.Close()
+	clientReplica := httptest.NewUnstartedServer(NewServer(c, "", "127.0.0.1:0", tlsConfig))
+	clientReplica.TLS = tlsConfig.Clone()
+	clientReplica.StartTLS()
+	defer clientReplica.Close()
+
+	caPool := x509.NewCertPool()
+	caPool.AppendCertsFromPEM(caPEM)
+	agent := &Agent{
+		ServerURL:         agentReplica.URL,
+		Cluster:           cluster,
+		Token:             string(secret.Data[greenhouseapis.TunnelTokenKey]),
+		Target:            apiServer.Listener.Addr().String(),
+		TLSConfig:         &tls.Config{RootCAs: caPool, MinVersion: tls.VersionTLS12},
+		ReconnectInterval: 100 * time.Millisecond,
+	}
+	go agent.Run(ctx)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if err := c.Get(ctx, cluster, secret); err != nil {
+			t.Fatalf("failed to get secret: %v", err)
+		}
+		if secret.Annotations[greenhouseapis.SecretTunnelServerAddressAnnotation] == agentReplica.Listener.Addr().String() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("agent replica did not advertise its address")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	proxyURL, err := url.Parse(clientReplica.URL)
+	if err != nil {
+		t.Fatalf("failed to parse tunnel server URL: %v", err)
+	}
+	proxyURL.User = url.User(ClusterKey(cluster))
+	secret.Annotations[greenhouseapis.SecretTunnelProxyURLAnnotation] = proxyURL.String()
+	version, err := serverVersion(secret)
+	if err != nil {
+		t.Fatalf("failed to get server version through forwarded tunnel: %v", err)
+	}
+	if version != "v1.32.2" {
+		t.Errorf("expected version v1.32.2, got %s", version)
+	}
+}
+
+// TestAuthenticate tests that only tokens of kubeconfig Secrets of clusters with the reverse-tunnel access mode are accepted.
+func TestAuthenticate(t *testing.T) {
+	cluster := types.NamespacedName{Namespace: "test-org", Name: "test-cluster"}
+	apiServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer apiServer.Close()
+
+	opaqueSecret := clusterSecret(t, cluster, apiServer)
+	opaqueSecret.Type = corev1.SecretTypeOpaque
+
+	tests := []struct {
+		name      string
+		objects   []client.Object
+		token     string
+		expectErr bool
+	}{
+		{
+			name:    "accepts token of cluster with reverse-tunnel access mode",
+			objects: []client.Object{clusterSecret(t, cluster, apiServer), tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeReverseTunnel)},
+			token:   "secret-token",
+		},
+		{
+			name:      "rejects invalid token",
+			objects:   []client.Object{clusterSecret(t, cluster, apiServer), tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeReverseTunnel)},
+			token:     "invalid",
+			expectErr: true,
+		},
+		{
+			name:      "rejects secret without kubeconfig type",
+			objects:   []client.Object{opaqueSecret, tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeReverseTunnel)},
+			token:     "secret-token",
+			expectErr: true,
+		},
+		{
+			name:      "rejects secret without cluster",
+			objects:   []client.Object{clusterSecret(t, cluster, apiServer)},
+			token:     "secret-token",
+			expectErr: true,
+		},
+		{
+			name:      "rejects cluster with direct access mode",
+			objects:   []client.Object{clusterSecret(t, cluster, apiServer), tunnelCluster(cluster, greenhousev1alpha1.ClusterAccessModeDirect)},
+			token:     "secret-token",
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(fake.NewClientBuilder().WithScheme(clientutil.Scheme).WithObjects(tt.objects...).Build(), "", "", nil)
+			_, err := server.authenticate(context.Background(), cluster, greenhouseapis.TunnelTokenKey, tt.token)
+			if tt.expectErr && err == nil {
+				t.Error("expected an error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// tunnelCluster returns a Cluster with the given access mode.
+func tunnelCluster(cluster types.NamespacedName, accessMode greenhousev1alpha1.ClusterAccessMode) *greenhousev1alpha1.Cluster {
+	return &greenhousev1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+		Spec:       greenhousev1alpha1.ClusterSpec{AccessMode: accessMode},
+	}
+}
+
+// tunnelTLSConfig returns a TLS configuration with a certificate for 127.0.0.1 issued by a new CA and the PEM encoded CA certificate.
+func tunnelTLSConfig(t *testing.T) (tlsConfig *tls.Config, caPEM []byte) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "greenhouse-tunnel-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "greenhouse-tunnel"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	tlsConfig = &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	return tlsConfig, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+}
+
+// serverVersion requests the version of the cluster with a client created from its Secret.
+func serverVersion(secret *corev1.Secret) (string, error) {
+	restClientGetter, err := clientutil.NewRestClientGetterFromSecret(secret, "")
+	if err != nil {
+		return "", err
+	}
+	dc, err := restClientGetter.ToDiscoveryClient()
+	if err != nil {
+		return "", err
+	}
+	info, err := dc.ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+// clusterSecret returns the Secret of a cluster with a kubeconfig for the API server and the tunnel tokens.
+func clusterSecret(t *testing.T, cluster types.NamespacedName, apiServer *httptest.Server) *corev1.Secret {
+	t.Helper()
+	kubeConfig := clientcmdapi.NewConfig()
+	kubeConfig.Clusters[cluster.Name] = &clientcmdapi.Cluster{
+		Server:                   apiServer.URL,
+		CertificateAuthorityData: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: apiServer.Certificate().Raw}),
+	}
+	kubeConfig.AuthInfos[cluster.Name] = &clientcmdapi.AuthInfo{Token: "token"}
+	kubeConfig.Contexts[cluster.Name] = &clientcmdapi.Context{Cluster: cluster.Name, AuthInfo: cluster.Name}
+	kubeConfig.CurrentContext = cluster.Name
+	kubeConfigBytes, err := clientcmd.Write(*kubeConfig)
+	if err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.Name, Namespace: cluster.Namespace},
+		Type:       greenhouseapis.SecretTypeKubeConfig,
+		Data: map[string][]byte{
+			greenhouseapis.KubeConfigKey:       kubeConfigBytes,
+			greenhouseapis.TunnelTokenKey:      []byte("secret-token"),
+			greenhouseapis.TunnelProxyTokenKey: []byte("secret-proxy-token"),
+		},
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"fmt"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	greenhouseapisv1alpha1 "github.com/cloudoperators/greenhouse/pkg/apis/greenhouse/v1alpha1"
 	"github.com/cloudoperators/greenhouse/pkg/clientutil"
+	"github.com/cloudoperators/greenhouse/pkg/lifecycle"
 	"github.com/cloudoperators/greenhouse/pkg/scim"
 )
 
@@ -50,3 +52,46 @@ func GreenhouseSCIMConfigToSCIMConfig(ctx context.Context, k8sClient client.Clie
 
 	return cfg, nil
 }
+
+// CheckSCIMAPIAvailability verifies that the SCIM API configured for the organization can be accessed and knows the admin group of the organization.
+// The result is returned as the SCIMAPIAvailable condition of the organization.
+func CheckSCIMAPIAvailability(ctx context.Context, k8sClient client.Client, org *greenhouseapisv1alpha1.Organization) greenhouseapisv1alpha1.Condition {
+	if org.Spec.Authentication == nil || org.Spec.Authentication.SCIMConfig == nil {
+		// SCIM Config is optional.
+		return greenhouseapisv1alpha1.UnknownCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMConfigErrorReason, "SCIM Config not provided")
+	}
+
+	if org.Spec.MappedOrgAdminIDPGroup == "" {
+		return greenhouseapisv1alpha1.FalseCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMRequestFailedReason, ".Spec.MappedOrgAdminIDPGroup is not set in Organization")
+	}
+
+	namespace := org.Name
+	scimConfig := org.Spec.Authentication.SCIMConfig
+
+	config, err := GreenhouseSCIMConfigToSCIMConfig(ctx, k8sClient, scimConfig, namespace)
+	if err != nil {
+		return greenhouseapisv1alpha1.FalseCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMConfigErrorReason, err.Error())
+	}
+	logger := log.FromContext(ctx)
+	scimClient, err := scim.NewSCIMClient(logger, config)
+	if err != nil {
+		return greenhouseapisv1alpha1.FalseCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMRequestFailedReason, "Failed to create SCIM client")
+	}
+
+	// verify that the SCIM API can be accessed
+	opts := &scim.QueryOptions{
+		Filter:             scim.GroupFilterByDisplayName(org.Spec.MappedOrgAdminIDPGroup),
+		ExcludedAttributes: scim.SetAttributes(scim.AttrMembers),
+	}
+
+	groups, err := scimClient.GetGroups(ctx, opts)
+	if err != nil {
+		logger.Error(err, "Failed to request data from SCIM API")
+		return greenhouseapisv1alpha1.FalseCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMRequestFailedReason, "Failed to request data from SCIM API")
+	}
+	if len(groups) == 0 {
+		return greenhouseapisv1alpha1.FalseCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, greenhouseapisv1alpha1.SCIMRequestFailedReason, org.Spec.MappedOrgAdminIDPGroup+" Group not found in SCIM API")
+	}
+
+	return greenhouseapisv1alpha1.TrueCondition(greenhouseapisv1alpha1.SCIMAPIAvailableCondition, lifecycle.CreatedReason, "SCIM API is available")
+}